@@ -1,5 +1,7 @@
 package commontest
 
+import "strings"
+
 // TestContext is an abstraction used by ReaderTestSuite and WriterTestSuite.
 type TestContext interface {
 	// JSONData returns either (for readers) the input data that was passed in when the TestContext
@@ -35,6 +37,13 @@ const (
 	SkipValueVariant ValueVariant = "skip:"
 )
 
+// IsTypeless returns true if the variant reads or skips a value without regard to its type: that is,
+// if it is UntypedVariant or SkipValueVariant, or a variation of one of them that a ValueTestFactory
+// defines, whose name ends with the same text (such as "lazy skip:").
+func (v ValueVariant) IsTypeless() bool {
+	return strings.HasSuffix(string(v), string(UntypedVariant)) || strings.HasSuffix(string(v), string(SkipValueVariant))
+}
+
 // ValueTestFactory is an interface for producing specific reader/writer test actions. To test any
 // reader or writer with ReaderTestSuite or WriterTestSuite, provide an implementation of this
 // interface that performs the specified actions.
@@ -63,6 +72,8 @@ const (
 	ObjectValue ValueKind = iota
 )
 
+// NumberKind mirrors jreader.NumberKind, which this package cannot use without depending on the
+// reader it tests; keep the two in step.
 type NumberKind int
 
 const (
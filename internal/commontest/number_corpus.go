@@ -0,0 +1,78 @@
+package commontest
+
+import (
+	"strconv"
+)
+
+// NumberCase is an entry in NumberCorpus. It describes the text of a JSON number and the results
+// that reading it as each Go numeric type should produce.
+type NumberCase struct {
+	// Text is the JSON number as it appears in the input.
+	Text string
+
+	// Int64 is the expected result of reading the number as an int64, if Int64OK is true; if
+	// Int64OK is false, reading it as an int64 should fail.
+	Int64   int64
+	Int64OK bool
+
+	// UInt64 is the expected result of reading the number as a uint64, if UInt64OK is true; if
+	// UInt64OK is false, reading it as a uint64 should fail.
+	UInt64   uint64
+	UInt64OK bool
+
+	// Float64 is the expected result of reading the number as a float64, if Float64OK is true; if
+	// Float64OK is false, reading it as a float64 should fail.
+	Float64   float64
+	Float64OK bool
+}
+
+// numberCorpusTexts are grouped by the part of the number parsing logic that they exercise.
+var numberCorpusTexts = []string{ //nolint:gochecknoglobals
+	// small integers and signed zero
+	"0", "-0", "1", "-1", "42", "-42", "0.0", "-0.0", "1.0",
+
+	// int64 boundaries
+	"9223372036854775807", "-9223372036854775808", "9223372036854775808", "-9223372036854775809",
+
+	// uint64 boundaries, at and around 20 digits
+	"18446744073709551615", "18446744073709551616", "10000000000000000000", "99999999999999999999",
+	"100000000000000000000",
+
+	// integers written with exponents or fractions
+	"1e2", "1E2", "1e+2", "100e-2", "1.5", "-1.5", "1e19", "1e20",
+
+	// float64 extremes
+	"1.7976931348623157e308", "-1.7976931348623157e308", "1.7976931348623159e308", "1e309", "-1e309",
+	"2.2250738585072014e-308", "2.2250738585072011e-308", "1e-309", "5e-324", "4.9406564584124654e-324",
+	"2.4703282292062328e-324", "2.4703282292062327e-324", "1e-400", "-1e-400",
+
+	// decimals that are not exactly representable
+	"0.1", "0.2", "0.3", "1e23", "8.533e+68", "4.1006e-184", "9.998e+307", "9.9538452227e-280",
+
+	// mantissas too long for the fast path, including halfway cases that need the slow path
+	"0.1000000000000000055511151231257827021181583404541015625",
+	"1.00000000000000011102230246251565404236316680908203125",
+	"1.00000000000000011102230246251565404236316680908203124",
+	"9007199254740993", "9007199254740993.0000000000000000001", "123456789012345678901234567890",
+	"0.000000000000000000000000000000000000000000001234567890123456789012345",
+}
+
+// NumberCorpus returns a list of JSON numbers covering edge cases of integer and floating-point
+// parsing, with expected results derived from the strconv package. A number that strconv cannot
+// parse as a given type, including a float64 that overflows to infinity, is expected to cause an
+// error when read as that type.
+func NumberCorpus() []NumberCase {
+	ret := make([]NumberCase, 0, len(numberCorpusTexts))
+	for _, text := range numberCorpusTexts {
+		c := NumberCase{Text: text}
+		var err error
+		c.Int64, err = strconv.ParseInt(text, 10, 64)
+		c.Int64OK = err == nil
+		c.UInt64, err = strconv.ParseUint(text, 10, 64)
+		c.UInt64OK = err == nil
+		c.Float64, err = strconv.ParseFloat(text, 64)
+		c.Float64OK = err == nil
+		ret = append(ret, c)
+	}
+	return ret
+}
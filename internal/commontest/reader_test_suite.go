@@ -36,7 +36,7 @@ func (s ReaderTestSuite) Run(t *testing.T) {
 	}
 	var testDefs testDefs
 	testDefs = append(testDefs, tf.MakeAllValueTests()...)
-	//testDefs = append(testDefs, tf.MakeAllReadErrorTests()...)
+	testDefs = append(testDefs, tf.MakeAllReadErrorTests()...)
 	whitespaceOptions := MakeWhitespaceOptions()
 	whitespaceOptions[""] = ""
 	for _, td := range testDefs {
@@ -124,7 +124,7 @@ func (f testFactory) makeScalarValueReadErrorTests() testDefs {
 			testAction := f.valueTestFactory.Value(tv.value, variant)
 
 			// error: want a value, got a value of some other type
-			if v != UntypedVariant && v != SkipValueVariant {
+			if !v.IsTypeless() {
 				for _, wrongValue := range f.makeScalarValues(false) {
 					wv := wrongValue
 					if wv.value.Kind == tv.value.Kind {
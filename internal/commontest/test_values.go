@@ -67,6 +67,16 @@ func makeNumberTestValues(encodingBehavior encodingBehavior) []testValue {
 	return ret
 }
 
+// NumberTestEncodings returns the JSON encodings of the standard number test values, in the form
+// that is used for parsing tests.
+func NumberTestEncodings() []string {
+	var ret []string
+	for _, v := range makeNumberTestValues(encodingBehavior{forParsing: true}) {
+		ret = append(ret, v.encoding)
+	}
+	return ret
+}
+
 func makeStringTestValues(encodingBehavior encodingBehavior, allPermutations bool) []testValue {
 	base := []stringTestValueBase{
 		{name: "empty", val: "", encoding: `""`},
@@ -0,0 +1,256 @@
+package jreader
+
+import (
+	"bytes"
+	"strconv"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// IsCanonical checks whether a JSON document is in canonical form, as is required before signing
+// or verifying the signature of a document in a scheme such as the JSON Canonicalization Scheme
+// (RFC 8785). A canonical document:
+//
+//   - has no whitespace outside of strings;
+//   - has the properties of each object in strictly ascending order of their names, comparing the
+//     decoded names as sequences of UTF-16 code units as RFC 8785 does, so that no name appears
+//     more than once;
+//   - writes each string with the fewest escape sequences: only a quote, a backslash, and control
+//     characters are escaped, a control character with a two-character escape such as \n is
+//     escaped that way, and any other control character is written as \u00xx with lowercase hex
+//     digits;
+//   - writes each number in the form that ECMAScript uses to write a number: without a plus sign,
+//     leading or trailing zeros, or "-0"; as an integer if it is an integer of at most 21 digits;
+//     as a decimal fraction if it is at least 1e-6 in magnitude and less than 1e21; and otherwise
+//     with one digit before the decimal point and an exponent such as "e+21" or "e-7".
+//
+// Unlike RFC 8785, numbers are checked as they are written, not after conversion to a float64, so
+// a number with more digits than a float64 can hold is not rejected.
+//
+// The document is read once, with a Reader. The return value is true and nil if the document is
+// canonical. If it is well-formed but not canonical, the return value is false and a
+// CanonicalFormError that describes the first problem. If it is not well-formed JSON, or has any
+// data after the value, the return value is false and the parsing error.
+func IsCanonical(data []byte) (bool, error) {
+	r := NewReader(data)
+	c := canonicalChecker{r: &r, data: data}
+	c.value()
+	if r.Error() != nil {
+		return false, r.Error()
+	}
+	if err := r.RequireEOF(); err != nil {
+		return false, err
+	}
+	if c.err == nil && c.end < len(data) {
+		c.err = CanonicalFormError{Message: errMsgNotCanonicalSpace, Offset: c.end}
+	}
+	if c.err != nil {
+		return false, c.err
+	}
+	return true, nil
+}
+
+// canonicalChecker reads a document with a Reader and checks each token at the position where the
+// Reader found it. After the first problem, it only reads the rest of the document, so that a
+// parsing error later on is still reported instead.
+type canonicalChecker struct {
+	r       *Reader
+	data    []byte
+	end     int    // the position after the last token that was checked
+	err     error  // the first problem that was found
+	scratch []byte // for decoding property names
+}
+
+func (c *canonicalChecker) fail(message string, offset int) {
+	if c.err == nil {
+		c.err = CanonicalFormError{Message: message, Offset: offset}
+	}
+}
+
+// at checks that the token at pos comes right after the last one, or after a comma that does.
+func (c *canonicalChecker) at(pos int) {
+	if c.end < pos && c.data[c.end] == ',' {
+		c.end++
+	}
+	if pos != c.end {
+		c.fail(errMsgNotCanonicalSpace, c.end)
+	}
+}
+
+// value reads and checks the next value.
+func (c *canonicalChecker) value() {
+	r := c.r
+	if c.err != nil {
+		_ = r.SkipValue()
+		return
+	}
+	v := r.Any()
+	if r.Error() != nil {
+		return
+	}
+	start := r.tr.LastPos()
+	c.at(start)
+	c.end = r.tr.pos
+	switch v.Kind { //nolint:exhaustive
+	case StringValue:
+		c.string(start, c.end)
+	case NumberValue:
+		if raw := c.data[start:c.end]; !bytes.Equal(raw, canonicalNumber(nil, raw)) {
+			c.fail(errMsgNotCanonicalNumber, start)
+		}
+	case ArrayValue:
+		for arr := v.Array; arr.Next(); {
+			c.value()
+		}
+		c.closing()
+	case ObjectValue:
+		c.object(v.Object)
+		c.closing()
+	}
+}
+
+// object reads and checks the properties of an object whose opening brace has been read.
+func (c *canonicalChecker) object(obj ObjectState) {
+	var name, previous []byte
+	for first := true; obj.Next(); first = false {
+		if c.err != nil {
+			c.value()
+			continue
+		}
+		// The Reader only reports where the colon is, but in a canonical document the name is right
+		// after the opening brace or the comma, and the colon is right after the name.
+		if !first && c.data[c.end] == ',' {
+			c.end++
+		}
+		if c.data[c.end] != '"' {
+			c.fail(errMsgNotCanonicalSpace, c.end)
+			c.value()
+			continue
+		}
+		start, raw := c.end, obj.Name()
+		c.end += len(raw) + 2
+		c.string(start, c.end)
+		name, c.scratch = decodeName(c.scratch, raw)
+		if !first && compareUTF16(previous, name) >= 0 {
+			c.fail(errMsgNotCanonicalKeyOrder, start)
+		}
+		previous = append(previous[:0], name...)
+		c.at(c.r.tr.LastPos())
+		c.end++
+		c.value()
+	}
+}
+
+// closing checks the closing delimiter of an array or object, once ArrayState.Next or
+// ObjectState.Next has returned false.
+func (c *canonicalChecker) closing() {
+	if c.err == nil && c.r.Error() == nil {
+		c.at(c.r.tr.LastPos())
+		c.end++
+	}
+}
+
+// string checks the string token from start to end, including its quotes.
+func (c *canonicalChecker) string(start, end int) {
+	s := c.data[:end-1]
+	for i := start + 1; i < len(s); i++ {
+		b := s[i]
+		if b < 0x20 {
+			c.fail(errMsgNotCanonicalUnescaped, i)
+			return
+		}
+		if b != '\\' {
+			continue
+		}
+		switch s[i+1] {
+		case '"', '\\', 'b', 'f', 'n', 'r', 't':
+			i++
+		case 'u':
+			// Only control characters that do not have a two-character escape may be escaped this way.
+			if i+6 > len(s) {
+				c.fail(errMsgNotCanonicalEscape, i)
+				return
+			}
+			hex := s[i+2 : i+6]
+			ch, err := strconv.ParseUint(string(hex), 16, 16)
+			if err != nil || ch >= 0x20 || bytes.IndexByte([]byte("\b\f\n\r\t"), byte(ch)) >= 0 ||
+				!bytes.Equal(hex, bytes.ToLower(hex)) {
+				c.fail(errMsgNotCanonicalEscape, i)
+				return
+			}
+			i += 5
+		default:
+			c.fail(errMsgNotCanonicalEscape, i)
+			return
+		}
+	}
+}
+
+// compareUTF16 compares two UTF-8 strings as sequences of UTF-16 code units, which is how RFC 8785
+// orders property names. This differs from comparing the bytes only when a character above U+FFFF,
+// which is written with a surrogate pair, is compared to one from U+E000 to U+FFFF.
+func compareUTF16(a, b []byte) int {
+	for len(a) > 0 && len(b) > 0 {
+		ra, na := utf8.DecodeRune(a)
+		rb, nb := utf8.DecodeRune(b)
+		if ra == utf8.RuneError || rb == utf8.RuneError {
+			if d := bytes.Compare(a[:na], b[:nb]); d != 0 {
+				return d
+			}
+		} else if ra != rb {
+			a1, a2 := utf16Units(ra)
+			b1, b2 := utf16Units(rb)
+			if a1 != b1 {
+				return int(a1 - b1)
+			}
+			return int(a2 - b2)
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return len(a) - len(b)
+}
+
+// utf16Units returns the UTF-16 code units of r; the second is zero if there is only one.
+func utf16Units(r rune) (rune, rune) {
+	if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+		return r1, r2
+	}
+	return r, 0
+}
+
+// canonicalNumber appends to buf the canonical form of the JSON number whose text is raw, as
+// described for IsCanonical.
+func canonicalNumber(buf []byte, raw []byte) []byte {
+	neg, digits, exp := decimalParts(nil, raw)
+	if len(digits) == 0 {
+		return append(buf, '0')
+	}
+	if neg {
+		buf = append(buf, '-')
+	}
+	k := len(digits)
+	switch {
+	case k <= exp && exp <= 21:
+		buf = append(buf, digits...)
+		buf = append(buf, bytes.Repeat([]byte{'0'}, exp-k)...)
+	case 0 < exp && exp <= 21:
+		buf = append(buf, digits[:exp]...)
+		buf = append(append(buf, '.'), digits[exp:]...)
+	case -6 < exp && exp <= 0:
+		buf = append(buf, "0."...)
+		buf = append(buf, bytes.Repeat([]byte{'0'}, -exp)...)
+		buf = append(buf, digits...)
+	default:
+		buf = append(buf, digits[0])
+		if k > 1 {
+			buf = append(append(buf, '.'), digits[1:]...)
+		}
+		buf = append(buf, 'e')
+		if exp-1 > 0 {
+			buf = append(buf, '+')
+		}
+		buf = strconv.AppendInt(buf, int64(exp-1), 10)
+	}
+	return buf
+}
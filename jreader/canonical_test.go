@@ -0,0 +1,87 @@
+package jreader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsCanonical(t *testing.T) {
+	t.Run("canonical", func(t *testing.T) {
+		for _, input := range []string{
+			`null`,
+			`{}`,
+			`[]`,
+			`{"a":1,"b":[true,false,null],"c":{"":"x","d":[]}}`,
+			`{"a":1,"a\u0001":2,"ab":3,"b":4,"é":5}`,
+			`["\"\\\b\f\n\r\t\u0000\u001f","é","/"]`,
+			`[0,-1,1.5,-0.25,100,123456789012345678901,1e+21,1.5e+22,0.000001,1e-7,-1.25e-10]`,
+			`{"\u0001":1,"😀":2,"ｱ":3}`,
+		} {
+			ok, err := IsCanonical([]byte(input))
+			assert.NoError(t, err, input)
+			assert.True(t, ok, input)
+		}
+	})
+
+	t.Run("not canonical", func(t *testing.T) {
+		for _, c := range []struct {
+			input   string
+			message string
+			offset  int
+		}{
+			{` {}`, errMsgNotCanonicalSpace, 0},
+			{`{} `, errMsgNotCanonicalSpace, 2},
+			{"[1,\n2]", errMsgNotCanonicalSpace, 3},
+			{`[1 ,2]`, errMsgNotCanonicalSpace, 2},
+			{`{"a" :1}`, errMsgNotCanonicalSpace, 4},
+			{`{"a": 1}`, errMsgNotCanonicalSpace, 5},
+			{`{"b":1,"a":2}`, errMsgNotCanonicalKeyOrder, 7},
+			{`{"a":1,"a":2}`, errMsgNotCanonicalKeyOrder, 7},
+			{`{"x":{"b":1,"a":2}}`, errMsgNotCanonicalKeyOrder, 12},
+			{`{"ｱ":1,"😀":2}`, errMsgNotCanonicalKeyOrder, 9},
+			{`{ "a":1}`, errMsgNotCanonicalSpace, 1},
+			{`{"a":1, "b":2}`, errMsgNotCanonicalSpace, 7},
+			{`{"a":1 }`, errMsgNotCanonicalSpace, 6},
+			{`[1,[2 ]]`, errMsgNotCanonicalSpace, 5},
+			{`{"\u0041":1}`, errMsgNotCanonicalEscape, 2},
+			{`["\/"]`, errMsgNotCanonicalEscape, 2},
+			{`["\u0041"]`, errMsgNotCanonicalEscape, 2},
+			{`["\u000a"]`, errMsgNotCanonicalEscape, 2},
+			{`["\u001F"]`, errMsgNotCanonicalEscape, 2},
+			{`[1.0]`, errMsgNotCanonicalNumber, 1},
+			{`[-0]`, errMsgNotCanonicalNumber, 1},
+			{`[1e3]`, errMsgNotCanonicalNumber, 1},
+			{`[1E+21]`, errMsgNotCanonicalNumber, 1},
+			{`[1e21]`, errMsgNotCanonicalNumber, 1},
+			{`[0.0000001]`, errMsgNotCanonicalNumber, 1},
+			{`[1.50]`, errMsgNotCanonicalNumber, 1},
+			{`[1,2,3,4.0e0]`, errMsgNotCanonicalNumber, 7},
+		} {
+			ok, err := IsCanonical([]byte(c.input))
+			assert.False(t, ok, c.input)
+			assert.Equal(t, CanonicalFormError{Message: c.message, Offset: c.offset}, err, c.input)
+		}
+	})
+
+	t.Run("not well-formed", func(t *testing.T) {
+		for _, input := range []string{``, `{`, `[1,]`, `{"a":1}x`, `[1][2]`, `[ 1,]`, `{"b":1,"a":}`} {
+			ok, err := IsCanonical([]byte(input))
+			assert.False(t, ok, input)
+			require.Error(t, err, input)
+			_, isCanonicalError := err.(CanonicalFormError)
+			assert.False(t, isCanonicalError, input)
+		}
+	})
+}
+
+func TestCompareUTF16(t *testing.T) {
+	assert.Equal(t, 0, compareUTF16([]byte("ab"), []byte("ab")))
+	assert.Less(t, compareUTF16([]byte("a"), []byte("ab")), 0)
+	assert.Less(t, compareUTF16([]byte("ab"), []byte("b")), 0)
+	assert.Less(t, compareUTF16([]byte("é"), []byte("ｱ")), 0)
+	assert.Less(t, compareUTF16([]byte("😀"), []byte("ｱ")), 0, "U+1F600 is D83D DE00 in UTF-16")
+	assert.Greater(t, compareUTF16([]byte("ｱ"), []byte("😀")), 0)
+	assert.Less(t, compareUTF16([]byte("😀"), []byte("😁")), 0)
+}
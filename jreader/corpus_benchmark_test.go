@@ -0,0 +1,155 @@
+package jreader
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//go:generate go run ./testdata/bench/generate.go
+
+// BenchmarkCorpus reads each of the documents in testdata/bench, which are modeled on common kinds
+// of real-world JSON, in several ways: reading every value in streaming mode, pre-processing and
+// then reading every value in lazy mode, only checking that the document is well-formed, and, as a
+// baseline, with encoding/json. The MB/s and allocs/op that it reports should be compared before
+// and after any change that is meant to improve performance:
+//
+//	go test -run '^$' -bench BenchmarkCorpus -benchmem -count 5 ./jreader
+func BenchmarkCorpus(b *testing.B) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "bench", "*.json"))
+	if err != nil || len(paths) == 0 {
+		b.Fatalf("no benchmark documents found: %v", err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path) //nolint:gosec
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(strings.TrimSuffix(filepath.Base(path), ".json"), func(b *testing.B) {
+			b.Run("Direct", func(b *testing.B) { benchmarkCorpusDirect(b, data) })
+			b.Run("PreProcessLazy", func(b *testing.B) { benchmarkCorpusLazy(b, data) })
+			b.Run("Validate", func(b *testing.B) { benchmarkCorpusValidate(b, data) })
+			b.Run("EncodingJSON", func(b *testing.B) { benchmarkCorpusEncodingJSON(b, data) })
+		})
+	}
+}
+
+func benchmarkCorpusDirect(b *testing.B, data []byte) {
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := NewReader(data)
+		readEveryValue(&r)
+		failBenchmarkOnReaderError(b, &r)
+	}
+}
+
+func benchmarkCorpusLazy(b *testing.B, data []byte) {
+	structs := make([]JsonTreeStruct, 0, 1024)
+	chars := make([]byte, 0, 1024)
+	strs := make([][]byte, 0, 1024)
+	numbers := make([]NumberProps, 0, 1024)
+	config := BufferConfig{StructBuffer: &structs, CharsBuffer: &chars,
+		ComputedValuesBuffer: JsonComputedValues{StringValues: &strs, NumberValues: &numbers}}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := NewReaderWithBuffers(data, config)
+		r.PreProcess()
+		readEveryValue(&r)
+		failBenchmarkOnReaderError(b, &r)
+	}
+}
+
+func benchmarkCorpusValidate(b *testing.B, data []byte) {
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := NewReader(data)
+		if err := r.SkipValue(); err != nil {
+			b.Fatal(err)
+		}
+		if err := r.RequireEOF(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkCorpusEncodingJSON(b *testing.B, data []byte) {
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var value any
+		if err := json.Unmarshal(data, &value); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// readEveryValue reads the next value and everything in it, converting each number to a float64 and
+// decoding each string, which is roughly the work that encoding/json does for an "any" target.
+func readEveryValue(r *Reader) {
+	v := *r.Any()
+	switch v.Kind {
+	case NumberValue:
+		if _, err := v.Number.Float64(); err != nil {
+			r.AddError(err)
+		}
+	case StringValue:
+		_ = r.decodedString(nil, v.String)
+	case ArrayValue:
+		for v.Array.Next() {
+			readEveryValue(r)
+		}
+	case ObjectValue:
+		for v.Object.Next() {
+			_ = r.decodedString(nil, v.Object.Name())
+			readEveryValue(r)
+		}
+	}
+}
+
+// BenchmarkPreProcessSparseNumbers pre-processes a large array of numbers and then reads only one
+// element in a hundred, comparing parsing every number while pre-processing with parsing each
+// number when it is first read.
+func BenchmarkPreProcessSparseNumbers(b *testing.B) {
+	data, err := os.ReadFile(filepath.Join("testdata", "bench", "numbers.json"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, deferNumbers := range []bool{false, true} {
+		name := "Eager"
+		if deferNumbers {
+			name = "Deferred"
+		}
+		b.Run(name, func(b *testing.B) {
+			structs := make([]JsonTreeStruct, 0, 1024)
+			chars := make([]byte, 0, 1024)
+			numbers := make([]NumberProps, 0, 1024)
+			config := BufferConfig{StructBuffer: &structs, CharsBuffer: &chars,
+				ComputedValuesBuffer: JsonComputedValues{NumberValues: &numbers}}
+			opts := PreProcessOptions{ComputeNumbers: true, DeferNumbers: deferNumbers, BuildIndex: true}
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r := NewReaderWithBuffers(data, config)
+				r.SetNumberRawRead(false)
+				r.PreProcessWith(opts)
+				arr := r.Array()
+				for j := 0; arr.Next(); j++ {
+					if j%100 == 0 {
+						_ = r.Float64()
+					}
+				}
+				failBenchmarkOnReaderError(b, &r)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package jreader
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Decoder decodes JSON values into Go values by reflection, in the same way as
+// Reader.DecodeWithDefaults, except that it can be given custom decoders for specific Go types. This
+// is useful for scalar types that appear in many structs but are not represented in JSON in the way
+// that their Go kind would suggest, such as a money amount that is a string in JSON:
+//
+//	var decoder = newDecoder()
+//
+//	func newDecoder() *jreader.Decoder {
+//	    d := jreader.NewDecoder()
+//	    _ = jreader.RegisterDecoderFor(d, func(r *jreader.Reader) (Money, error) {
+//	        return ParseMoney(r.String())
+//	    })
+//	    return d
+//	}
+//
+//	err := decoder.Decode(&r, &order)
+//
+// A custom decoder is used for every value of its type, wherever it appears: in a struct field, in
+// a slice or array element, in a map value, or behind any number of pointers (where a JSON null sets
+// the pointer to nil without calling the decoder). It takes precedence over the type's
+// ReadFromJSONReader method, if it has one.
+//
+// A Decoder is normally set up once at startup, and can then be used by any number of goroutines at
+// the same time, each with its own Reader. A custom decoder cannot be registered for a type once the
+// Decoder has decoded a value of that type, since that value would already have been decoded in a
+// different way.
+type Decoder struct {
+	mu            sync.Mutex // held while registering, and while recording the first use of a type
+	decoders      sync.Map   // reflect.Type -> func(*Reader) (reflect.Value, error)
+	used          sync.Map   // reflect.Type -> struct{}, for every type that has been looked up
+	unknownFields UnknownFieldPolicy
+}
+
+// NewDecoder creates a Decoder with no custom decoders.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+// RegisterDecoder sets the custom decoder for the Go type t. The function must consume exactly one
+// JSON value from the Reader, and return either a value that is assignable to t, or an error; an
+// error is reported as the Reader's error, in the same way as AddError.
+//
+// If the Decoder has already decoded a value of type t, or if t or fn is nil, the decoder is not
+// registered and an error is returned.
+func (d *Decoder) RegisterDecoder(t reflect.Type, fn func(r *Reader) (reflect.Value, error)) error {
+	if t == nil || fn == nil {
+		return fmt.Errorf("cannot register a nil type or decoder function")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, used := d.used.Load(t); used {
+		return fmt.Errorf("cannot register a decoder for type %s after a value of that type has been decoded", t)
+	}
+	d.decoders.Store(t, fn)
+	return nil
+}
+
+// RegisterDecoderFor is a typed version of Decoder.RegisterDecoder, which sets the custom decoder for
+// the Go type T.
+func RegisterDecoderFor[T any](d *Decoder, fn func(r *Reader) (T, error)) error {
+	if fn == nil {
+		return d.RegisterDecoder(reflect.TypeOf((*T)(nil)).Elem(), nil)
+	}
+	return d.RegisterDecoder(reflect.TypeOf((*T)(nil)).Elem(), func(r *Reader) (reflect.Value, error) {
+		value, err := fn(r)
+		return reflect.ValueOf(&value).Elem(), err
+	})
+}
+
+// UnknownFieldPolicy determines what a Decoder does with an object property that does not
+// correspond to any field of the struct that the object is being decoded into. The zero value is
+// the same as IgnoreUnknownFields.
+type UnknownFieldPolicy struct {
+	disallow bool
+	callback func(name []byte, r *Reader) error
+}
+
+// IgnoreUnknownFields returns the default UnknownFieldPolicy, which skips the values of unknown
+// properties, as Reader.DecodeWithDefaults does.
+func IgnoreUnknownFields() UnknownFieldPolicy {
+	return UnknownFieldPolicy{}
+}
+
+// DisallowUnknownFields returns an UnknownFieldPolicy that makes decoding fail with an
+// UnknownFieldError at the first unknown property, like json.Decoder.DisallowUnknownFields.
+func DisallowUnknownFields() UnknownFieldPolicy {
+	return UnknownFieldPolicy{disallow: true}
+}
+
+// UnknownFieldCallback returns an UnknownFieldPolicy that calls fn for each unknown property, with
+// the property name as ObjectState.Name returns it and the Reader positioned at the property's
+// value. This is useful for logging properties that a newer version of a schema has added:
+//
+//	d.SetUnknownFieldPolicy(jreader.UnknownFieldCallback(func(name []byte, r *jreader.Reader) error {
+//	    kind, err := r.SkipValueKind()
+//	    log.Printf("ignoring %s property %q", kind, name)
+//	    return err
+//	}))
+//
+// The function may read the value, or leave it to be skipped. If it returns an error, decoding
+// fails with that error, in the same way as Reader.AddError. If fn is nil, unknown properties are
+// ignored.
+func UnknownFieldCallback(fn func(name []byte, r *Reader) error) UnknownFieldPolicy {
+	return UnknownFieldPolicy{callback: fn}
+}
+
+// SetUnknownFieldPolicy sets what the Decoder does with an object property that does not correspond
+// to any field of the struct being decoded; by default, it is ignored. Like custom decoders, this
+// should be set up before the Decoder is used.
+func (d *Decoder) SetUnknownFieldPolicy(policy UnknownFieldPolicy) {
+	d.unknownFields = policy
+}
+
+// Decode reads the next JSON value into the value that target points to, which can be of any type
+// that DecodeWithDefaults supports for a struct field, decoding over its current value: for
+// instance, fields of a struct whose properties are absent are left unchanged. If there is an
+// error, target is not modified and the Reader enters a failed state; the error is also returned.
+// If target is not a non-nil pointer, the error is a UsageError.
+func (d *Decoder) Decode(r *Reader, target any) error {
+	if r.err != nil {
+		return r.err
+	}
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Pointer || targetValue.IsNil() {
+		r.err = UsageError{Message: errMsgBadDecoderTarget, Offset: r.tr.getPos()}
+		return r.err
+	}
+	result := reflect.New(targetValue.Elem().Type()).Elem()
+	result.Set(targetValue.Elem())
+	defer d.use(r)()
+	r.decodeReflect(result)
+	if r.err != nil {
+		return r.err
+	}
+	targetValue.Elem().Set(result)
+	return nil
+}
+
+// DecodeWithDefaults is the same as Reader.DecodeWithDefaults, except that it uses the Decoder's
+// custom decoders.
+func (d *Decoder) DecodeWithDefaults(r *Reader, target any, defaults any) error {
+	defer d.use(r)()
+	return r.DecodeWithDefaults(target, defaults)
+}
+
+// use makes the Reader's reflection-based decoding use the Decoder, and returns a function that
+// restores the previous state.
+func (d *Decoder) use(r *Reader) func() {
+	previous, previousPath := r.decoder, len(r.decodePath)
+	r.decoder = d
+	return func() {
+		r.decoder = previous
+		r.decodePath = r.decodePath[:previousPath]
+	}
+}
+
+// unknownField applies the Decoder's UnknownFieldPolicy to the property called name, whose value
+// the Reader is positioned at. The property's JSON Pointer must already be in r.decodePath.
+func (d *Decoder) unknownField(r *Reader, name []byte) {
+	switch {
+	case d.unknownFields.disallow:
+		_, offset, _ := r.peekKind()
+		decoded, _ := decodeName(nil, name)
+		r.err = UnknownFieldError{Name: string(decoded), Path: string(r.decodePath),
+			Offset: offset}
+	case d.unknownFields.callback != nil:
+		r.AddError(d.unknownFields.callback(name, r))
+	}
+}
+
+// lookup returns the custom decoder for the type t, if any, and records that t has been used. The
+// use is recorded under the same lock as RegisterDecoder, before the decoder is loaded, so that once
+// t has been looked up its decoder cannot change.
+func (d *Decoder) lookup(t reflect.Type) (func(*Reader) (reflect.Value, error), bool) {
+	if _, used := d.used.Load(t); !used {
+		d.mu.Lock()
+		d.used.Store(t, struct{}{})
+		d.mu.Unlock()
+	}
+	fn, ok := d.decoders.Load(t)
+	if !ok {
+		return nil, false
+	}
+	return fn.(func(*Reader) (reflect.Value, error)), true
+}
+
+// decodeCustom decodes the next JSON value into v if there is a custom decoder for its type, or
+// for the type that it points to through any number of pointers, and returns true; or returns false
+// if there is no such decoder.
+func (d *Decoder) decodeCustom(r *Reader, v reflect.Value) bool {
+	t := v.Type()
+	if fn, ok := d.lookup(t); ok {
+		result, err := fn(r)
+		if err != nil {
+			r.AddError(err)
+		}
+		if r.err != nil {
+			return true
+		}
+		if !result.IsValid() || !result.Type().AssignableTo(t) {
+			r.err = UsageError{Message: errMsgBadDecoderResult + t.String(), Offset: r.tr.getPos()}
+			return true
+		}
+		v.Set(result)
+		return true
+	}
+	if t.Kind() != reflect.Pointer || !d.hasDecoderBehindPointer(t.Elem()) {
+		return false
+	}
+	if kind, _, ok := r.peekKind(); ok && kind == NullValue && r.err == nil {
+		_ = r.Null()
+		v.Set(reflect.Zero(t))
+		return true
+	}
+	p := newPointerCopy(v)
+	r.decodeReflect(p.Elem())
+	if r.err == nil {
+		v.Set(p)
+	}
+	return true
+}
+
+func (d *Decoder) hasDecoderBehindPointer(t reflect.Type) bool {
+	for {
+		if _, ok := d.lookup(t); ok {
+			return true
+		}
+		if t.Kind() != reflect.Pointer {
+			return false
+		}
+		t = t.Elem()
+	}
+}
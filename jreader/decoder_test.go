@@ -0,0 +1,268 @@
+package jreader
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type decoderTestMoney struct {
+	cents int64
+}
+
+type decoderTestOrder struct {
+	Total    decoderTestMoney            `json:"total"`
+	Discount *decoderTestMoney           `json:"discount"`
+	Refund   **decoderTestMoney          `json:"refund"`
+	Items    []decoderTestMoney          `json:"items"`
+	Fees     map[string]decoderTestMoney `json:"fees"`
+	Note     string                      `json:"note"`
+}
+
+func decodeTestMoney(r *Reader) (decoderTestMoney, error) {
+	s := string(r.String())
+	units, cents, _ := strings.Cut(s, ".")
+	n, err := strconv.ParseInt(units+cents, 10, 64)
+	if err != nil {
+		return decoderTestMoney{}, errors.New("invalid amount: " + s)
+	}
+	return decoderTestMoney{cents: n}, nil
+}
+
+func newTestDecoder(t *testing.T) *Decoder {
+	d := NewDecoder()
+	require.NoError(t, RegisterDecoderFor(d, decodeTestMoney))
+	return d
+}
+
+func TestDecoder(t *testing.T) {
+	t.Run("custom decoder is used wherever the type appears", func(t *testing.T) {
+		data := `{"total":"12.50","discount":"1.00","refund":"0.25","items":["10.00","2.50"],` +
+			`"fees":{"shipping":"3.99"},"note":"x"}`
+		r := NewReader([]byte(data))
+		var order decoderTestOrder
+		require.NoError(t, newTestDecoder(t).Decode(&r, &order))
+
+		assert.Equal(t, decoderTestMoney{1250}, order.Total)
+		require.NotNil(t, order.Discount)
+		assert.Equal(t, decoderTestMoney{100}, *order.Discount)
+		require.NotNil(t, order.Refund)
+		require.NotNil(t, *order.Refund)
+		assert.Equal(t, decoderTestMoney{25}, **order.Refund)
+		assert.Equal(t, []decoderTestMoney{{1000}, {250}}, order.Items)
+		assert.Equal(t, map[string]decoderTestMoney{"shipping": {399}}, order.Fees)
+		assert.Equal(t, "x", order.Note)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("null sets a pointer to nil without calling the decoder", func(t *testing.T) {
+		discount := decoderTestMoney{5}
+		order := decoderTestOrder{Discount: &discount}
+		r := NewReader([]byte(`{"discount":null}`))
+		require.NoError(t, newTestDecoder(t).Decode(&r, &order))
+		assert.Nil(t, order.Discount)
+		assert.Equal(t, decoderTestMoney{5}, discount)
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"total":"1.23","items":["4.56"]}`)
+		var order decoderTestOrder
+		require.NoError(t, newTestDecoder(t).Decode(&r, &order))
+		assert.Equal(t, decoderTestMoney{123}, order.Total)
+		assert.Equal(t, []decoderTestMoney{{456}}, order.Items)
+	})
+
+	t.Run("DecodeWithDefaults", func(t *testing.T) {
+		defaults := decoderTestOrder{Note: "default"}
+		r := NewReader([]byte(`{"total":"2.00"}`))
+		var order decoderTestOrder
+		require.NoError(t, newTestDecoder(t).DecodeWithDefaults(&r, &order, defaults))
+		assert.Equal(t, decoderTestOrder{Total: decoderTestMoney{200}, Note: "default"}, order)
+	})
+
+	t.Run("error from the decoder", func(t *testing.T) {
+		order := decoderTestOrder{Note: "unchanged"}
+		r := NewReader([]byte(`{"note":"changed","total":"abc"}`))
+		err := newTestDecoder(t).Decode(&r, &order)
+		require.EqualError(t, err, "invalid amount: abc")
+		require.Equal(t, err, r.Error())
+		assert.Equal(t, decoderTestOrder{Note: "unchanged"}, order)
+	})
+
+	t.Run("decoder returns the wrong type", func(t *testing.T) {
+		d := NewDecoder()
+		require.NoError(t, d.RegisterDecoder(reflect.TypeOf(decoderTestMoney{}), func(r *Reader) (reflect.Value, error) {
+			return reflect.ValueOf(string(r.String())), nil
+		}))
+		r := NewReader([]byte(`{"total":"1.00"}`))
+		var order decoderTestOrder
+		require.IsType(t, UsageError{}, d.Decode(&r, &order))
+	})
+
+	t.Run("without a Decoder, the type is decoded by kind", func(t *testing.T) {
+		_ = newTestDecoder(t)
+		r := NewReader([]byte(`{"total":"1.00"}`))
+		var order decoderTestOrder
+		require.IsType(t, TypeError{}, r.DecodeWithDefaults(&order, order))
+	})
+
+	t.Run("registration after use is rejected", func(t *testing.T) {
+		d := NewDecoder()
+		r := NewReader([]byte(`{"cents":1}`))
+		var m struct{ Cents int64 }
+		require.NoError(t, d.Decode(&r, &m))
+		require.Error(t, RegisterDecoderFor(d, func(r *Reader) (struct{ Cents int64 }, error) {
+			return struct{ Cents int64 }{}, nil
+		}))
+
+		r = NewReader([]byte(`{"total":{}}`))
+		var order decoderTestOrder
+		require.NoError(t, d.Decode(&r, &order))
+		require.Error(t, RegisterDecoderFor(d, decodeTestMoney))
+	})
+
+	t.Run("registration concurrent with use", func(t *testing.T) {
+		custom := func(r *Reader) (decoderTestMoney, error) {
+			return decoderTestMoney{cents: 1}, r.SkipValue()
+		}
+		for i := 0; i < 100; i++ {
+			d := NewDecoder()
+			results := make(chan int64, 4)
+			for j := 0; j < cap(results); j++ {
+				go func() {
+					r := NewReader([]byte(`{}`))
+					var m decoderTestMoney
+					_ = d.Decode(&r, &m)
+					results <- m.cents
+				}()
+			}
+			registered := RegisterDecoderFor(d, custom) == nil
+			for j := 0; j < cap(results); j++ {
+				if cents := <-results; registered {
+					require.Equal(t, int64(1), cents, "a value was decoded without the decoder that was registered")
+				}
+			}
+		}
+	})
+
+	t.Run("target is not a pointer", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		require.IsType(t, UsageError{}, newTestDecoder(t).Decode(&r, decoderTestOrder{}))
+	})
+}
+
+func TestDecoderUnknownFieldPolicy(t *testing.T) {
+	type inner struct {
+		A int `json:"a"`
+	}
+	type outer struct {
+		Name  string  `json:"name"`
+		Inner inner   `json:"inner"`
+		List  []inner `json:"list"`
+	}
+
+	t.Run("ignored by default", func(t *testing.T) {
+		r := NewReader([]byte(`{"name":"x","extra":[1,{"b":2}],"inner":{"a":1,"b":2}}`))
+		var value outer
+		require.NoError(t, NewDecoder().Decode(&r, &value))
+		assert.Equal(t, outer{Name: "x", Inner: inner{A: 1}}, value)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("error at top level", func(t *testing.T) {
+		r := NewReader([]byte(`{"name":"x","extra":1,"other":2}`))
+		d := NewDecoder()
+		d.SetUnknownFieldPolicy(DisallowUnknownFields())
+		var value outer
+		err := d.Decode(&r, &value)
+		var ufe UnknownFieldError
+		require.ErrorAs(t, err, &ufe)
+		assert.Equal(t, UnknownFieldError{Name: "extra", Path: "/extra", Offset: 20}, ufe)
+		assert.Equal(t, outer{}, value)
+	})
+
+	t.Run("error when nested", func(t *testing.T) {
+		r := NewReader([]byte(`{"list":[{"a":1},{"a":2,"b\/c":3}]}`))
+		d := NewDecoder()
+		d.SetUnknownFieldPolicy(DisallowUnknownFields())
+		var value outer
+		err := d.Decode(&r, &value)
+		var ufe UnknownFieldError
+		require.ErrorAs(t, err, &ufe)
+		assert.Equal(t, "b/c", ufe.Name)
+		assert.Equal(t, "/list/1/b~1c", ufe.Path)
+		offset, ok := ErrorOffset(err)
+		assert.True(t, ok)
+		assert.Equal(t, 31, offset)
+	})
+
+	t.Run("error does not leak into a later decode", func(t *testing.T) {
+		d := NewDecoder()
+		d.SetUnknownFieldPolicy(DisallowUnknownFields())
+		r := NewReader([]byte(`{"inner":{"b":1}}`))
+		var value outer
+		require.Error(t, d.Decode(&r, &value))
+		assert.Len(t, r.decodePath, 0)
+
+		r = NewReader([]byte(`{"inner":{"a":1}}`))
+		require.NoError(t, d.Decode(&r, &value))
+		assert.Equal(t, 1, value.Inner.A)
+	})
+
+	for _, lazy := range []bool{false, true} {
+		newReader := func(t *testing.T, data string) Reader {
+			if lazy {
+				return newLazyReaderForTest(t, data)
+			}
+			return NewReader([]byte(data))
+		}
+		mode := map[bool]string{false: "direct", true: "lazy"}[lazy]
+
+		t.Run("callback that reads the value, "+mode, func(t *testing.T) {
+			r := newReader(t, `{"extra":{"x":[1,2]},"name":"x","inner":{"more":true}}`)
+			d := NewDecoder()
+			seen := map[string]ValueKind{}
+			d.SetUnknownFieldPolicy(UnknownFieldCallback(func(name []byte, r *Reader) error {
+				kind, err := r.SkipValueKind()
+				seen[string(name)] = kind
+				return err
+			}))
+			var value outer
+			require.NoError(t, d.Decode(&r, &value))
+			assert.Equal(t, map[string]ValueKind{"extra": ObjectValue, "more": BoolValue}, seen)
+			assert.Equal(t, "x", value.Name)
+			require.NoError(t, r.RequireEOF())
+		})
+
+		t.Run("callback that does not read the value, "+mode, func(t *testing.T) {
+			r := newReader(t, `{"extra":{"x":[1,2]},"name":"x","more":[{"a":1}]}`)
+			d := NewDecoder()
+			var names []string
+			d.SetUnknownFieldPolicy(UnknownFieldCallback(func(name []byte, r *Reader) error {
+				names = append(names, string(name))
+				return nil
+			}))
+			var value outer
+			require.NoError(t, d.Decode(&r, &value))
+			assert.Equal(t, []string{"extra", "more"}, names)
+			assert.Equal(t, "x", value.Name)
+			require.NoError(t, r.RequireEOF())
+		})
+	}
+
+	t.Run("callback error stops decoding", func(t *testing.T) {
+		r := NewReader([]byte(`{"extra":1,"name":"x"}`))
+		d := NewDecoder()
+		d.SetUnknownFieldPolicy(UnknownFieldCallback(func(name []byte, r *Reader) error {
+			return errors.New("unexpected " + string(name))
+		}))
+		var value outer
+		require.EqualError(t, d.Decode(&r, &value), "unexpected extra")
+		assert.Equal(t, outer{}, value)
+	})
+}
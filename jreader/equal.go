@@ -0,0 +1,218 @@
+package jreader
+
+import (
+	"bytes"
+	"hash"
+	"sort"
+	"strconv"
+)
+
+// EqualIgnoringKeyOrder parses two JSON documents and returns true if they represent the same value.
+// Unlike a byte comparison, this ignores whitespace, the order of object properties, the way
+// numbers are written (1.0 is equal to 1; see NumberProps.Equal), and the way strings and property
+// names are escaped. Array elements are compared in order.
+//
+// If a property name appears more than once in an object, only its last value is compared, since
+// that is the one that a decoder would use.
+//
+// If either document is not well-formed JSON, or has any data after the value (not counting
+// whitespace), the return value is false and the parsing error is returned.
+func EqualIgnoringKeyOrder(a, b []byte) (bool, error) {
+	ra := NewReader(a)
+	va, err := ra.ReadAllAny()
+	if err != nil {
+		return false, err
+	}
+	rb := NewReader(b)
+	vb, err := rb.ReadAllAny()
+	if err != nil {
+		return false, err
+	}
+	return va.Equal(vb), nil
+}
+
+// Equal returns true if two AnyValues represent the same JSON value. The values must have the same
+// Kind; numbers are compared by numeric value (see NumberProps.Equal); strings must have the same
+// content; arrays must have equal elements in the same order; and objects must have the same
+// property names, with equal values, in any order. If a property name appears more than once, only
+// its last value is compared.
+//
+// Arrays and objects are compared using their Elements and Properties fields, so this is meant for
+// values that were read with Reader.ReadAllAny, which also decodes any escape sequences in strings.
+// Two nil values are equal.
+func (v *AnyValue) Equal(other *AnyValue) bool {
+	if v == nil || other == nil {
+		return v == other
+	}
+	if v.Kind != other.Kind {
+		return false
+	}
+	switch v.Kind {
+	case BoolValue:
+		return v.Bool == other.Bool
+	case NumberValue:
+		return v.Number.Equal(other.Number)
+	case StringValue:
+		return bytes.Equal(v.String, other.String)
+	case ArrayValue:
+		if len(v.Elements) != len(other.Elements) {
+			return false
+		}
+		for i := range v.Elements {
+			if !v.Elements[i].Equal(other.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case ObjectValue:
+		pa, pb := lastPropertyValues(v.Properties), lastPropertyValues(other.Properties)
+		if len(pa) != len(pb) {
+			return false
+		}
+		for name, va := range pa {
+			vb, ok := pb[name]
+			if !ok || !va.Equal(vb) {
+				return false
+			}
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// lastPropertyValues returns a map of property names to values, where a name that appears more
+// than once is mapped to its last value.
+func lastPropertyValues(props []AnyProperty) map[string]*AnyValue {
+	m := make(map[string]*AnyValue, len(props))
+	for _, p := range props {
+		m[string(p.Name)] = p.Value
+	}
+	return m
+}
+
+// HashValue parses a JSON document and writes a canonical form of it to h, so that any two
+// documents that are equal according to EqualIgnoringKeyOrder produce the same hash: object
+// properties are written in sorted order, numbers are normalized so that 1.0 and 1 are the same,
+// and strings are written with their escape sequences decoded. The canonical form is not JSON, and
+// it is only meant to be used as hash input; it is the same for every run of the program.
+//
+//	h := sha256.New()
+//	if err := jreader.HashValue(data, h); err != nil { ... }
+//	key := h.Sum(nil)
+//
+// The document is read once, without building a tree of values. The canonical form is built up in
+// a buffer, since the properties of an object can only be sorted once all of them have been read;
+// so the memory used is about the size of the document, and the text of a property value is copied
+// once for each object that it is nested in. The buffer is written to h at the end. If the document
+// is not well-formed JSON, or has any data after the value (not counting whitespace), nothing is
+// written and the parsing error is returned.
+func HashValue(data []byte, h hash.Hash) error {
+	r := NewReader(data)
+	c := canonicalHasher{r: &r}
+	out := c.value(nil)
+	if r.Error() != nil {
+		return r.Error()
+	}
+	if err := r.RequireEOF(); err != nil {
+		return err
+	}
+	h.Write(out)
+	return nil
+}
+
+// canonicalHasher builds the canonical form that HashValue writes. Every value starts with a byte
+// that identifies its kind, strings are prefixed with their length, and arrays end with a byte of
+// their own, so that the encoding of a sequence of values is unambiguous.
+type canonicalHasher struct {
+	r       *Reader
+	props   []hashedProperty // the properties of the objects that are being read, innermost last
+	tail    []byte           // for reordering the properties of an object
+	scratch []byte
+}
+
+// hashedProperty is the position of an object property's name and value in the canonical form.
+type hashedProperty struct {
+	start, nameEnd, nameLen, end int
+}
+
+// value reads the next value and appends its canonical form to out.
+func (c *canonicalHasher) value(out []byte) []byte {
+	r := c.r
+	v := r.Any()
+	if r.Error() != nil {
+		return out
+	}
+	switch v.Kind {
+	case NullValue:
+		out = append(out, 'n')
+	case BoolValue:
+		if v.Bool {
+			out = append(out, 't')
+		} else {
+			out = append(out, 'f')
+		}
+	case NumberValue:
+		neg, digits, exp := decimalParts(c.scratch[:0], v.Number.raw)
+		c.scratch = digits[:0]
+		sign := byte('+')
+		if neg {
+			sign = '-'
+		}
+		out = append(append(out, 'd', sign), digits...)
+		out = strconv.AppendInt(append(out, 'e'), int64(exp), 10)
+	case StringValue:
+		out = appendCanonicalString(out, r.decodedString(c.scratch[:0], v.String))
+	case ArrayValue:
+		out = append(out, '[')
+		for arr := v.Array; arr.Next(); {
+			out = c.value(out)
+		}
+		out = append(out, ']')
+	case ObjectValue:
+		out = c.object(v.Object, out)
+	}
+	return out
+}
+
+// object reads the properties of an object, and appends its canonical form to out: the number of
+// distinct property names, and then each name and its last value in sorted order of the names.
+func (c *canonicalHasher) object(obj ObjectState, out []byte) []byte {
+	base, start := len(c.props), len(out)
+	for obj.Next() {
+		p := hashedProperty{start: len(out)}
+		name := c.r.decodedString(c.scratch[:0], obj.Name())
+		out = appendCanonicalString(out, name)
+		p.nameEnd, p.nameLen = len(out), len(name)
+		out = c.value(out)
+		p.end = len(out)
+		c.props = append(c.props, p)
+	}
+	props := c.props[base:]
+	defer func() { c.props = c.props[:base] }()
+	if c.r.Error() != nil {
+		return out
+	}
+	nameOf := func(p hashedProperty) []byte { return out[p.nameEnd-p.nameLen : p.nameEnd] }
+	sort.SliceStable(props, func(i, j int) bool {
+		return bytes.Compare(nameOf(props[i]), nameOf(props[j])) < 0
+	})
+	distinct := props[:0]
+	for i, p := range props {
+		if i+1 < len(props) && bytes.Equal(nameOf(p), nameOf(props[i+1])) {
+			continue // only the last value of a property that appears more than once is used
+		}
+		distinct = append(distinct, p)
+	}
+	c.tail = append(c.tail[:0], out[start:]...)
+	out = strconv.AppendInt(append(out[:start], '{'), int64(len(distinct)), 10)
+	for _, p := range distinct {
+		out = append(out, c.tail[p.start-start:p.end-start]...)
+	}
+	return out
+}
+
+func appendCanonicalString(out []byte, s []byte) []byte {
+	out = strconv.AppendInt(append(out, 's'), int64(len(s)), 10)
+	return append(append(out, ':'), s...)
+}
@@ -0,0 +1,119 @@
+package jreader
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqualIgnoringKeyOrder(t *testing.T) {
+	for _, tc := range []struct {
+		a, b  string
+		equal bool
+	}{
+		{`{"a":1,"b":[true,null,"x"]}`, ` { "b" : [ true, null, "x" ], "a" : 1 } `, true},
+		{`{"a":{"c":2,"d":3},"b":4}`, `{"b":4,"a":{"d":3,"c":2}}`, true},
+		{`{"a":1}`, `{"a":1.0}`, true},
+		{`[1, 100, 0.001, -2.5, 0]`, `[1e0, 1E2, 1e-3, -25e-1, -0.0]`, true},
+		{`{"\u0061":"\u0062c"}`, `{"a":"bc"}`, true},
+		{`{"a":1}`, `{"a":1}`, true},
+		{`{"a":1,"a":2}`, `{"a":2}`, true},
+		{`{}`, `{ }`, true},
+		{`[]`, `[ ]`, true},
+
+		{`{"a":1}`, `{"a":2}`, false},
+		{`{"a":1}`, `{"b":1}`, false},
+		{`{"a":1}`, `{"a":1,"b":1}`, false},
+		{`{"a":1,"b":1}`, `{"a":1}`, false},
+		{`[1,2]`, `[2,1]`, false},
+		{`[1,2]`, `[1,2,3]`, false},
+		{`1`, `"1"`, false},
+		{`true`, `false`, false},
+		{`null`, `false`, false},
+		{`9007199254740993`, `9007199254740992`, false},
+		{`0.1`, `0.10000000000000001`, false},
+		{`{"a":[{"b":1}]}`, `{"a":[{"b":"1"}]}`, false},
+	} {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			equal, err := EqualIgnoringKeyOrder([]byte(tc.a), []byte(tc.b))
+			require.NoError(t, err)
+			require.Equal(t, tc.equal, equal)
+
+			equal, err = EqualIgnoringKeyOrder([]byte(tc.b), []byte(tc.a))
+			require.NoError(t, err)
+			require.Equal(t, tc.equal, equal)
+		})
+	}
+}
+
+func TestEqualIgnoringKeyOrderErrors(t *testing.T) {
+	_, err := EqualIgnoringKeyOrder([]byte(`{"a":}`), []byte(`{}`))
+	require.IsType(t, SyntaxError{}, err)
+
+	_, err = EqualIgnoringKeyOrder([]byte(`{}`), []byte(`{} {}`))
+	require.IsType(t, SyntaxError{}, err)
+}
+
+func TestAnyValueEqual(t *testing.T) {
+	readAll := func(data string) *AnyValue {
+		r := NewReader([]byte(data))
+		v, err := r.ReadAllAny()
+		require.NoError(t, err)
+		return v
+	}
+
+	a := readAll(`{"x":[1, {"y":"z"}], "n":null, "b":false}`)
+	require.True(t, a.Equal(a))
+	require.True(t, a.Equal(readAll(`{"b":false, "n":null, "x":[1.0, {"y":"z"}]}`)))
+	require.False(t, a.Equal(readAll(`{"b":false, "n":null, "x":[{"y":"z"}, 1]}`)))
+	require.False(t, a.Equal(readAll(`{"b":true, "n":null, "x":[1, {"y":"z"}]}`)))
+	require.False(t, a.Equal(readAll(`{"n":null, "x":[1, {"y":"z"}]}`)))
+	require.False(t, a.Equal(readAll(`[1]`)))
+	require.False(t, a.Equal(nil))
+
+	var nilValue *AnyValue
+	require.True(t, nilValue.Equal(nil))
+	require.False(t, nilValue.Equal(a))
+
+	require.True(t, (&AnyValue{Kind: NullValue}).Equal(readAll(`null`)))
+}
+
+func TestHashValue(t *testing.T) {
+	hashOf := func(data string) []byte {
+		h := sha256.New()
+		require.NoError(t, HashValue([]byte(data), h))
+		return h.Sum(nil)
+	}
+
+	for _, tc := range []struct{ a, b string }{
+		{`{"a":1,"b":{"c":[true,null],"d":"x"}}`, ` {"b": {"d":"x", "c":[ true, null ]}, "a": 1.0}`},
+		{`[100, 0.5, -0, 12.340]`, `[1e2, 5E-1, 0, 1234e-2]`},
+		{`{"\u0061":"\u0062"}`, `{"a":"b"}`},
+		{`{"a":1,"a":2}`, `{"a":2}`},
+		{`{"x":[{"b":1,"a":{"d":0,"c":1},"b":3}],"w":{}}`, `{"w":{},"x":[{"a":{"c":1,"d":0},"b":3}]}`},
+	} {
+		t.Run(tc.a, func(t *testing.T) {
+			equal, err := EqualIgnoringKeyOrder([]byte(tc.a), []byte(tc.b))
+			require.NoError(t, err)
+			require.True(t, equal)
+			require.Equal(t, hashOf(tc.a), hashOf(tc.b))
+		})
+	}
+
+	distinct := []string{
+		`null`, `true`, `false`, `0`, `1`, `-1`, `10`, `0.1`, `""`, `"a"`, `"1"`, `[]`, `{}`, `[[]]`, `[null]`,
+		`["a","b"]`, `["ab"]`, `{"a":"b"}`, `{"b":"a"}`, `{"a":null}`, `[{}, []]`, `[[], {}]`,
+		`[[1],2]`, `[[1,2]]`, `{"a":{"b":1},"c":2}`, `{"a":{"b":1,"c":2}}`,
+	}
+	seen := map[string]string{}
+	for _, data := range distinct {
+		digest := string(hashOf(data))
+		require.NotContains(t, seen, digest, "%s has the same hash as %s", data, seen[digest])
+		seen[digest] = data
+	}
+
+	h := sha256.New()
+	require.Error(t, HashValue([]byte(`{"a":`), h))
+	require.Equal(t, sha256.New().Sum(nil), h.Sum(nil))
+}
@@ -2,19 +2,54 @@ package jreader
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
+	"unicode/utf8"
 )
 
 const (
-	errMsgBadArrayItem     = "expected comma or end of array"
-	errMsgBadObjectItem    = "expected comma or end of object"
-	errMsgDataAfterEnd     = "unexpected data after end of JSON value"
-	errMsgExpectedColon    = "expected colon after property name"
-	errMsgInvalidNumber    = "invalid numeric value"
-	errMsgInvalidString    = "unterminated or invalid string value"
-	errMsgUnexpectedChar   = "unexpected character"
-	errMsgUnexpectedSymbol = "unexpected symbol"
+	errMsgAnyMapNotInLazyMode    = "ToAnyMap requires a pre-processed tree; call PreProcess first"
+	errMsgAtNotInLazyMode        = "ArrayState.At requires a pre-processed tree; call PreProcess first"
+	errMsgBadArrayItem           = "expected comma or end of array"
+	errMsgBadDecodeDefaults      = "DecodeWithDefaults requires defaults of the same struct type as the target"
+	errMsgBadDecodeTarget        = "DecodeWithDefaults requires a non-nil pointer to a struct"
+	errMsgBadDecodeType          = "cannot decode JSON into a value of type "
+	errMsgBadDecoderResult       = "custom decoder returned a value that is not of type "
+	errMsgBadDecoderTarget       = "Decoder.Decode requires a non-nil pointer"
+	errMsgBadObjectItem          = "expected comma or end of object"
+	errMsgBeyondPreProcessed     = "value is beyond the pre-processed tree; call SyncWithPreProcess and PreProcess first"
+	errMsgDataAfterEnd           = "unexpected data after end of JSON value"
+	errMsgExpectedColon          = "expected colon after property name"
+	errMsgInputChanged           = "input was modified after it was pre-processed; it must not change in lazy mode"
+	errMsgInvalidNumber          = "invalid numeric value"
+	errMsgInvalidString          = "unterminated or invalid string value"
+	errMsgNotCanonicalEscape     = "string has an escape sequence that is not in canonical form"
+	errMsgNotCanonicalKeyOrder   = "object property names are not in ascending order"
+	errMsgNotCanonicalNumber     = "number is not in canonical form"
+	errMsgNotCanonicalSpace      = "document has whitespace outside of a string"
+	errMsgNotCanonicalUnescaped  = "string has a control character that is not escaped"
+	errMsgNotEnumValue           = "string is not one of the allowed values"
+	errMsgNotHexString           = "string is not hexadecimal"
+	errMsgNotExpectedNumber      = "number does not have the expected value"
+	errMsgNotExpectedString      = "string does not have the expected value"
+	errMsgOddHexString           = "hexadecimal string has an odd number of digits"
+	errMsgOneOfNotTracked        = "RequireExactlyOne requires TrackPresence to have been called with all of the names"
+	errMsgPatchNotObject         = "PatchWriter requires the pre-processed tree of a JSON object"
+	errMsgPreProcessNoIndex      = "PreProcessWith requires BuildIndex to be true; use PreProcess for the defaults"
+	errMsgStringTooLong          = "string is longer than the maximum length"
+	errMsgSubReaderBadNode       = "NodeRef does not refer to a value in the parent Reader's pre-processed tree"
+	errMsgSubReaderNotInLazyMode = "NewSubReader requires a pre-processed tree; call PreProcess on the parent first"
+	errMsgTaggedNotInLazyMode    = "DecodeTagged requires a pre-processed tree; call PreProcess first"
+	errMsgTokensInLazyMode       = "tokens cannot be read in lazy mode"
+	errMsgUnexpectedChar         = "unexpected character"
+	errMsgUnexpectedSymbol       = "unexpected symbol"
+	errMsgUnknownProperty        = "property name is not one of the allowed names"
+	errMsgUnknownTag             = "tag is not one of the allowed values"
+	errMsgValueBeforeNext        = "attempted to read a value at a property name; ObjectState.Next was not called"
+	errMsgViewBadNode            = "NodeRef does not refer to a value in the ReaderView's pre-processed tree"
+	errMsgViewNotInLazyMode      = "NewReaderView requires a pre-processed tree; call PreProcess first"
 )
 
 // SyntaxError is returned by Reader if the input is not well-formed JSON.
@@ -41,6 +76,10 @@ type TypeError struct {
 	// Nullable is true if the caller indicated that a null value was acceptable in this context.
 	Nullable bool
 
+	// Property, if not empty, is the name of the object property whose value had the wrong type.
+	// It is only set by methods that read a whole object at once, such as StringMap.
+	Property string
+
 	// Offset is the approximate character index within the input where the error occurred.
 	Offset int
 }
@@ -56,6 +95,226 @@ type RequiredPropertyError struct {
 	Offset int
 }
 
+// OneOfPropertyError is returned by ObjectState.RequireExactlyOne if a JSON object did not contain
+// exactly one of a group of mutually exclusive properties.
+type OneOfPropertyError struct {
+	// Names is the group of property names, of which exactly one was required.
+	Names []string
+
+	// Present is the names from the group that were found, in the same order as in Names. It is
+	// empty if none of them were found.
+	Present []string
+
+	// Offset is the approximate character index within the input where the error occurred
+	// (at or near the end of the JSON object).
+	Offset int
+}
+
+// ValueError is returned by Reader if a JSON value was of the expected type, but was not one of the
+// values that the caller allowed.
+type ValueError struct {
+	// Message is a descriptive message.
+	Message string
+
+	// Got is the value that was found. For a string, this is the string content with any escape
+	// sequences decoded, without quotes.
+	Got string
+
+	// Expected, if not empty, is the only value that the caller allowed, in the same format as Got.
+	// It is set by Reader.ReadExactString.
+	Expected string
+
+	// Property, if not empty, is the name of the object property whose value this was. It is set by
+	// Reader.DecodeEnumObject, and by Reader.ReadStringWithMaxLen in lazy mode.
+	Property string
+
+	// Offset is the approximate character index within the input where the error occurred.
+	Offset int
+}
+
+// TupleLengthError is returned by Reader.Tuple if a JSON array did not have the expected number
+// of elements, and by ArrayState.SkipN if there were fewer elements left than it was asked to skip.
+type TupleLengthError struct {
+	// Expected is the number of elements that the caller expected.
+	Expected int
+
+	// Actual is the number of elements that were found.
+	Actual int
+
+	// Offset is the approximate character index within the input where the error occurred
+	// (at or near the end of the JSON array).
+	Offset int
+}
+
+// ValidationError is returned by Reader.ReadObjectWithSchema, Reader.ReadArrayWithSchema, or
+// Reader.DecodeFields if a JSON object or array did not match the schema. Unlike other errors, it
+// describes every problem that was found, rather than only the first.
+type ValidationError struct {
+	// Violations is the list of problems, in the order they were found; properties that were
+	// missing, and problems with the length of an array, are listed last.
+	Violations []SchemaViolation
+
+	// Offset is the approximate character index within the input where the object or array began.
+	Offset int
+}
+
+// SchemaViolation is one of the problems described by a ValidationError.
+type SchemaViolation struct {
+	// Property is the property name, for a problem with an object property.
+	Property string
+
+	// Index is the index of the element, if Reason is WrongElementType.
+	Index int
+
+	// Length is the number of elements in the array, if Reason is ArrayTooShort or ArrayTooLong.
+	Length int
+
+	// Reason indicates what the problem was.
+	Reason SchemaViolationReason
+
+	// Expected and Actual are the declared and actual types of the value, if Reason is
+	// WrongPropertyType or WrongElementType.
+	Expected, Actual ValueKind
+
+	// Offset is the approximate character index within the input where the property or element
+	// appeared, or the end of the object or array if the problem was with the object or array as a
+	// whole.
+	Offset int
+}
+
+// SchemaViolationReason is the type of SchemaViolation.Reason.
+type SchemaViolationReason int
+
+const (
+	// MissingProperty means that a required property did not appear in the object.
+	MissingProperty SchemaViolationReason = iota + 1
+
+	// UnknownProperty means that a property appeared that the schema does not allow.
+	UnknownProperty
+
+	// WrongPropertyType means that the property value was not of the declared type.
+	WrongPropertyType
+
+	// WrongElementType means that an array element was not of the declared type.
+	WrongElementType
+
+	// ArrayTooShort means that an array had fewer elements than the minimum.
+	ArrayTooShort
+
+	// ArrayTooLong means that an array had more elements than the maximum.
+	ArrayTooLong
+)
+
+// NumberFormatError is returned by Reader, and by NumberProps, if a JSON number could not be
+// converted to the requested integer type. A value that is not a number at all causes a TypeError
+// instead.
+type NumberFormatError struct {
+	// Raw is the number as it appeared in the input.
+	Raw []byte
+
+	// Offset is the approximate character index within the input where the error occurred. It is
+	// always zero for an error returned by a NumberProps method, since the NumberProps does not know
+	// where it came from.
+	Offset int
+
+	// Reason indicates why the number could not be converted.
+	Reason NumberFormatReason
+}
+
+// NumberFormatReason is the type of NumberFormatError.Reason.
+type NumberFormatReason int
+
+const (
+	// NotAnInteger means that the number had a fractional part or an exponent, such as 3.5 or 1e2.
+	// A caller that wants to accept such values can read the number with Reader.NumberProps, and
+	// call Float64 if Int64 returns this error.
+	NotAnInteger NumberFormatReason = iota + 1
+
+	// OutOfRange means that the number was an integer, but it was too large or too small for the
+	// requested type; this includes any negative number when the requested type is unsigned.
+	OutOfRange
+
+	// InvalidSyntax means that the text of the number could not be parsed at all.
+	InvalidSyntax
+)
+
+// MatrixError is returned by Reader.Float64Matrix and Reader.Int64Matrix if there was an error
+// within one of the rows of the matrix. It identifies the element where the error occurred; the
+// underlying error, such as a TypeError, is in Err.
+type MatrixError struct {
+	// Row is the zero-based index of the row.
+	Row int
+
+	// Column is the zero-based index of the element within the row, or -1 if the row itself could
+	// not be read as an array.
+	Column int
+
+	// Err is the underlying error.
+	Err error
+}
+
+// LineError is returned by Reader.ReadJSONLines if there was an error on one of the lines. The
+// underlying error, such as a SyntaxError whose offset is relative to the start of the line, is in
+// Err.
+type LineError struct {
+	// Line is the line number, counting from 1.
+	Line int
+
+	// Err is the underlying error.
+	Err error
+}
+
+// CanonicalFormError is returned by IsCanonical if a JSON document is well-formed, but is not in
+// canonical form.
+type CanonicalFormError struct {
+	// Message is a descriptive message.
+	Message string
+
+	// Offset is the character index within the input of the first part of the document that is
+	// not in canonical form.
+	Offset int
+}
+
+// StateError is returned by Reader if a method was called when the Reader was not in a state that
+// supports it: a method that can only be used in lazy mode was called before Reader.PreProcess, or
+// a value was read in lazy mode after all of the pre-processed values had been read, while there is
+// still more input. In the latter case, calling Reader.SyncWithPreProcess and then Reader.PreProcess
+// again makes the next value available. It is also returned in lazy mode if the input was modified
+// after it was pre-processed (see PreProcessOptions.DisableMutationCheck).
+type StateError struct {
+	// Message is a descriptive message.
+	Message string
+
+	// Offset is the approximate character index within the input where the error occurred.
+	Offset int
+}
+
+// UsageError is returned by Reader if the caller used the Reader API incorrectly, and misuse
+// detection was enabled with Reader.SetDetectMisuse. It indicates a programming error rather than
+// a problem with the input.
+type UsageError struct {
+	// Message is a descriptive message.
+	Message string
+
+	// Offset is the approximate character index within the input where the error occurred.
+	Offset int
+}
+
+// UnknownFieldError is returned by a Decoder whose UnknownFieldPolicy is DisallowUnknownFields if
+// a JSON object had a property that does not correspond to any field of the struct it was being
+// decoded into.
+type UnknownFieldError struct {
+	// Name is the property name, with any escape sequences decoded.
+	Name string
+
+	// Path is the location of the property's value as a JSON Pointer (RFC 6901) relative to the
+	// value being decoded, such as "/items/0/extra".
+	Path string
+
+	// Offset is the approximate character index within the input where the property's value begins.
+	Offset int
+}
+
 // Error returns a description of the error.
 func (e SyntaxError) Error() string {
 	if e.Value != "" {
@@ -66,10 +325,14 @@ func (e SyntaxError) Error() string {
 
 // Error returns a description of the error.
 func (e TypeError) Error() string {
+	expected := e.Expected.String()
 	if e.Nullable {
-		return fmt.Sprintf("expected %s or null, got %s at position %d", e.Expected, e.Actual, e.Offset)
+		expected += " or null"
+	}
+	if e.Property != "" {
+		return fmt.Sprintf("expected %s, got %s for property %q at position %d", expected, e.Actual, e.Property, e.Offset)
 	}
-	return fmt.Sprintf("expected %s, got %s at position %d", e.Expected, e.Actual, e.Offset)
+	return fmt.Sprintf("expected %s, got %s at position %d", expected, e.Actual, e.Offset)
 }
 
 // Error returns a description of the error.
@@ -77,6 +340,186 @@ func (e RequiredPropertyError) Error() string {
 	return fmt.Sprintf("a required property %q was missing from a JSON object at position %d", e.Name, e.Offset)
 }
 
+// Error returns a description of the error.
+func (e OneOfPropertyError) Error() string {
+	if len(e.Present) == 0 {
+		return fmt.Sprintf("exactly one of the properties %q was required in a JSON object at position %d, "+
+			"but none was found", e.Names, e.Offset)
+	}
+	return fmt.Sprintf("exactly one of the properties %q was required in a JSON object at position %d, "+
+		"but found %q", e.Names, e.Offset, e.Present)
+}
+
+// Error returns a description of the error.
+func (e ValueError) Error() string {
+	message := e.Message
+	if e.Property != "" {
+		message += fmt.Sprintf(" for property %q", e.Property)
+	}
+	if e.Expected != "" {
+		return fmt.Sprintf("%s at position %d (expected %q, got %q)", message, e.Offset, e.Expected, e.Got)
+	}
+	return fmt.Sprintf("%s at position %d (%q)", message, e.Offset, e.Got)
+}
+
+// Error returns a description of the error.
+func (e TupleLengthError) Error() string {
+	if e.Actual < e.Expected {
+		return fmt.Sprintf("array ended before element %d (expected %d elements) at position %d",
+			e.Actual, e.Expected, e.Offset)
+	}
+	return fmt.Sprintf("unexpected array element %d (expected %d elements, got %d) at position %d",
+		e.Expected, e.Expected, e.Actual, e.Offset)
+}
+
+// Error returns a description of the error.
+func (e ValidationError) Error() string {
+	descriptions := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		descriptions = append(descriptions, v.String())
+	}
+	return fmt.Sprintf("JSON value at position %d does not match the schema: %s", e.Offset,
+		strings.Join(descriptions, "; "))
+}
+
+// String returns a description of the violation.
+func (v SchemaViolation) String() string {
+	switch v.Reason {
+	case MissingProperty:
+		return fmt.Sprintf("required property %q is missing", v.Property)
+	case UnknownProperty:
+		return fmt.Sprintf("property %q is not allowed at position %d", v.Property, v.Offset)
+	case WrongPropertyType:
+		return fmt.Sprintf("expected %s, got %s for property %q at position %d", v.Expected, v.Actual,
+			v.Property, v.Offset)
+	case WrongElementType:
+		return fmt.Sprintf("expected %s, got %s for element %d at position %d", v.Expected, v.Actual,
+			v.Index, v.Offset)
+	case ArrayTooShort:
+		return fmt.Sprintf("array has too few elements (%d) at position %d", v.Length, v.Offset)
+	case ArrayTooLong:
+		return fmt.Sprintf("array has too many elements (%d) at position %d", v.Length, v.Offset)
+	default:
+		return "unknown schema violation"
+	}
+}
+
+// Error returns a description of the error.
+func (e NumberFormatError) Error() string {
+	return fmt.Sprintf("number cannot be read as an integer (%s) at position %d (%q)", e.Reason, e.Offset, e.Raw)
+}
+
+// String returns a description of the reason.
+func (r NumberFormatReason) String() string {
+	switch r {
+	case NotAnInteger:
+		return "not an integer"
+	case OutOfRange:
+		return "out of range"
+	case InvalidSyntax:
+		return "invalid syntax"
+	default:
+		return "unknown reason"
+	}
+}
+
+// Error returns a description of the error.
+func (e MatrixError) Error() string {
+	if e.Column < 0 {
+		return fmt.Sprintf("matrix row %d: %s", e.Row, e.Err)
+	}
+	return fmt.Sprintf("matrix row %d, column %d: %s", e.Row, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e MatrixError) Unwrap() error {
+	return e.Err
+}
+
+// Error returns a description of the error.
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e LineError) Unwrap() error {
+	return e.Err
+}
+
+// Error returns a description of the error.
+func (e CanonicalFormError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Offset)
+}
+
+// Error returns a description of the error.
+func (e StateError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Offset)
+}
+
+// Error returns a description of the error.
+func (e UsageError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Offset)
+}
+
+// Error returns a description of the error.
+func (e UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown property %q at %s at position %d", e.Name, e.Path, e.Offset)
+}
+
+// ErrorOffset returns the Offset field of an error that was returned by Reader or by another
+// function in this package, or of the first such error that it wraps, and true; or zero and false if
+// the error is not of one of these types.
+func ErrorOffset(err error) (int, bool) {
+	for err != nil {
+		switch e := err.(type) {
+		case SyntaxError:
+			return e.Offset, true
+		case TypeError:
+			return e.Offset, true
+		case RequiredPropertyError:
+			return e.Offset, true
+		case OneOfPropertyError:
+			return e.Offset, true
+		case ValueError:
+			return e.Offset, true
+		case TupleLengthError:
+			return e.Offset, true
+		case ValidationError:
+			return e.Offset, true
+		case NumberFormatError:
+			return e.Offset, true
+		case CanonicalFormError:
+			return e.Offset, true
+		case StateError:
+			return e.Offset, true
+		case UsageError:
+			return e.Offset, true
+		case UnknownFieldError:
+			return e.Offset, true
+		}
+		err = errors.Unwrap(err)
+	}
+	return 0, false
+}
+
+// RuneOffset converts an offset within data, such as the Offset of an error, from a byte index to a
+// character index: that is, the number of Unicode characters that precede it, counting any byte that
+// is not part of a valid UTF-8 sequence as one character. This is useful when the input is shown in
+// an environment that indexes text by character rather than by byte. An offset beyond the end of
+// data is treated as the end of data.
+//
+// Note that some environments, such as JavaScript strings, count characters outside of the Basic
+// Multilingual Plane (such as most emoji) as two units rather than one.
+func RuneOffset(data []byte, byteOffset int) int {
+	if byteOffset <= 0 {
+		return 0
+	}
+	if byteOffset > len(data) {
+		byteOffset = len(data)
+	}
+	return utf8.RuneCount(data[:byteOffset])
+}
+
 // ToJSONError converts errors defined by the jreader package into the corresponding error types defined
 // by the encoding/json package, if any. The target parameter, if not nil, is used to determine the
 // target value type for json.UnmarshalTypeError.
@@ -91,6 +534,7 @@ func ToJSONError(err error, target interface{}) error {
 			Value:  e.Expected.String(),
 			Type:   reflect.TypeOf(target),
 			Offset: int64(e.Offset),
+			Field:  e.Property,
 		}
 	}
 	return err
@@ -3,10 +3,12 @@ package jreader
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSyntaxError(t *testing.T) {
@@ -24,6 +26,9 @@ func TestTypeError(t *testing.T) {
 	assert.Equal(t, "expected boolean or null, got string at position 2",
 		TypeError{Expected: BoolValue, Actual: StringValue, Offset: 2, Nullable: true}.Error())
 
+	assert.Equal(t, `expected boolean or null, got string for property "a" at position 2`,
+		TypeError{Expected: BoolValue, Actual: StringValue, Offset: 2, Nullable: true, Property: "a"}.Error())
+
 	assert.Equal(t, "expected null, got boolean at position 2",
 		TypeError{Expected: NullValue, Actual: BoolValue, Offset: 2}.Error())
 
@@ -56,3 +61,57 @@ func TestToJSONError(t *testing.T) {
 	e3 := errors.New("some other error")
 	assert.Equal(t, e3, ToJSONError(e3, nil))
 }
+
+func TestTupleLengthError(t *testing.T) {
+	assert.Equal(t, "array ended before element 2 (expected 3 elements) at position 5",
+		TupleLengthError{Expected: 3, Actual: 2, Offset: 5}.Error())
+	assert.Equal(t, "unexpected array element 3 (expected 3 elements, got 5) at position 5",
+		TupleLengthError{Expected: 3, Actual: 5, Offset: 5}.Error())
+}
+
+func TestValueError(t *testing.T) {
+	assert.Equal(t, `bad value at position 2 ("x")`, ValueError{Message: "bad value", Got: "x", Offset: 2}.Error())
+	assert.Equal(t, `bad value at position 2 (expected "y", got "x")`,
+		ValueError{Message: "bad value", Got: "x", Expected: "y", Offset: 2}.Error())
+}
+
+func TestRuneOffset(t *testing.T) {
+	data := []byte(`{"😀": "é", "k": tru}`)
+	r := NewReader(data)
+	_, err := r.ReadAllAny()
+	offset, ok := ErrorOffset(err)
+	require.True(t, ok)
+	runeOffset := RuneOffset(data, offset)
+	assert.Equal(t, offset-4, runeOffset) // 😀 is 4 bytes and é is 2 bytes
+	assert.Equal(t, "tru}", string([]rune(string(data))[runeOffset:]))
+
+	assert.Equal(t, 0, RuneOffset(data, -1))
+	assert.Equal(t, len([]rune(string(data))), RuneOffset(data, len(data)+10))
+	assert.Equal(t, 3, RuneOffset([]byte("a\xffb\xc3"), 3))
+}
+
+func TestErrorOffset(t *testing.T) {
+	for _, err := range []error{
+		SyntaxError{Offset: 7},
+		TypeError{Offset: 7},
+		RequiredPropertyError{Offset: 7},
+		OneOfPropertyError{Offset: 7},
+		ValueError{Offset: 7},
+		TupleLengthError{Offset: 7},
+		ValidationError{Offset: 7},
+		NumberFormatError{Offset: 7},
+		CanonicalFormError{Offset: 7},
+		StateError{Offset: 7},
+		UsageError{Offset: 7},
+		MatrixError{Err: TypeError{Offset: 7}},
+		fmt.Errorf("wrapped: %w", SyntaxError{Offset: 7}),
+	} {
+		offset, ok := ErrorOffset(err)
+		assert.True(t, ok, "%T", err)
+		assert.Equal(t, 7, offset, "%T", err)
+	}
+	_, ok := ErrorOffset(errors.New("other"))
+	assert.False(t, ok)
+	_, ok = ErrorOffset(nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,154 @@
+package jreader
+
+import "bytes"
+
+// FieldMatcher maps JSON property names to field indexes. It is meant to be built once, for
+// instance in a package-level variable, for each set of property names that a decoder expects,
+// and then used with ObjectState.NextMatch to dispatch on property names without comparing
+// against every candidate in turn:
+//
+//	var myStructFields = jreader.NewFieldMatcher([]string{"id", "name", "tags"})
+//
+//	for obj := r.Object(); ; {
+//	    i, ok := obj.NextMatch(myStructFields)
+//	    if !ok {
+//	        break
+//	    }
+//	    switch i {
+//	    case 0:
+//	        s.id = r.Int64()
+//	    case 1:
+//	        s.name = string(r.String())
+//	    ...
+//	    }
+//	}
+//
+// Property names are matched after any JSON escape sequences in them are decoded, so a property
+// written as "\u0069d" in the input matches "id". A FieldMatcher is immutable after construction
+// and can be shared between goroutines.
+type FieldMatcher struct {
+	names [][]byte
+	// keys are all the names that can be matched, and keyFields the field index for each of them;
+	// for a plain FieldMatcher these are the same as names, but a FieldSet can add aliases.
+	keys      [][]byte
+	keyFields []int
+	// slots is an open-addressing hash table of key index + 1 (zero means an empty slot). It is
+	// kept at most a quarter full, so lookups rarely need more than one comparison.
+	slots []int32
+	mask  uint32
+}
+
+// NewFieldMatcher creates a FieldMatcher for the specified property names. The index of each name
+// in the slice is the value that Match returns for it. If a name appears more than once, the first
+// occurrence wins.
+func NewFieldMatcher(names []string) *FieldMatcher {
+	m := &FieldMatcher{}
+	m.init(names)
+	return m
+}
+
+func (m *FieldMatcher) init(names []string) {
+	m.names = make([][]byte, len(names))
+	m.keys = make([][]byte, 0, len(names))
+	m.keyFields = make([]int, 0, len(names))
+	m.rehash(len(names))
+	for i, name := range names {
+		m.names[i] = []byte(name)
+		if m.matchUnescaped(m.names[i]) < 0 {
+			m.addKey(m.names[i], i)
+		}
+	}
+}
+
+// addKey makes the specified name match the specified field index. The caller is responsible for
+// checking that the name is not already present.
+func (m *FieldMatcher) addKey(name []byte, field int) {
+	m.keys = append(m.keys, name)
+	m.keyFields = append(m.keyFields, field)
+	if len(m.keys)*4 > len(m.slots) {
+		m.rehash(len(m.keys))
+		return
+	}
+	m.insertSlot(len(m.keys) - 1)
+}
+
+func (m *FieldMatcher) rehash(minKeys int) {
+	size := 8
+	for size < minKeys*4 {
+		size *= 2
+	}
+	m.slots = make([]int32, size)
+	m.mask = uint32(size - 1)
+	for i := range m.keys {
+		m.insertSlot(i)
+	}
+}
+
+func (m *FieldMatcher) insertSlot(keyIndex int) {
+	slot := hashFieldName(m.keys[keyIndex]) & m.mask
+	for m.slots[slot] != 0 {
+		slot = (slot + 1) & m.mask
+	}
+	m.slots[slot] = int32(keyIndex + 1)
+}
+
+// Len returns the number of property names in the FieldMatcher.
+func (m *FieldMatcher) Len() int {
+	return len(m.names)
+}
+
+// Name returns the property name at the specified index.
+func (m *FieldMatcher) Name(index int) string {
+	return string(m.names[index])
+}
+
+// Match returns the index of the specified property name, or -1 if it is not one of the names in
+// the FieldMatcher. The name can be given exactly as ObjectState.Name returns it, that is, possibly
+// still containing JSON escape sequences.
+func (m *FieldMatcher) Match(name []byte) int {
+	// A name with a backslash is only matched after decoding, since its raw form can be equal to a
+	// different field name: the raw name a\\b stands for a\b, not for a field called a\\b.
+	if bytes.IndexByte(name, '\\') < 0 {
+		return m.matchUnescaped(name)
+	}
+	var buf [64]byte
+	decoded, _ := decodeName(buf[:0], name)
+	return m.matchUnescaped(decoded)
+}
+
+func (m *FieldMatcher) matchUnescaped(name []byte) int {
+	for slot := hashFieldName(name) & m.mask; ; slot = (slot + 1) & m.mask {
+		i := m.slots[slot]
+		if i == 0 {
+			return -1
+		}
+		if string(m.keys[i-1]) == string(name) {
+			return m.keyFields[i-1]
+		}
+	}
+}
+
+// hashFieldName is a deliberately cheap hash that only looks at the length and three bytes of the
+// name; property names in a single schema almost always differ in at least one of these.
+func hashFieldName(name []byte) uint32 {
+	n := len(name)
+	if n == 0 {
+		return 0
+	}
+	h := uint32(n) * 0x9e3779b1
+	h ^= uint32(name[0]) | uint32(name[n/2])<<8 | uint32(name[n-1])<<16
+	return h ^ (h >> 15)
+}
+
+// NextMatch is equivalent to calling Next, and then passing the property name to the FieldMatcher's
+// Match method. It returns the field index (or -1 for an unrecognized property) and true if a
+// property is available, or -1 and false at the end of the object.
+//
+// As with Next, if the caller does not read the value of a property, calling NextMatch again
+// skips it.
+func (obj *ObjectState) NextMatch(m *FieldMatcher) (int, bool) {
+	if !obj.Next() {
+		return -1, false
+	}
+	return m.Match(obj.name), true
+}
@@ -0,0 +1,57 @@
+package jreader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldMatcherMatch(t *testing.T) {
+	m := NewFieldMatcher([]string{"a", "ab", "ba", "abc", ""})
+
+	assert.Equal(t, 0, m.Match([]byte("a")))
+	assert.Equal(t, 1, m.Match([]byte("ab")))
+	assert.Equal(t, 2, m.Match([]byte("ba")))
+	assert.Equal(t, 3, m.Match([]byte("abc")))
+	assert.Equal(t, 4, m.Match([]byte("")))
+	assert.Equal(t, -1, m.Match([]byte("b")))
+	assert.Equal(t, -1, m.Match([]byte("abcd")))
+	assert.Equal(t, -1, m.Match([]byte("xy")))
+}
+
+func TestFieldMatcherMatchDecodesEscapes(t *testing.T) {
+	m := NewFieldMatcher([]string{"ab", `a"b`})
+
+	assert.Equal(t, 0, m.Match([]byte(`ab`)))
+	assert.Equal(t, 1, m.Match([]byte(`a\"b`)))
+	assert.Equal(t, -1, m.Match([]byte(`a\qb`)))
+}
+
+func TestFieldMatcherDoesNotMatchRawEscapes(t *testing.T) {
+	m := NewFieldMatcher([]string{`a\\b`, `a\u0062`})
+
+	assert.Equal(t, -1, m.Match([]byte(`a\\b`)), "the raw name a\\\\b is a\\b")
+	assert.Equal(t, 0, m.Match([]byte(`a\\\\b`)))
+	assert.Equal(t, -1, m.Match([]byte(`a\u0062`)), "the raw name a\\u0062 is ab")
+}
+
+func TestObjectStateNextMatch(t *testing.T) {
+	m := NewFieldMatcher([]string{"name", "count"})
+	r := NewReader([]byte(`{"count":2, "other":[1,2], "name":"x"}`))
+
+	var indexes []int
+	obj := r.Object()
+	for {
+		i, ok := obj.NextMatch(m)
+		if !ok {
+			break
+		}
+		indexes = append(indexes, i)
+		if i == 1 {
+			require.Equal(t, int64(2), r.Int64())
+		}
+	}
+	require.NoError(t, r.Error())
+	assert.Equal(t, []int{1, -1, 0}, indexes)
+}
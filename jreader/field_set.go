@@ -0,0 +1,209 @@
+package jreader
+
+import "fmt"
+
+// FieldSet is a FieldMatcher that can also recognize alternate names for a field, for instance when
+// an API has renamed a property but still accepts the old name:
+//
+//	var colorFields = newColorFields()
+//
+//	func newColorFields() *jreader.FieldSet {
+//	    fs := jreader.NewFieldSet("color", "size")
+//	    fs.Alias("color", "colour")
+//	    return fs
+//	}
+//
+// Since a FieldSet is normally set up once at startup, mistakes in the setup (such as giving two
+// different fields the same alias) do not cause a panic, but are recorded and reported by Err.
+type FieldSet struct {
+	FieldMatcher
+	err error
+}
+
+// NewFieldSet creates a FieldSet for the specified property names. As with NewFieldMatcher, the
+// index of each name is the value that Match returns for it.
+func NewFieldSet(names ...string) *FieldSet {
+	fs := &FieldSet{}
+	fs.init(names)
+	return fs
+}
+
+// Alias adds alternate names for the field whose canonical name is canonical. After this, Match
+// returns the canonical field's index for any of the aliases.
+//
+// If canonical is not one of the FieldSet's names, or if an alias is already the name or alias of a
+// different field, the alias is not added and the FieldSet records an error that can be retrieved
+// with Err.
+func (fs *FieldSet) Alias(canonical string, aliases ...string) {
+	field := fs.matchUnescaped([]byte(canonical))
+	if field < 0 || fs.Name(field) != canonical {
+		fs.addError(fmt.Errorf("cannot add alias for unknown field %q", canonical))
+		return
+	}
+	for _, alias := range aliases {
+		name := []byte(alias)
+		existing := fs.matchUnescaped(name)
+		if existing == field {
+			continue
+		}
+		if existing >= 0 {
+			fs.addError(fmt.Errorf("alias %q for field %q is already used by field %q", alias, canonical,
+				fs.Name(existing)))
+			continue
+		}
+		fs.addKey(name, field)
+	}
+}
+
+// Err returns the first error that occurred while setting up the FieldSet, or nil if there was none.
+func (fs *FieldSet) Err() error {
+	return fs.err
+}
+
+func (fs *FieldSet) addError(err error) {
+	if fs.err == nil {
+		fs.err = err
+	}
+}
+
+// FieldPresence records which fields of a FieldMatcher appeared in an object that was read with
+// ObjectState.TrackPresence. This is useful for telling apart a property that was absent from one
+// whose value happened to equal the default, for instance to implement PATCH semantics:
+//
+//	var userFields = jreader.NewFieldMatcher([]string{"name", "email"})
+//
+//	present := jreader.NewFieldPresence(userFields)
+//	for _, data := range documents {
+//	    r := jreader.NewReader(data)
+//	    obj := r.Object()
+//	    obj.TrackPresence(present)
+//	    for obj.Next() {
+//	        ...
+//	    }
+//	    if present.HasName("email") { ... }
+//	}
+//
+// The FieldMatcher is not modified, so it can still be shared, but a FieldPresence holds the state
+// of one object at a time: it can be reused for any number of objects, since TrackPresence clears
+// it, but not by several goroutines at once.
+type FieldPresence struct {
+	matcher *FieldMatcher
+	bits    []uint64
+}
+
+// NewFieldPresence creates a FieldPresence for the fields of a FieldMatcher, or of a FieldSet
+// (passing &fs.FieldMatcher), in which case a field also counts as present if one of its aliases
+// appears. All allocation is done here; tracking does not allocate.
+func NewFieldPresence(m *FieldMatcher) *FieldPresence {
+	return &FieldPresence{matcher: m, bits: make([]uint64, (m.Len()+63)/64)}
+}
+
+// Reset marks all fields as absent.
+func (p *FieldPresence) Reset() {
+	for i := range p.bits {
+		p.bits[i] = 0
+	}
+}
+
+// TrackPresence makes the ObjectState record in p which of p's fields appear in the object, after
+// clearing any fields that p recorded for a previous object:
+//
+//	obj := r.Object()
+//	obj.TrackPresence(present)
+//	for obj.Next() {
+//	    ...
+//	}
+//	if present.HasName("email") { ... }
+//
+// TrackPresence should be called before the first call to Next. A property counts as present as
+// soon as Next has returned it, even if its value was never read, and property names are matched
+// after any escape sequences in them are decoded.
+func (obj *ObjectState) TrackPresence(p *FieldPresence) {
+	p.Reset()
+	obj.presence = p
+}
+
+// Has returns true if the field with the specified index was seen in the object. It returns false
+// if the index is out of range.
+func (p *FieldPresence) Has(index int) bool {
+	if index < 0 || index/64 >= len(p.bits) {
+		return false
+	}
+	return p.bits[index/64]&(1<<uint(index%64)) != 0
+}
+
+// HasName is the same as Has, but takes the name (or an alias) of the field instead of its index.
+func (p *FieldPresence) HasName(name string) bool {
+	return p.Has(p.matcher.matchUnescaped([]byte(name)))
+}
+
+// MissingNames returns the names of all fields that were not seen in the object, in the order
+// they were given to the FieldMatcher.
+func (p *FieldPresence) MissingNames() []string {
+	var missing []string
+	for i := 0; i < p.matcher.Len(); i++ {
+		if !p.Has(i) {
+			missing = append(missing, p.matcher.Name(i))
+		}
+	}
+	return missing
+}
+
+// RequireExactlyOne checks that exactly one of the specified property names appeared in an object
+// that was read with TrackPresence, for a schema where several properties are mutually exclusive
+// but one of them is mandatory, such as a union that is tagged by which property is present:
+//
+//	var shapeFields = jreader.NewFieldMatcher([]string{"circle", "square"})
+//
+//	obj := r.Object()
+//	obj.TrackPresence(jreader.NewFieldPresence(shapeFields))
+//	for obj.Next() {
+//	    ...
+//	}
+//	if err := obj.RequireExactlyOne("circle", "square"); err != nil { ... }
+//
+// It should be called after Next has returned false. If none or more than one of the names were
+// seen, and no other error has occurred, the Reader's error state is set to a OneOfPropertyError,
+// which is also returned. If the Reader had already failed, its existing error is returned instead.
+// All of the names must be fields of the FieldPresence passed to TrackPresence (aliases are also
+// accepted); if not, the error is a UsageError.
+func (obj *ObjectState) RequireExactlyOne(names ...string) error {
+	if obj.r != nil && obj.r.err != nil {
+		return obj.r.err
+	}
+	offset := 0
+	if obj.r != nil {
+		offset = obj.r.tr.LastPos()
+	}
+	err := obj.presence.requireExactlyOne(names, offset)
+	if err != nil && obj.r != nil {
+		obj.r.AddError(err)
+	}
+	return err
+}
+
+func (p *FieldPresence) requireExactlyOne(names []string, offset int) error {
+	if p == nil {
+		return UsageError{Message: errMsgOneOfNotTracked, Offset: offset}
+	}
+	var present []string
+	for _, name := range names {
+		field := p.matcher.matchUnescaped([]byte(name))
+		if field < 0 {
+			return UsageError{Message: errMsgOneOfNotTracked, Offset: offset}
+		}
+		if p.Has(field) {
+			present = append(present, name)
+		}
+	}
+	if len(present) != 1 {
+		return OneOfPropertyError{Names: names, Present: present, Offset: offset}
+	}
+	return nil
+}
+
+func (p *FieldPresence) mark(name []byte) {
+	if i := p.matcher.Match(name); i >= 0 {
+		p.bits[i/64] |= 1 << uint(i%64)
+	}
+}
@@ -0,0 +1,249 @@
+package jreader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldSetAlias(t *testing.T) {
+	fs := NewFieldSet("color", "size")
+	fs.Alias("color", "colour", "couleur")
+	fs.Alias("size", "dimension")
+	require.NoError(t, fs.Err())
+
+	assert.Equal(t, 0, fs.Match([]byte("color")))
+	assert.Equal(t, 0, fs.Match([]byte("colour")))
+	assert.Equal(t, 0, fs.Match([]byte("couleur")))
+	assert.Equal(t, 1, fs.Match([]byte("size")))
+	assert.Equal(t, 1, fs.Match([]byte("dimension")))
+	assert.Equal(t, -1, fs.Match([]byte("shape")))
+	assert.Equal(t, 2, fs.Len())
+	assert.Equal(t, "color", fs.Name(0))
+}
+
+func TestFieldSetAliasWithManyNames(t *testing.T) {
+	fs := NewFieldSet("a")
+	var aliases []string
+	for i := 0; i < 100; i++ {
+		aliases = append(aliases, string(rune('b'+i%20))+string(rune('a'+i/20)))
+	}
+	fs.Alias("a", aliases...)
+	require.NoError(t, fs.Err())
+	for _, alias := range aliases {
+		assert.Equal(t, 0, fs.Match([]byte(alias)), alias)
+	}
+}
+
+func TestFieldSetAliasCollisionIsReported(t *testing.T) {
+	fs := NewFieldSet("color", "size")
+	fs.Alias("color", "c")
+	fs.Alias("size", "c")
+	require.Error(t, fs.Err())
+	assert.Contains(t, fs.Err().Error(), `"c"`)
+	assert.Equal(t, 0, fs.Match([]byte("c")))
+
+	fs = NewFieldSet("color", "size")
+	fs.Alias("color", "size")
+	require.Error(t, fs.Err())
+	assert.Equal(t, 1, fs.Match([]byte("size")))
+
+	fs = NewFieldSet("color")
+	fs.Alias("shape", "form")
+	require.Error(t, fs.Err())
+}
+
+func TestFieldSetWithNextMatch(t *testing.T) {
+	fs := NewFieldSet("color")
+	fs.Alias("color", "colour")
+	r := NewReader([]byte(`{"colour":"red"}`))
+	var color string
+	for obj := r.Object(); ; {
+		i, ok := obj.NextMatch(&fs.FieldMatcher)
+		if !ok {
+			break
+		}
+		if i == 0 {
+			color = string(r.String())
+		}
+	}
+	require.NoError(t, r.Error())
+	assert.Equal(t, "red", color)
+}
+
+func readerFor(data string) *Reader {
+	r := NewReader([]byte(data))
+	return &r
+}
+
+func readWithPresence(t *testing.T, r *Reader, fields []string) *FieldPresence {
+	present := NewFieldPresence(NewFieldMatcher(fields))
+	obj := r.Object()
+	obj.TrackPresence(present)
+	for obj.Next() {
+		if string(obj.Name()) == "a" {
+			_ = r.Int64()
+		}
+	}
+	require.NoError(t, r.Error())
+	return present
+}
+
+func TestTrackPresence(t *testing.T) {
+	fields := []string{"a", "b", "c"}
+
+	t.Run("all present", func(t *testing.T) {
+		present := readWithPresence(t, readerFor(`{"c":3, "a":1, "b":2}`), fields)
+		assert.True(t, present.Has(0))
+		assert.True(t, present.Has(1))
+		assert.True(t, present.Has(2))
+		assert.Nil(t, present.MissingNames())
+	})
+
+	t.Run("none present", func(t *testing.T) {
+		present := readWithPresence(t, readerFor(`{"d":4}`), fields)
+		assert.False(t, present.HasName("a"))
+		assert.False(t, present.Has(3))
+		assert.Equal(t, fields, present.MissingNames())
+	})
+
+	t.Run("duplicates counted once", func(t *testing.T) {
+		present := readWithPresence(t, readerFor(`{"a":1, "a":2}`), fields)
+		assert.True(t, present.HasName("a"))
+		assert.Equal(t, []string{"b", "c"}, present.MissingNames())
+	})
+
+	t.Run("skipped value", func(t *testing.T) {
+		present := readWithPresence(t, readerFor(`{"b":{"x":[1,2]}, "c":null}`), fields)
+		assert.True(t, present.HasName("b"))
+		assert.True(t, present.HasName("c"))
+		assert.Equal(t, []string{"a"}, present.MissingNames())
+	})
+
+	t.Run("escaped name", func(t *testing.T) {
+		present := readWithPresence(t, readerFor(`{"\u0062":2}`), fields)
+		assert.True(t, present.HasName("b"))
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"a":1, "c":[true]}`)
+		present := readWithPresence(t, &r, fields)
+		assert.Equal(t, []string{"b"}, present.MissingNames())
+	})
+
+	t.Run("reused for another object", func(t *testing.T) {
+		present := NewFieldPresence(NewFieldMatcher(fields))
+		for _, data := range []string{`{"a":1, "b":2}`, `{"c":3}`} {
+			r := NewReader([]byte(data))
+			obj := r.Object()
+			obj.TrackPresence(present)
+			for obj.Next() {
+			}
+			require.NoError(t, r.Error())
+		}
+		assert.Equal(t, []string{"a", "b"}, present.MissingNames())
+
+		present.Reset()
+		assert.Equal(t, fields, present.MissingNames())
+	})
+
+	t.Run("alias of a FieldSet", func(t *testing.T) {
+		fs := NewFieldSet("color", "size")
+		fs.Alias("color", "colour")
+		present := NewFieldPresence(&fs.FieldMatcher)
+		r := NewReader([]byte(`{"colour": "red"}`))
+		obj := r.Object()
+		obj.TrackPresence(present)
+		for obj.Next() {
+		}
+		assert.True(t, present.HasName("color"))
+		assert.True(t, present.HasName("colour"))
+		assert.Equal(t, []string{"size"}, present.MissingNames())
+	})
+
+	t.Run("no allocations", func(t *testing.T) {
+		data := []byte(`{"a":1, "\u0062":2, "c":3}`)
+		present := NewFieldPresence(NewFieldMatcher(fields))
+		read := func(track bool) {
+			r := NewReader(data)
+			obj := r.Object()
+			if track {
+				obj.TrackPresence(present)
+			}
+			for obj.Next() {
+			}
+		}
+		withoutTracking := testing.AllocsPerRun(10, func() { read(false) })
+		withTracking := testing.AllocsPerRun(10, func() { read(true) })
+		assert.Equal(t, withoutTracking, withTracking)
+		assert.Nil(t, present.MissingNames())
+	})
+}
+
+func TestRequireExactlyOne(t *testing.T) {
+	readObject := func(data string, tracked []string) (*Reader, ObjectState) {
+		r := NewReader([]byte(data))
+		obj := r.Object()
+		if tracked != nil {
+			obj.TrackPresence(NewFieldPresence(NewFieldMatcher(tracked)))
+		}
+		for obj.Next() {
+		}
+		return &r, obj
+	}
+	shapes := []string{"circle", "square", "name"}
+
+	t.Run("exactly one", func(t *testing.T) {
+		r, obj := readObject(`{"name": "x", "square": 2}`, shapes)
+		require.NoError(t, obj.RequireExactlyOne("circle", "square"))
+		require.NoError(t, r.Error())
+	})
+
+	t.Run("none present", func(t *testing.T) {
+		r, obj := readObject(`{"name": "x"}`, shapes)
+		err := obj.RequireExactlyOne("circle", "square")
+		require.Equal(t, OneOfPropertyError{Names: []string{"circle", "square"}, Offset: 12}, err)
+		require.Equal(t, err, r.Error())
+		assert.Contains(t, err.Error(), "none was found")
+	})
+
+	t.Run("two present", func(t *testing.T) {
+		r, obj := readObject(`{"square": 2, "name": "x", "circle": 1}`, shapes)
+		err := obj.RequireExactlyOne("circle", "square")
+		require.Equal(t, OneOfPropertyError{Names: []string{"circle", "square"},
+			Present: []string{"circle", "square"}, Offset: 38}, err)
+		require.Equal(t, err, r.Error())
+	})
+
+	t.Run("alias", func(t *testing.T) {
+		fs := NewFieldSet("color", "size")
+		fs.Alias("color", "colour")
+		r := NewReader([]byte(`{"colour": "red"}`))
+		obj := r.Object()
+		obj.TrackPresence(NewFieldPresence(&fs.FieldMatcher))
+		for obj.Next() {
+		}
+		require.NoError(t, obj.RequireExactlyOne("color", "size"))
+		require.NoError(t, obj.RequireExactlyOne("colour"))
+	})
+
+	t.Run("reader already failed", func(t *testing.T) {
+		r, obj := readObject(`{"circle": 1, "square": 2}`, shapes)
+		failure := errors.New("sorry")
+		r.AddError(failure)
+		require.Equal(t, failure, obj.RequireExactlyOne("circle", "square"))
+	})
+
+	t.Run("presence not tracked", func(t *testing.T) {
+		r, obj := readObject(`{"circle": 1}`, nil)
+		require.IsType(t, UsageError{}, obj.RequireExactlyOne("circle", "square"))
+		require.IsType(t, UsageError{}, r.Error())
+	})
+
+	t.Run("name not tracked", func(t *testing.T) {
+		_, obj := readObject(`{"circle": 1}`, []string{"circle"})
+		require.IsType(t, UsageError{}, obj.RequireExactlyOne("circle", "square"))
+	})
+}
@@ -0,0 +1,30 @@
+package jreader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newPreProcessedReader creates a Reader for data and pre-processes it, so that it reads in lazy mode.
+// Any syntax error is left on the Reader.
+func newPreProcessedReader(data []byte) Reader {
+	buffer := make([]JsonTreeStruct, 0, 100)
+	charBuffer := make([]byte, 0, 100)
+	r := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer})
+	r.PreProcess()
+	return r
+}
+
+// newLazyReaderForTest is like newPreProcessedReader, but fails the test if data is not valid JSON.
+func newLazyReaderForTest(t *testing.T, data string) Reader {
+	r := newPreProcessedReader([]byte(data))
+	require.NoError(t, r.Error())
+	return r
+}
+
+// treeForTest pre-processes data and returns the resulting tree, failing the test if data is not valid JSON.
+func treeForTest(t *testing.T, data []byte) []JsonTreeStruct {
+	r := newLazyReaderForTest(t, string(data))
+	return *r.tr.structBuffer.values
+}
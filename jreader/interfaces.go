@@ -1,5 +1,10 @@
 package jreader
 
+import (
+	"fmt"
+	"strconv"
+)
+
 // AnyValue is returned by Reader.Any() to represent a JSON value of an arbitrary type.
 type AnyValue struct {
 	// Kind describes the type of the JSON value.
@@ -21,6 +26,100 @@ type AnyValue struct {
 	// Object is an ObjectState that can be used to iterate through the object properties if the
 	// JSON value is an object, or an uninitialized ObjectState{} otherwise.
 	Object ObjectState
+
+	// Elements is the list of array elements if the JSON value is an array that was read with
+	// Reader.ReadAllAny, or nil otherwise.
+	Elements []*AnyValue
+
+	// Properties is the list of object properties, in the order they appeared, if the JSON value
+	// is an object that was read with Reader.ReadAllAny, or nil otherwise.
+	Properties []AnyProperty
+}
+
+// Type returns the name of the value's JSON type: "null", "bool", "number", "string", "array", or
+// "object". This is meant for error messages such as "expected string, got " + v.Type(). It differs
+// from Kind.String() only in using "bool" rather than "boolean".
+func (v *AnyValue) Type() string {
+	if v.Kind == BoolValue {
+		return "bool"
+	}
+	return v.Kind.String()
+}
+
+// anyValueSummaryItems is the number of array elements or object properties that Format shows.
+const anyValueSummaryItems = 3
+
+// Format implements fmt.Formatter, so that the %v and %s verbs show a compact, human-readable
+// summary of the value, which is useful in test failure messages; AnyValue cannot implement
+// fmt.Stringer, since it has a field called String. The summary looks like JSON, but only the
+// first few elements of an array or properties of an object are shown, with "…" standing for the
+// rest, so it is not necessarily valid JSON. Arrays and objects are summarized from their Elements
+// and Properties fields, so their contents are only shown for values read with Reader.ReadAllAny.
+//
+// The %#v verb shows the Go representation of the struct, as it would without this method.
+func (v AnyValue) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		type plainAnyValue AnyValue // has no Format method
+		fmt.Fprintf(f, "%#v", plainAnyValue(v))
+		return
+	}
+	_, _ = f.Write(v.appendSummary(nil))
+}
+
+func (v *AnyValue) appendSummary(buf []byte) []byte {
+	switch v.Kind {
+	case BoolValue:
+		return strconv.AppendBool(buf, v.Bool)
+	case NumberValue:
+		return append(buf, v.Number.raw...)
+	case StringValue:
+		return strconv.AppendQuote(buf, string(v.String))
+	case ArrayValue:
+		buf = append(buf, '[')
+		for i, e := range v.Elements {
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			if i == anyValueSummaryItems {
+				buf = append(buf, "…"...)
+				break
+			}
+			buf = e.appendSummary(buf)
+		}
+		if v.Elements == nil {
+			buf = append(buf, "…"...)
+		}
+		return append(buf, ']')
+	case ObjectValue:
+		buf = append(buf, '{')
+		for i, p := range v.Properties {
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			if i == anyValueSummaryItems {
+				buf = append(buf, "…"...)
+				break
+			}
+			buf = strconv.AppendQuote(buf, string(p.Name))
+			buf = append(buf, ": "...)
+			buf = p.Value.appendSummary(buf)
+		}
+		if v.Properties == nil {
+			buf = append(buf, "…"...)
+		}
+		return append(buf, '}')
+	default:
+		return append(buf, "null"...)
+	}
+}
+
+// AnyProperty is an object property in an AnyValue that was read with Reader.ReadAllAny.
+type AnyProperty struct {
+	// Name is the property name, with any escape sequences decoded.
+	Name []byte
+
+	// Value is the property value.
+	Value *AnyValue
 }
 
 // ValueKind defines the allowable value types for Reader.Any.
@@ -66,6 +165,30 @@ func (k ValueKind) String() string {
 	}
 }
 
+// NumberKind distinguishes JSON numbers that are written as integers from those that are not; see
+// Reader.PeekNumberKind.
+type NumberKind int
+
+const (
+	// NumberInt means the number has no fractional part or exponent, such as 3 or -12.
+	NumberInt NumberKind = iota
+
+	// NumberFloat means the number has a fractional part or an exponent, such as 3.5, 1e3, or 2.0.
+	NumberFloat NumberKind = iota
+)
+
+// String returns a description of the NumberKind.
+func (k NumberKind) String() string {
+	switch k {
+	case NumberInt:
+		return "int"
+	case NumberFloat:
+		return "float"
+	default:
+		return "unknown number kind"
+	}
+}
+
 // Readable is an interface for types that can read their data from a Reader.
 type Readable interface {
 	// ReadFromJSONReader attempts to read the object's state from a Reader.
@@ -1,7 +1,8 @@
 package jreader
 
 import (
-	"fmt"
+	"bytes"
+	"errors"
 	"math"
 	"math/bits"
 	"strconv"
@@ -16,28 +17,31 @@ type NumberProps struct {
 	raw        []byte
 }
 
+// UInt64 converts the number to a uint64. If that is not possible, the error is a
+// NumberFormatError.
 func (val NumberProps) UInt64() (uint64, error) {
-	if val.trunc {
-		result, err := strconv.ParseUint(string(val.raw), 10, 64)
-		return result, err
+	if val.trunc || val.exponent != 0 {
+		// Either the number was not parsed, or it had too many digits to fit in the mantissa.
+		return parseUInt64(val.raw)
 	}
 	if val.isFloat {
-		return 0, fmt.Errorf("number is not a uint, because it is a float")
+		return 0, NumberFormatError{Raw: val.raw, Reason: NotAnInteger}
 	}
 	if val.isNegative {
-		return 0, fmt.Errorf("number is not a uint, because it is an int")
+		return 0, NumberFormatError{Raw: val.raw, Reason: OutOfRange}
 	}
 	return val.mantissa, nil
 }
 
+// Int64 converts the number to an int64. If that is not possible, the error is a
+// NumberFormatError.
 func (val NumberProps) Int64() (int64, error) {
-	if val.trunc {
-		result, _ := strconv.ParseInt(string(val.raw), 10, 64)
-		return result, nil
+	if val.trunc || val.exponent != 0 {
+		// Either the number was not parsed, or it had too many digits to fit in the mantissa.
+		return parseInt64(val.raw)
 	}
-	//if r.tr.options.computeNumber {
 	if val.isFloat {
-		return 0, fmt.Errorf("number is not a int, because it is a float")
+		return 0, NumberFormatError{Raw: val.raw, Reason: NotAnInteger}
 	}
 	overflow := false
 	if val.isNegative {
@@ -46,7 +50,7 @@ func (val NumberProps) Int64() (int64, error) {
 		overflow = val.mantissa > math.MaxInt64
 	}
 	if overflow {
-		return 0, fmt.Errorf("int under or over-flow")
+		return 0, NumberFormatError{Raw: val.raw, Reason: OutOfRange}
 	}
 	if val.isNegative {
 		if val.mantissa == math.MaxInt64+1 {
@@ -66,6 +70,195 @@ func (val NumberProps) Float64() (float64, error) {
 	return f, nil
 }
 
+// Equal returns true if the two numbers have the same numeric value, regardless of how they are
+// written: 1, 1.0, and 1e0 are all equal, and so are 0 and -0. The comparison is exact, so it
+// distinguishes numbers that would be rounded to the same float64.
+func (val NumberProps) Equal(other NumberProps) bool {
+	var buf1, buf2 [32]byte
+	neg1, digits1, exp1 := decimalParts(buf1[:0], val.raw)
+	neg2, digits2, exp2 := decimalParts(buf2[:0], other.raw)
+	return neg1 == neg2 && exp1 == exp2 && bytes.Equal(digits1, digits2)
+}
+
+// HasFraction returns true if the number was written with a decimal point, as in 3.5 or 1.0e3.
+// Together with HasExponent, this describes the style in which the number was written, regardless of
+// its value, so that it can be reproduced when the number is written again.
+func (val NumberProps) HasFraction() bool {
+	return bytes.IndexByte(val.raw, '.') >= 0
+}
+
+// HasExponent returns true if the number was written in scientific notation, with an "e" or "E", as
+// in 3.5e3 or 1E-2.
+func (val NumberProps) HasExponent() bool {
+	return bytes.IndexAny(val.raw, "eE") >= 0
+}
+
+// scaleDecimal converts the text of a JSON number to an integer after multiplying it by 10 to the
+// power of decimals. If that is not possible, it returns the reason for a NumberFormatError.
+func scaleDecimal(raw []byte, decimals int) (int64, NumberFormatReason) {
+	var buf [32]byte
+	neg, digits, exp := decimalParts(buf[:0], raw)
+	zeros := exp + decimals - len(digits) // the number of zeros that follow the digits
+	if zeros < 0 {
+		return 0, NotAnInteger
+	}
+	if len(digits)+zeros > 20 { // more digits than any uint64
+		return 0, OutOfRange
+	}
+	var n uint64
+	for i := 0; i < len(digits)+zeros; i++ {
+		d := uint64(0)
+		if i < len(digits) {
+			d = uint64(digits[i] - '0')
+		}
+		if n > (math.MaxUint64-d)/10 {
+			return 0, OutOfRange
+		}
+		n = n*10 + d
+	}
+	switch {
+	case !neg && n <= math.MaxInt64:
+		return int64(n), 0
+	case neg && n <= math.MaxInt64+1:
+		return -int64(n-1) - 1, 0 // n is never zero here, since decimalParts treats -0 as 0
+	default:
+		return 0, OutOfRange
+	}
+}
+
+// decimalParts converts the text of a JSON number to a canonical form: its value is 0.digits times
+// 10 to the power of exp, where digits has no leading or trailing zeros. For zero, digits is empty
+// and neg and exp are always false and 0. The digits are appended to buf.
+func decimalParts(buf []byte, raw []byte) (neg bool, digits []byte, exp int) {
+	i := 0
+	if i < len(raw) && raw[i] == '-' {
+		neg = true
+		i++
+	}
+	digits = buf
+	for ; i < len(raw) && isDigit(raw[i]); i++ {
+		if raw[i] != '0' || len(digits) != 0 {
+			digits = append(digits, raw[i])
+		}
+		if len(digits) != 0 {
+			exp++
+		}
+	}
+	if i < len(raw) && raw[i] == '.' {
+		for i++; i < len(raw) && isDigit(raw[i]); i++ {
+			if raw[i] == '0' && len(digits) == 0 {
+				exp-- // a leading zero after the decimal point
+				continue
+			}
+			digits = append(digits, raw[i])
+		}
+	}
+	if i < len(raw) && (raw[i] == 'e' || raw[i] == 'E') {
+		i++
+		expNeg := false
+		if i < len(raw) && isSign(raw[i]) {
+			expNeg = raw[i] == '-'
+			i++
+		}
+		e := 0
+		for ; i < len(raw) && isDigit(raw[i]); i++ {
+			if e < 1e9 { // larger exponents are far out of range for any numeric type anyway
+				e = 10*e + int(raw[i]-'0')
+			}
+		}
+		if expNeg {
+			e = -e
+		}
+		exp += e
+	}
+	for len(digits) > 0 && digits[len(digits)-1] == '0' {
+		digits = digits[:len(digits)-1]
+	}
+	if len(digits) == 0 {
+		return false, digits, 0
+	}
+	return neg, digits, exp
+}
+
+// numberToInt64 converts a number that was read by the Reader to an int64. When the Reader is in
+// lazy mode without computed numbers, the number has not been parsed yet, so its raw text is parsed
+// directly; otherwise the parsed NumberProps are used. A NumberFormatError gets the position of the
+// number.
+func (r *Reader) numberToInt64(n *NumberProps) (int64, error) {
+	var result int64
+	var err error
+	if r.IsNumbersRaw() {
+		result, err = parseInt64(n.raw)
+	} else {
+		result, err = n.Int64()
+	}
+	return result, r.withNumberOffset(err)
+}
+
+func (r *Reader) numberToUInt64(n *NumberProps) (uint64, error) {
+	var result uint64
+	var err error
+	if r.IsNumbersRaw() {
+		result, err = parseUInt64(n.raw)
+	} else {
+		result, err = n.UInt64()
+	}
+	return result, r.withNumberOffset(err)
+}
+
+func (r *Reader) numberToFloat64(n *NumberProps) (float64, error) {
+	if r.IsNumbersRaw() {
+		return strconv.ParseFloat(string(n.raw), 64)
+	}
+	return n.Float64()
+}
+
+// withNumberOffset sets the Offset of a NumberFormatError to the position of the value that was
+// just read.
+func (r *Reader) withNumberOffset(err error) error {
+	if e, ok := err.(NumberFormatError); ok {
+		e.Offset = r.tr.LastPos()
+		if r.tr.options.lazyRead && r.tr.structBuffer.pos > 0 {
+			e.Offset = (*r.tr.structBuffer.values)[r.tr.structBuffer.pos-1].start
+		}
+		return e
+	}
+	return err
+}
+
+func parseInt64(raw []byte) (int64, error) {
+	n, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return 0, numberFormatError(raw, err)
+	}
+	return n, nil
+}
+
+func parseUInt64(raw []byte) (uint64, error) {
+	n, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return 0, numberFormatError(raw, err)
+	}
+	return n, nil
+}
+
+// numberFormatError converts an error from strconv.ParseInt or strconv.ParseUint to a
+// NumberFormatError. The raw text is assumed to have been accepted as a JSON number by the
+// tokenizer, so a syntax error means that it was not an integer, unless it was a negative integer
+// that was being parsed as unsigned.
+func numberFormatError(raw []byte, err error) NumberFormatError {
+	reason := InvalidSyntax
+	switch {
+	case bytes.ContainsAny(raw, ".eE"):
+		reason = NotAnInteger
+	case errors.Is(err, strconv.ErrRange):
+		reason = OutOfRange
+	case len(raw) > 1 && raw[0] == '-' && isDigit(raw[1]):
+		reason = OutOfRange
+	}
+	return NumberFormatError{Raw: raw, Reason: reason}
+}
+
 const maxMantDigits = 19
 
 func isDigit(b byte) bool {
@@ -76,12 +269,23 @@ func isSign(b byte) bool {
 	return b == '-' || b == '+'
 }
 
-func (r *tokenReader) readNumberProps(first byte, result *NumberProps) bool { //nolint:unparam
+func (r *tokenReader) readNumberProps(first byte, result *NumberProps) bool {
 	startPos := r.pos - 1
 	ch, success := first, true
 
 	*result = NumberProps{}
 
+	if r.options.deferNumber {
+		// Check the number against the JSON grammar, but leave parsing it until it is first read.
+		end, ok := scanNumber(r.data[:r.len], startPos)
+		if !ok {
+			return false
+		}
+		r.pos = end
+		result.trunc = true
+		result.raw = r.data[startPos:end]
+		return true
+	}
 	if r.options.readRawNumbers {
 		// basic version which must be strconv parsed
 		switch ch {
@@ -99,6 +303,9 @@ func (r *tokenReader) readNumberProps(first byte, result *NumberProps) bool { //
 			r.unreadByte()
 			break
 		}
+		if r.pos < r.len && isInvalidAfterNumber(r.data[r.pos]) {
+			return false
+		}
 		result.trunc = true
 		result.raw = r.data[startPos:r.pos]
 		return true
@@ -215,9 +422,10 @@ func (r *tokenReader) readNumberProps(first byte, result *NumberProps) bool { //
 					return false
 				}
 			}
-			if isDigit(ch) {
-				expPart = int(ch - '0')
+			if !isDigit(ch) {
+				return false
 			}
+			expPart = int(ch - '0')
 			for {
 				ch, success = r.readByte()
 				if !success {
@@ -241,11 +449,14 @@ func (r *tokenReader) readNumberProps(first byte, result *NumberProps) bool { //
 			result.exponent = dp - ndMant
 		}
 
-		// endPos := r.pos
-		if r.pos == len(r.data) {
+		// If success is true, the last byte that was read is not part of the number.
+		if !success {
 			result.raw = r.data[startPos:r.pos]
 		} else {
 			r.unreadByte()
+			if isInvalidAfterNumber(r.data[r.pos]) {
+				return false
+			}
 			result.raw = r.data[startPos:r.pos]
 		}
 
@@ -253,6 +464,58 @@ func (r *tokenReader) readNumberProps(first byte, result *NumberProps) bool { //
 	}
 }
 
+// scanNumber checks that data[pos:] begins with a number in the JSON grammar, followed by a character
+// that can end one as described for isInvalidAfterNumber, and returns the position after the number.
+// Unlike readNumberProps, this does not compute anything from the digits.
+func scanNumber(data []byte, pos int) (int, bool) {
+	if pos < len(data) && data[pos] == '-' {
+		pos++
+	}
+	switch {
+	case pos < len(data) && data[pos] == '0':
+		pos++
+	case pos < len(data) && isDigit(data[pos]):
+		pos = skipDigits(data, pos)
+	default:
+		return pos, false
+	}
+	if pos < len(data) && data[pos] == '.' {
+		if pos++; pos >= len(data) || !isDigit(data[pos]) {
+			return pos, false
+		}
+		pos = skipDigits(data, pos)
+	}
+	if pos < len(data) && (data[pos] == 'e' || data[pos] == 'E') {
+		if pos++; pos < len(data) && isSign(data[pos]) {
+			pos++
+		}
+		if pos >= len(data) || !isDigit(data[pos]) {
+			return pos, false
+		}
+		pos = skipDigits(data, pos)
+	}
+	if pos < len(data) && isInvalidAfterNumber(data[pos]) {
+		return pos, false
+	}
+	return pos, true
+}
+
+func skipDigits(data []byte, pos int) int {
+	for pos < len(data) && isDigit(data[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// isInvalidAfterNumber returns true for characters that cannot immediately follow a JSON number, but
+// that could be taken as a continuation of it in some other syntax, such as the 'x' of a hexadecimal
+// literal "0x1A" or the '_' of "1_000". Rejecting these makes such input a syntax error at the start
+// of the number, rather than reading part of it as a valid number and failing on what follows.
+func isInvalidAfterNumber(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || isDigit(ch) ||
+		ch == '_' || ch == '.' || ch == '+' || ch == '-'
+}
+
 func readFloat(props *NumberProps) (f float64, n int, err error) {
 	ok := true
 
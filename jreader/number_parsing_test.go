@@ -1,8 +1,13 @@
 package jreader
 
 import (
+	"errors"
 	"fmt"
+	"github.com/Brat-vseznamus/go-jsonstream/v3/internal/commontest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math"
 	"strconv"
 	"strings"
 	"testing"
@@ -491,3 +496,612 @@ func underscoreOK(s string) bool {
 	}
 	return saw != '_'
 }
+
+// longMantissaInputs are decimals with more significant digits than fit in the 19-digit mantissa
+// of NumberProps, including values that are exactly or almost halfway between two float64 values.
+var longMantissaInputs = []string{ //nolint:gochecknoglobals
+	"0.123456789111315171921232527",
+	"0.12345678911131517192123252729",
+	"9007199254740993.0000000000000000001",
+	"9007199254740993000000000000000000001e-20",
+	"-9007199254740993.00000000000000000000",
+	"1.00000000000000011102230246251565404236316680908203124",
+	"1.00000000000000011102230246251565404236316680908203125",
+	"1.00000000000000011102230246251565404236316680908203126",
+	"0.1000000000000000055511151231257827021181583404541015625",
+	"0.10000000000000000555111512312578270211815834045410156250000000000001",
+	"2.22507385850720113605740979670913197593481954635164564e-308",
+	"4.94065645841246544176568792868221372365059802614324764425585682500675507270208751865299836361635992379796564695445717730926656710355939796398774796010781878126300713190311404527845817167848982103688718636056998730723050006387409153564984387312473397273169615140031715385398074126238565591171026658556686768187039560310624931945271591492455329305456544401127480129709999541931989409080416563324524757147869014726780159355238611550134803526493472019379026810710749170333222684475333572083243193609238289345836806010601150616980975307834227731832924790498252473077637592724787465608477820373446969953364701797267771758512566055119913150489110145103786273816725095583738973359899366480994116420570263709027924276754456522908753868250641971826553344726562500000000000000001e-324",
+	"123456789012345678901234567890",
+	"-0.0000000000000000123456789111315171921",
+	"72057594037927933.0000000000000000000001",
+}
+
+func TestFloat64WithLongMantissaMatchesStrconv(t *testing.T) {
+	for _, input := range longMantissaInputs {
+		expected, err := strconv.ParseFloat(input, 64)
+		require.NoError(t, err)
+
+		for _, rawNumbers := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s (raw: %t)", input, rawNumbers), func(t *testing.T) {
+				r := NewReader([]byte(input))
+				r.SetNumberRawRead(rawNumbers)
+				assert.Equal(t, expected, r.Float64())
+				require.NoError(t, r.Error())
+
+				r = NewReader([]byte(input))
+				r.SetNumberRawRead(rawNumbers)
+				result, ok := r.Float64OrNull()
+				assert.True(t, ok)
+				assert.Equal(t, expected, result)
+
+				r = NewReader([]byte(input))
+				r.SetNumberRawRead(rawNumbers)
+				f, err := r.Any().Number.Float64()
+				require.NoError(t, err)
+				assert.Equal(t, expected, f)
+			})
+		}
+	}
+}
+
+func TestFloat64WithLongMantissaMatchesStrconvInLazyMode(t *testing.T) {
+	for _, input := range longMantissaInputs {
+		expected, err := strconv.ParseFloat(input, 64)
+		require.NoError(t, err)
+
+		for _, computeNumbers := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s (computed: %t)", input, computeNumbers), func(t *testing.T) {
+				newReader := func() Reader {
+					buffer := make([]JsonTreeStruct, 0, 10)
+					charBuffer := make([]byte, 0, 10)
+					config := BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer}
+					if computeNumbers {
+						numbers := make([]NumberProps, 0, 10)
+						config.ComputedValuesBuffer.NumberValues = &numbers
+					}
+					r := NewReaderWithBuffers([]byte(input), config)
+					r.SetNumberRawRead(false)
+					r.PreProcess()
+					return r
+				}
+
+				r := newReader()
+				assert.Equal(t, expected, r.Float64())
+				require.NoError(t, r.Error())
+
+				r = newReader()
+				f, err := r.Any().Number.Float64()
+				require.NoError(t, err)
+				assert.Equal(t, expected, f)
+			})
+		}
+	}
+}
+
+// numberReaderMode is a way of configuring a Reader that uses different number parsing logic.
+type numberReaderMode struct {
+	name      string
+	newReader func(data []byte) Reader
+}
+
+// numberReaderModes are the standard numberReaderMode values.
+var numberReaderModes = []numberReaderMode{ //nolint:gochecknoglobals
+	{"direct raw", func(data []byte) Reader {
+		return NewReader(data)
+	}},
+	{"direct computed", func(data []byte) Reader {
+		r := NewReader(data)
+		r.SetNumberRawRead(false)
+		return r
+	}},
+	{"lazy raw", newPreProcessedReader},
+	{"lazy computed", func(data []byte) Reader {
+		buffer := make([]JsonTreeStruct, 0, 10)
+		charBuffer := make([]byte, 0, 10)
+		numbers := make([]NumberProps, 0, 10)
+		r := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer,
+			ComputedValuesBuffer: JsonComputedValues{NumberValues: &numbers}})
+		r.SetNumberRawRead(false)
+		r.PreProcess()
+		return r
+	}},
+}
+
+func TestNonJSONNumberSyntaxIsRejected(t *testing.T) {
+	inputs := []struct {
+		input  string
+		offset int
+	}{
+		{"0x1A", 0},
+		{"1_000", 0},
+		{"1.5_0", 0},
+		{"12abc", 0},
+		{"-Infinity", 0},
+		{"[1, 0x1A]", 4},
+		{`{"a": 1_000}`, 6},
+	}
+	for _, mode := range numberReaderModes {
+		for _, test := range inputs {
+			t.Run(fmt.Sprintf("%s: %s", mode.name, test.input), func(t *testing.T) {
+				r := mode.newReader([]byte(test.input))
+				_ = r.SkipValue()
+				require.Equal(t, SyntaxError{Message: errMsgInvalidNumber, Offset: test.offset}, r.Error())
+			})
+		}
+	}
+}
+
+func TestLeadingPlusSignIsRejected(t *testing.T) {
+	inputs := []struct {
+		input  string
+		offset int
+		scalar bool
+	}{
+		{"+1", 0, true},
+		{"+0", 0, true},
+		{"+1.5", 0, true},
+		{"  +1", 2, true},
+		{"[1, +2]", 4, false},
+		{`{"a": +0}`, 6, false},
+	}
+	for _, mode := range numberReaderModes {
+		for _, test := range inputs {
+			t.Run(fmt.Sprintf("%s: %s", mode.name, test.input), func(t *testing.T) {
+				expected := SyntaxError{Message: errMsgUnexpectedChar, Value: "+", Offset: test.offset}
+
+				r := mode.newReader([]byte(test.input))
+				_ = r.SkipValue()
+				require.Equal(t, expected, r.Error())
+
+				if test.scalar {
+					r = mode.newReader([]byte(test.input))
+					_ = r.Int64()
+					require.Equal(t, expected, r.Error())
+
+					r = mode.newReader([]byte(test.input))
+					_ = r.Float64()
+					require.Equal(t, expected, r.Error())
+				}
+			})
+		}
+	}
+}
+
+func TestNonJSONNumberErrorDoesNotConsumeToken(t *testing.T) {
+	r := NewReader([]byte(`0x1A`))
+	r.SetNumberRawRead(false)
+	_ = r.Float64()
+	require.Equal(t, SyntaxError{Message: errMsgInvalidNumber, Offset: 0}, r.Error())
+	require.Equal(t, 0, r.Consumed())
+}
+
+func TestNumberCorpus(t *testing.T) {
+	for _, mode := range numberReaderModes {
+		for _, c := range commontest.NumberCorpus() {
+			t.Run(fmt.Sprintf("%s: %s", mode.name, c.Text), func(t *testing.T) {
+				r := mode.newReader([]byte(c.Text))
+				i := r.Int64()
+				if c.Int64OK {
+					assert.NoError(t, r.Error(), "Int64")
+					assert.Equal(t, c.Int64, i, "Int64")
+				} else {
+					assert.Error(t, r.Error(), "Int64 should fail, but returned %d", i)
+				}
+
+				r = mode.newReader([]byte(c.Text))
+				u := r.UInt64()
+				if c.UInt64OK {
+					assert.NoError(t, r.Error(), "UInt64")
+					assert.Equal(t, c.UInt64, u, "UInt64")
+				} else {
+					assert.Error(t, r.Error(), "UInt64 should fail, but returned %d", u)
+				}
+
+				r = mode.newReader([]byte(c.Text))
+				f := r.Float64()
+				if c.Float64OK {
+					assert.NoError(t, r.Error(), "Float64")
+					assert.Equal(t, math.Float64bits(c.Float64), math.Float64bits(f), "Float64: expected %g, got %g",
+						c.Float64, f)
+				} else {
+					assert.Error(t, r.Error(), "Float64 should fail, but returned %g", f)
+				}
+			})
+		}
+	}
+}
+
+func TestNegativeZero(t *testing.T) {
+	for _, mode := range numberReaderModes {
+		for _, input := range []string{"-0", "-0.0", "-0e5", "-0.000E-3"} {
+			t.Run(fmt.Sprintf("%s: %s", mode.name, input), func(t *testing.T) {
+				r := mode.newReader([]byte(input))
+				f := r.Float64()
+				require.NoError(t, r.Error())
+				require.True(t, math.Signbit(f), "Float64 returned %g", f)
+
+				r = mode.newReader([]byte(input))
+				f, nonNull := r.Float64OrNull()
+				require.NoError(t, r.Error())
+				require.True(t, nonNull)
+				require.True(t, math.Signbit(f), "Float64OrNull returned %g", f)
+
+				r = mode.newReader([]byte(`[` + input + `]`))
+				arr := r.Array()
+				require.True(t, arr.Next())
+				f = r.Float64()
+				require.NoError(t, r.Error())
+				require.True(t, math.Signbit(f), "Float64 in array returned %g", f)
+
+				r = mode.newReader([]byte(input))
+				f, err := r.NumberProps().Float64()
+				require.NoError(t, err)
+				require.True(t, math.Signbit(f), "NumberProps.Float64 returned %g", f)
+
+				r = mode.newReader([]byte(`{"a":` + input + `}`))
+				m, err := r.Float64Map()
+				require.NoError(t, err)
+				require.True(t, math.Signbit(m["a"]), "Float64Map returned %g", m["a"])
+			})
+		}
+
+		t.Run(mode.name+": Int64", func(t *testing.T) {
+			r := mode.newReader([]byte(`-0`))
+			i := r.Int64()
+			require.NoError(t, r.Error())
+			require.Equal(t, int64(0), i)
+		})
+	}
+}
+
+func TestNumberFollowedByLastByteOfInput(t *testing.T) {
+	for _, mode := range numberReaderModes {
+		for _, input := range []string{`[1]`, `[-0.5]`, `[1e3]`, `{"a":0}`} {
+			t.Run(fmt.Sprintf("%s: %s", mode.name, input), func(t *testing.T) {
+				r := mode.newReader([]byte(input))
+				require.NoError(t, r.SkipValue())
+				require.NoError(t, r.RequireEOF())
+			})
+		}
+	}
+}
+
+func TestNumberFormatError(t *testing.T) {
+	for _, mode := range numberReaderModes {
+		for _, tc := range []struct {
+			input    string
+			unsigned bool
+			reason   NumberFormatReason
+		}{
+			{"3.5", false, NotAnInteger},
+			{"3.5", true, NotAnInteger},
+			{"1e2", false, NotAnInteger},
+			{"-2E-1", true, NotAnInteger},
+			{"99999999999999999999", false, OutOfRange},
+			{"99999999999999999999", true, OutOfRange},
+			{"9223372036854775808", false, OutOfRange},
+			{"-9223372036854775809", false, OutOfRange},
+			{"-1", true, OutOfRange},
+		} {
+			t.Run(fmt.Sprintf("%s: %s, unsigned=%t", mode.name, tc.input, tc.unsigned), func(t *testing.T) {
+				r := mode.newReader([]byte(`[true, ` + tc.input + `]`))
+				arr := r.Array()
+				require.True(t, arr.Next())
+				require.True(t, r.Bool())
+				require.True(t, arr.Next())
+				if tc.unsigned {
+					_ = r.UInt64()
+				} else {
+					_ = r.Int64()
+				}
+				require.Equal(t, NumberFormatError{Raw: []byte(tc.input), Offset: 7, Reason: tc.reason}, r.Error())
+			})
+		}
+
+		t.Run(mode.name+": not a number", func(t *testing.T) {
+			r := mode.newReader([]byte(`"1"`))
+			_ = r.Int64()
+			require.IsType(t, TypeError{}, r.Error())
+		})
+	}
+}
+
+func TestNumberFormatErrorFromNumberProps(t *testing.T) {
+	_, err := NumberProps{raw: []byte("1.5"), isFloat: true}.Int64()
+	require.Equal(t, NumberFormatError{Raw: []byte("1.5"), Reason: NotAnInteger}, err)
+
+	_, err = NumberProps{raw: []byte("-1"), isNegative: true, mantissa: 1}.UInt64()
+	require.Equal(t, NumberFormatError{Raw: []byte("-1"), Reason: OutOfRange}, err)
+
+	_, err = NumberProps{raw: []byte("x"), trunc: true}.Int64()
+	require.Equal(t, NumberFormatError{Raw: []byte("x"), Reason: InvalidSyntax}, err)
+
+	require.Equal(t, `number cannot be read as an integer (out of range) at position 3 ("-1")`,
+		NumberFormatError{Raw: []byte("-1"), Offset: 3, Reason: OutOfRange}.Error())
+}
+
+func TestScaledInt(t *testing.T) {
+	for _, mode := range numberReaderModes {
+		for _, tc := range []struct {
+			input    string
+			decimals int
+			expected int64
+		}{
+			{"12.34", 2, 1234},
+			{"12.3", 2, 1230},
+			{"12", 2, 1200},
+			{"-12.34", 2, -1234},
+			{"12.340", 2, 1234},
+			{"0.05", 2, 5},
+			{"-0.0", 2, 0},
+			{"1.5e1", 2, 1500},
+			{"1234e-2", 2, 1234},
+			{"1200", -2, 12},
+			{"92233720368547758.07", 2, math.MaxInt64},
+			{"-92233720368547758.08", 2, math.MinInt64},
+		} {
+			t.Run(fmt.Sprintf("%s: %s, decimals=%d", mode.name, tc.input, tc.decimals), func(t *testing.T) {
+				r := mode.newReader([]byte(tc.input))
+				n, ok := r.ScaledInt(tc.decimals)
+				require.NoError(t, r.Error())
+				require.True(t, ok)
+				require.Equal(t, tc.expected, n)
+			})
+		}
+
+		for _, tc := range []struct {
+			input    string
+			decimals int
+			reason   NumberFormatReason
+		}{
+			{"12.345", 2, NotAnInteger},
+			{"12.34", 0, NotAnInteger},
+			{"1250", -2, NotAnInteger},
+			{"92233720368547758.08", 2, OutOfRange},
+			{"-92233720368547758.09", 2, OutOfRange},
+			{"1e30", 2, OutOfRange},
+		} {
+			t.Run(fmt.Sprintf("%s: %s, decimals=%d", mode.name, tc.input, tc.decimals), func(t *testing.T) {
+				r := mode.newReader([]byte(`[` + tc.input + `]`))
+				arr := r.Array()
+				require.True(t, arr.Next())
+				n, ok := r.ScaledInt(tc.decimals)
+				require.False(t, ok)
+				require.Equal(t, int64(0), n)
+				require.Equal(t, NumberFormatError{Raw: []byte(tc.input), Offset: 1, Reason: tc.reason}, r.Error())
+			})
+		}
+
+		t.Run(mode.name+": not a number", func(t *testing.T) {
+			r := mode.newReader([]byte(`"12.34"`))
+			_, ok := r.ScaledInt(2)
+			require.False(t, ok)
+			require.IsType(t, TypeError{}, r.Error())
+		})
+	}
+}
+
+func TestNumberPropsHasFractionAndExponent(t *testing.T) {
+	inputs := commontest.NumberTestEncodings()
+	for _, c := range commontest.NumberCorpus() {
+		inputs = append(inputs, c.Text)
+	}
+	for _, mode := range numberReaderModes {
+		for _, input := range inputs {
+			t.Run(fmt.Sprintf("%s: %s", mode.name, input), func(t *testing.T) {
+				r := mode.newReader([]byte(`[` + input + `]`))
+				arr := r.Array()
+				require.True(t, arr.Next())
+				n := r.NumberProps()
+				require.NoError(t, r.Error())
+				require.Equal(t, strings.Contains(input, "."), n.HasFraction())
+				require.Equal(t, strings.ContainsAny(input, "eE"), n.HasExponent())
+			})
+		}
+	}
+}
+
+func TestNumberish(t *testing.T) {
+	inputs := []struct {
+		input    string
+		intVal   int64
+		floatVal float64
+		isInt    bool
+	}{
+		{"3", 3, 3, true},
+		{"-3", -3, -3, true},
+		{"3.5", 0, 3.5, false},
+		{"3.0", 3, 3, true},
+		{"1e3", 1000, 1000, true},
+		{"1e300", 0, 1e300, false},
+		{"18446744073709551616", 0, 18446744073709551616, false},
+	}
+	for _, mode := range numberReaderModes {
+		for _, test := range inputs {
+			t.Run(fmt.Sprintf("%s: %s", mode.name, test.input), func(t *testing.T) {
+				r := mode.newReader([]byte(test.input))
+				intVal, floatVal, isInt, ok := r.Numberish()
+				require.NoError(t, r.Error())
+				assert.True(t, ok)
+				assert.Equal(t, test.isInt, isInt)
+				assert.Equal(t, test.intVal, intVal)
+				assert.Equal(t, test.floatVal, floatVal)
+			})
+		}
+		t.Run(mode.name+": not a number", func(t *testing.T) {
+			r := mode.newReader([]byte(`"3"`))
+			intVal, floatVal, isInt, ok := r.Numberish()
+			assert.False(t, ok)
+			assert.False(t, isInt)
+			assert.Equal(t, int64(0), intVal)
+			assert.Equal(t, float64(0), floatVal)
+			require.ErrorAs(t, r.Error(), &TypeError{})
+		})
+	}
+}
+
+func TestReadNumberAsString(t *testing.T) {
+	for _, mode := range numberReaderModes {
+		t.Run(mode.name, func(t *testing.T) {
+			r := mode.newReader([]byte(`[1.50, -2e3, 123456789012345678901234567890, null]`))
+			var values []string
+			arr := r.Array()
+			for i := 0; arr.Next(); i++ {
+				if i < 2 {
+					values = append(values, r.ReadNumberAsString())
+					continue
+				}
+				s, nonNull := r.ReadNumberAsStringOrNull()
+				values = append(values, fmt.Sprintf("%s %t", s, nonNull))
+			}
+			require.NoError(t, r.Error())
+			assert.Equal(t, []string{"1.50", "-2e3", "123456789012345678901234567890 true", " false"}, values)
+		})
+	}
+
+	t.Run("not a number", func(t *testing.T) {
+		r := NewReader([]byte(`"1"`))
+		assert.Equal(t, "", r.ReadNumberAsString())
+		require.IsType(t, TypeError{}, r.Error())
+
+		r = NewReader([]byte(`"1"`))
+		s, nonNull := r.ReadNumberAsStringOrNull()
+		assert.Equal(t, "", s)
+		assert.False(t, nonNull)
+		require.IsType(t, TypeError{}, r.Error())
+	})
+}
+
+func TestNumberRawTextExcludesFollowingCharacters(t *testing.T) {
+	// In lazy mode, the raw text of a number comes from the pre-processed tree, so it depends on the
+	// tree recording the end of the number rather than of the whitespace or delimiter after it.
+	inputs := []struct {
+		input    string
+		expected []string
+	}{
+		{"[1 , 22\n,3.5e2 ,-4\t]", []string{"1", "22", "3.5e2", "-4"}},
+		{`{"a":12 , "b":-0.5e-3}`, []string{"12", "-0.5e-3"}},
+		{"[7]", []string{"7"}},
+		{"[0\r\n]", []string{"0"}},
+	}
+	modes := append([]numberReaderMode{}, numberReaderModes...)
+	modes = append(modes, numberReaderMode{"lazy deferred", func(data []byte) Reader {
+		buffer := make([]JsonTreeStruct, 0, 10)
+		charBuffer := make([]byte, 0, 10)
+		numbers := make([]NumberProps, 0, 10)
+		r := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer,
+			ComputedValuesBuffer: JsonComputedValues{NumberValues: &numbers}})
+		r.PreProcessWith(PreProcessOptions{ComputeNumbers: true, DeferNumbers: true, BuildIndex: true})
+		return r
+	}})
+	for _, mode := range modes {
+		for _, test := range inputs {
+			t.Run(fmt.Sprintf("%s: %q", mode.name, test.input), func(t *testing.T) {
+				r := mode.newReader([]byte(test.input))
+				var values []string
+				if test.input[0] == '{' {
+					for obj := r.Object(); obj.Next(); {
+						values = append(values, string(r.Number()))
+					}
+				} else {
+					for arr := r.Array(); arr.Next(); {
+						values = append(values, string(r.Number()))
+					}
+				}
+				require.NoError(t, r.Error())
+				assert.Equal(t, test.expected, values)
+			})
+		}
+	}
+}
+
+func TestReadNumberBitLen(t *testing.T) {
+	inputs := []struct {
+		input  string
+		bitLen int
+	}{
+		{"0", 8},
+		{"127", 8},
+		{"-128", 8},
+		{"128", 16},
+		{"-32768", 16},
+		{"32768", 32},
+		{"2147483647", 32},
+		{"-2147483649", 64},
+		{"9223372036854775807", 64},
+		{"18446744073709551616", 64},
+		{"1e3", 16},
+		{"0.5", 32},
+		{"1.25e-3", 64},
+		{"0.1", 64},
+		{"1e300", 64},
+	}
+	for _, mode := range numberReaderModes {
+		for _, test := range inputs {
+			t.Run(fmt.Sprintf("%s: %s", mode.name, test.input), func(t *testing.T) {
+				r := mode.newReader([]byte(test.input))
+				bitLen := r.ReadNumberBitLen()
+				require.NoError(t, r.Error())
+				assert.Equal(t, test.bitLen, bitLen)
+			})
+		}
+		t.Run(mode.name+": not a number", func(t *testing.T) {
+			r := mode.newReader([]byte(`true`))
+			assert.Equal(t, 0, r.ReadNumberBitLen())
+			require.Error(t, r.Error())
+		})
+	}
+}
+
+func TestPeekNumberKind(t *testing.T) {
+	for _, mode := range numberReaderModes {
+		t.Run(mode.name, func(t *testing.T) {
+			r := mode.newReader([]byte(`[3, -12, 3.5, 1e3, 2.0, -0E-1]`))
+			var kinds []NumberKind
+			var values []float64
+			for arr := r.Array(); arr.Next(); {
+				kind, err := r.PeekNumberKind()
+				require.NoError(t, err)
+				kinds = append(kinds, kind)
+				if kind == NumberInt {
+					values = append(values, float64(r.Int64()))
+				} else {
+					values = append(values, r.Float64())
+				}
+			}
+			require.NoError(t, r.Error())
+			assert.Equal(t, []NumberKind{NumberInt, NumberInt, NumberFloat, NumberFloat, NumberFloat, NumberFloat}, kinds)
+			assert.Equal(t, []float64{3, -12, 3.5, 1000, 2, 0}, values)
+		})
+
+		t.Run(mode.name+": not a number", func(t *testing.T) {
+			r := mode.newReader([]byte(`{"a": "3"}`))
+			obj := r.Object()
+			require.True(t, obj.Next())
+			_, err := r.PeekNumberKind()
+			var typeErr TypeError
+			require.ErrorAs(t, err, &typeErr)
+			assert.Equal(t, TypeError{Expected: NumberValue, Actual: StringValue, Offset: 6}, typeErr)
+			require.NoError(t, r.Error())
+			assert.Equal(t, "3", string(r.String()))
+			assert.False(t, obj.Next())
+			require.NoError(t, r.Error())
+
+			_, err = r.PeekNumberKind()
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+
+	t.Run("failed state", func(t *testing.T) {
+		r := NewReader([]byte(`3`))
+		r.AddError(errors.New("sample"))
+		_, err := r.PeekNumberKind()
+		assert.EqualError(t, err, "sample")
+	})
+
+	t.Run("String", func(t *testing.T) {
+		assert.Equal(t, "int", NumberInt.String())
+		assert.Equal(t, "float", NumberFloat.String())
+	})
+}
@@ -0,0 +1,84 @@
+package jreader
+
+import "reflect"
+
+// Opt holds a value that may be absent, null, or present, which is how an optional property of a
+// JSON object is often modeled: for instance, in a partial update, an absent property means "leave
+// this unchanged", while a null means "clear this". The zero value is an absent value.
+//
+//	type Update struct {
+//	    Name  jreader.Opt[string]
+//	    Count jreader.Opt[int64]
+//	}
+//
+//	var u Update
+//	for obj := r.Object(); obj.Next(); {
+//	    switch string(obj.Name()) {
+//	    case "name":
+//	        u.Name.ReadFromJSONReader(r)
+//	    case "count":
+//	        u.Count.ReadFromJSONReader(r)
+//	    }
+//	}
+//	if u.Name.Set && u.Name.Null { ... }
+//
+// Opt implements Readable, so a field of type Opt is also read in this way by DecodeWithDefaults;
+// a property that is absent from the JSON object leaves the field's default unchanged.
+type Opt[T any] struct {
+	// Set is true if a value has been read, whether or not it was null.
+	Set bool
+
+	// Null is true if the value that was read was a JSON null.
+	Null bool
+
+	// Value is the value that was read, or the zero value of T if it was absent or null.
+	Value T
+}
+
+// Get returns the value and true if it was present and not null, or the zero value of T and false
+// otherwise.
+func (o Opt[T]) Get() (T, bool) {
+	return o.Value, o.Set && !o.Null
+}
+
+// Read reads the next JSON value, which may be a null, into the Opt, calling read to read it if it
+// is not a null. This allows any type to be read, in any way:
+//
+//	var flags jreader.Opt[[]bool]
+//	flags.Read(r, (*jreader.Reader).ReadBoolArray)
+//
+// Set is true afterward, even if there was an error; in that case, the Reader is in a failed state.
+func (o *Opt[T]) Read(r *Reader, read func(r *Reader) T) {
+	o.Set = true
+	if kind, _, ok := r.peekKind(); ok && kind == NullValue && r.err == nil {
+		var zero T
+		o.Null, o.Value = true, zero
+		_ = r.Null()
+		return
+	}
+	o.Null = false
+	o.Value = read(r)
+}
+
+// ReadFromJSONReader reads the next JSON value, which may be a null, into the Opt. A bool, int64,
+// float64, or string is read with the Reader method of the same name (with escape sequences in a
+// string decoded); any other type is read in the same way as a struct field by
+// DecodeWithDefaults.
+func (o *Opt[T]) ReadFromJSONReader(r *Reader) {
+	o.Read(r, func(r *Reader) T {
+		var value T
+		switch p := any(&value).(type) {
+		case *bool:
+			*p = r.Bool()
+		case *int64:
+			*p = r.Int64()
+		case *float64:
+			*p = r.Float64()
+		case *string:
+			*p = string(r.decodedString(nil, r.String()))
+		default:
+			r.decodeReflect(reflect.ValueOf(&value).Elem())
+		}
+		return value
+	})
+}
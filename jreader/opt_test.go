@@ -0,0 +1,114 @@
+package jreader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpt(t *testing.T) {
+	type update struct {
+		Name  Opt[string]
+		Count Opt[int64]
+		Ratio Opt[float64]
+		On    Opt[bool]
+	}
+	readUpdate := func(r *Reader) update {
+		var u update
+		for obj := r.Object(); obj.Next(); {
+			switch string(obj.Name()) {
+			case "name":
+				u.Name.ReadFromJSONReader(r)
+			case "count":
+				u.Count.ReadFromJSONReader(r)
+			case "ratio":
+				u.Ratio.ReadFromJSONReader(r)
+			case "on":
+				u.On.ReadFromJSONReader(r)
+			}
+		}
+		return u
+	}
+
+	for _, mode := range numberReaderModes {
+		t.Run(mode.name, func(t *testing.T) {
+			r := mode.newReader([]byte(`{"name": "a\"b", "count": null, "ratio": 0.5, "on": false}`))
+			u := readUpdate(&r)
+			require.NoError(t, r.Error())
+			assert.Equal(t, Opt[string]{Set: true, Value: `a"b`}, u.Name)
+			assert.Equal(t, Opt[int64]{Set: true, Null: true}, u.Count)
+			assert.Equal(t, Opt[float64]{Set: true, Value: 0.5}, u.Ratio)
+			assert.Equal(t, Opt[bool]{Set: true, Value: false}, u.On)
+		})
+	}
+
+	t.Run("absent, null, and present", func(t *testing.T) {
+		for _, tc := range []struct {
+			input    string
+			expected Opt[int64]
+			ok       bool
+		}{
+			{`{}`, Opt[int64]{}, false},
+			{`{"count": null}`, Opt[int64]{Set: true, Null: true}, false},
+			{`{"count": 3}`, Opt[int64]{Set: true, Value: 3}, true},
+			{`{"count": 0}`, Opt[int64]{Set: true, Value: 0}, true},
+		} {
+			r := NewReader([]byte(tc.input))
+			u := readUpdate(&r)
+			require.NoError(t, r.Error(), tc.input)
+			assert.Equal(t, tc.expected, u.Count, tc.input)
+			value, ok := u.Count.Get()
+			assert.Equal(t, tc.ok, ok, tc.input)
+			assert.Equal(t, tc.expected.Value, value, tc.input)
+		}
+	})
+
+	t.Run("null replaces an earlier value", func(t *testing.T) {
+		r := NewReader([]byte(`{"count": 3, "count": null}`))
+		u := readUpdate(&r)
+		require.NoError(t, r.Error())
+		assert.Equal(t, Opt[int64]{Set: true, Null: true}, u.Count)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		r := NewReader([]byte(`{"count": "x"}`))
+		u := readUpdate(&r)
+		require.IsType(t, TypeError{}, r.Error())
+		assert.True(t, u.Count.Set)
+		assert.False(t, u.Count.Null)
+	})
+
+	t.Run("custom read function", func(t *testing.T) {
+		var flags Opt[[]bool]
+		r := NewReader([]byte(`[true, false]`))
+		flags.Read(&r, (*Reader).ReadBoolArray)
+		require.NoError(t, r.Error())
+		assert.Equal(t, Opt[[]bool]{Set: true, Value: []bool{true, false}}, flags)
+	})
+
+	t.Run("other types", func(t *testing.T) {
+		var tags Opt[map[string]int]
+		r := NewReader([]byte(`{"a": 1}`))
+		tags.ReadFromJSONReader(&r)
+		require.NoError(t, r.Error())
+		assert.Equal(t, Opt[map[string]int]{Set: true, Value: map[string]int{"a": 1}}, tags)
+	})
+
+	t.Run("DecodeWithDefaults", func(t *testing.T) {
+		type config struct {
+			Host Opt[string] `json:"host"`
+			Port Opt[int64]  `json:"port"`
+			TLS  Opt[bool]   `json:"tls"`
+		}
+		defaults := config{Port: Opt[int64]{Set: true, Value: 80}, TLS: Opt[bool]{Set: true, Value: true}}
+		r := NewReader([]byte(`{"host": "x", "tls": null}`))
+		var c config
+		require.NoError(t, r.DecodeWithDefaults(&c, defaults))
+		assert.Equal(t, config{
+			Host: Opt[string]{Set: true, Value: "x"},
+			Port: Opt[int64]{Set: true, Value: 80},
+			TLS:  Opt[bool]{Set: true, Null: true},
+		}, c)
+	})
+}
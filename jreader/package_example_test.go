@@ -16,7 +16,7 @@ func Example() {
 	// Output: a \"good\" string
 }
 
-func ExampleWithEscapes() {
+func Example_withEscapes() {
 	charsBuffer := make([]byte, 10)
 	stringsBuffer := make([][]byte, 10)
 
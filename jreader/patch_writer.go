@@ -0,0 +1,236 @@
+package jreader
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// PatchWriter re-emits a JSON object with some of its top-level properties changed, added, or
+// removed, copying everything else verbatim from the original input. This is much cheaper than
+// decoding and re-encoding a large object when only a few properties change, and it preserves the
+// formatting of the unchanged parts.
+//
+//	buffer := make([]JsonTreeStruct, 0, 100)
+//	charBuffer := make([]byte, 0, 100)
+//	r := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer})
+//	r.PreProcess()
+//	if err := r.Error(); err != nil {
+//		return err
+//	}
+//	p, err := NewPatchWriter(data, buffer)
+//	if err != nil {
+//		return err
+//	}
+//	_ = p.Set("version", []byte(`2`))
+//	p.Remove("obsolete")
+//	_, err = p.WriteTo(w)
+//
+// Changed properties keep their original position; added properties are written after the last
+// existing one, in the order they were first set. The separators and indentation used for added
+// properties are copied from the existing ones where possible.
+type PatchWriter struct {
+	data    []byte
+	members []patchMember
+	start   int
+	end     int
+	added   []patchAddition
+}
+
+type patchMember struct {
+	name       []byte // raw property name, without quotes
+	keyStart   int    // position of the opening quote of the name
+	valueStart int
+	valueEnd   int
+	removed    bool
+	newValue   []byte
+}
+
+type patchAddition struct {
+	name  string
+	value []byte
+}
+
+// NewPatchWriter creates a PatchWriter for the JSON object in data. The tree must be the structure
+// buffer of a Reader that has pre-processed the same data (see Reader.PreProcess), and its root
+// must be an object; otherwise the error is a UsageError.
+//
+// The PatchWriter refers to data without copying it, so data must not be modified while the
+// PatchWriter is in use. It does not retain the tree.
+func NewPatchWriter(data []byte, tree []JsonTreeStruct) (*PatchWriter, error) {
+	if len(tree) == 0 || tree[0].start >= len(data) || data[tree[0].start] != '{' {
+		return nil, UsageError{Message: errMsgPatchNotObject}
+	}
+	root := tree[0]
+	p := &PatchWriter{data: data, start: root.start, end: root.end}
+	pos := root.start + 1
+	for i := 1; i < root.subTreeSize; i += tree[i].subTreeSize {
+		keyStart := skipRawWhitespace(data, pos)
+		if keyStart < len(data) && data[keyStart] == ',' {
+			keyStart = skipRawWhitespace(data, keyStart+1)
+		}
+		p.members = append(p.members, patchMember{
+			name:       tree[i].key,
+			keyStart:   keyStart,
+			valueStart: tree[i].start,
+			valueEnd:   tree[i].end,
+		})
+		pos = tree[i].end
+	}
+	return p, nil
+}
+
+// Set replaces the value of the top-level property called name with rawValue, which must be the
+// text of a single JSON value; if there is no such property, it is added. If the property appears
+// more than once in the original object, every occurrence is replaced. The PatchWriter keeps its
+// own copy of rawValue.
+//
+// If rawValue is not a single well-formed JSON value, Set returns the parsing error and does nothing.
+func (p *PatchWriter) Set(name string, rawValue []byte) error {
+	r := NewReader(rawValue)
+	r.SkipValue()
+	end := r.tr.pos
+	if r.err == nil {
+		r.AddError(r.RequireEOF())
+	}
+	if r.err != nil {
+		return r.err
+	}
+	value := append([]byte(nil), rawValue[skipRawWhitespace(rawValue, 0):end]...)
+	found := false
+	for i := range p.members {
+		if m := &p.members[i]; propertyNameEquals(m.name, name) {
+			m.removed = false
+			m.newValue = value
+			found = true
+		}
+	}
+	if found {
+		return nil
+	}
+	for i := range p.added {
+		if p.added[i].name == name {
+			p.added[i].value = value
+			return nil
+		}
+	}
+	p.added = append(p.added, patchAddition{name: name, value: value})
+	return nil
+}
+
+// Remove removes the top-level property called name, including every occurrence of it if it
+// appears more than once. It does nothing if there is no such property.
+func (p *PatchWriter) Remove(name string) {
+	for i := range p.members {
+		if m := &p.members[i]; propertyNameEquals(m.name, name) {
+			m.removed = true
+			m.newValue = nil
+		}
+	}
+	for i := range p.added {
+		if p.added[i].name == name {
+			p.added = append(p.added[:i], p.added[i+1:]...)
+			return
+		}
+	}
+}
+
+// WriteTo writes the patched object to w. Any whitespace or other data in the original input
+// before or after the object is not written. If every property has been removed and none added,
+// it writes an empty object, {}. It implements io.WriterTo.
+func (p *PatchWriter) WriteTo(w io.Writer) (int64, error) {
+	pw := patchOutput{w: w}
+	if len(p.members) == 0 {
+		// Keep whatever whitespace was inside the empty object, unless we are adding properties.
+		if len(p.added) == 0 {
+			pw.write(p.data[p.start:p.end])
+			return pw.n, pw.err
+		}
+		pw.write([]byte{'{'})
+	} else if len(p.added) == 0 && p.allRemoved() {
+		pw.write([]byte("{}"))
+		return pw.n, pw.err
+	} else {
+		pw.write(p.data[p.start:p.members[0].keyStart])
+	}
+	written := 0
+	for i, m := range p.members {
+		if m.removed {
+			continue
+		}
+		if written > 0 {
+			pw.write(p.memberSeparator(i))
+		}
+		if m.newValue == nil {
+			pw.write(p.data[m.keyStart:m.valueEnd])
+		} else {
+			pw.write(p.data[m.keyStart:m.valueStart])
+			pw.write(m.newValue)
+		}
+		written++
+	}
+	for _, a := range p.added {
+		if written > 0 {
+			pw.write(p.memberSeparator(len(p.members) - 1))
+		}
+		name, _ := json.Marshal(a.name)
+		pw.write(name)
+		pw.write(p.colonSeparator())
+		pw.write(a.value)
+		written++
+	}
+	if len(p.members) == 0 {
+		pw.write([]byte{'}'})
+	} else {
+		pw.write(p.data[p.members[len(p.members)-1].valueEnd:p.end])
+	}
+	return pw.n, pw.err
+}
+
+// allRemoved returns true if every original member has been removed.
+func (p *PatchWriter) allRemoved() bool {
+	for _, m := range p.members {
+		if !m.removed {
+			return false
+		}
+	}
+	return true
+}
+
+// memberSeparator returns the text that came before the original member at index i, starting
+// after the previous member's value: a comma and any whitespace. For the first member, it borrows
+// the separator of the second. If there are fewer than two members, it is just a comma.
+func (p *PatchWriter) memberSeparator(i int) []byte {
+	if len(p.members) < 2 {
+		return []byte{','}
+	}
+	if i == 0 {
+		i = 1
+	}
+	return p.data[p.members[i-1].valueEnd:p.members[i].keyStart]
+}
+
+// colonSeparator returns the text between the name and value of the last original member: a colon
+// and any whitespace.
+func (p *PatchWriter) colonSeparator() []byte {
+	if len(p.members) == 0 {
+		return []byte{':'}
+	}
+	m := p.members[len(p.members)-1]
+	return p.data[skipRawString(p.data, m.keyStart):m.valueStart]
+}
+
+// patchOutput accumulates the byte count and the first error from a series of writes.
+type patchOutput struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (o *patchOutput) write(b []byte) {
+	if o.err != nil {
+		return
+	}
+	n, err := o.w.Write(b)
+	o.n += int64(n)
+	o.err = err
+}
@@ -0,0 +1,150 @@
+package jreader
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPatchWriterForTest(t *testing.T, data string) *PatchWriter {
+	p, err := NewPatchWriter([]byte(data), treeForTest(t, []byte(data)))
+	require.NoError(t, err)
+	return p
+}
+
+func patchedString(t *testing.T, p *PatchWriter) string {
+	var buf bytes.Buffer
+	n, err := p.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	return buf.String()
+}
+
+func TestPatchWriter(t *testing.T) {
+	data := "{\n  \"a\": 1,\n  \"b\":   {\"x\" : [1,2]},\n  \"c\": \"s\"\n}"
+
+	t.Run("no changes", func(t *testing.T) {
+		p := newPatchWriterForTest(t, "  "+data+"  ")
+		assert.Equal(t, data, patchedString(t, p))
+	})
+
+	t.Run("replace value", func(t *testing.T) {
+		p := newPatchWriterForTest(t, data)
+		require.NoError(t, p.Set("b", []byte(` [true] `)))
+		assert.Equal(t, "{\n  \"a\": 1,\n  \"b\":   [true],\n  \"c\": \"s\"\n}", patchedString(t, p))
+	})
+
+	t.Run("add property", func(t *testing.T) {
+		p := newPatchWriterForTest(t, data)
+		require.NoError(t, p.Set("d", []byte(`null`)))
+		require.NoError(t, p.Set("e\"", []byte(`2`)))
+		require.NoError(t, p.Set("d", []byte(`3`)))
+		assert.Equal(t, "{\n  \"a\": 1,\n  \"b\":   {\"x\" : [1,2]},\n  \"c\": \"s\",\n  \"d\": 3,\n  \"e\\\"\": 2\n}",
+			patchedString(t, p))
+	})
+
+	t.Run("remove first property", func(t *testing.T) {
+		p := newPatchWriterForTest(t, data)
+		p.Remove("a")
+		assert.Equal(t, "{\n  \"b\":   {\"x\" : [1,2]},\n  \"c\": \"s\"\n}", patchedString(t, p))
+	})
+
+	t.Run("remove middle property", func(t *testing.T) {
+		p := newPatchWriterForTest(t, data)
+		p.Remove("b")
+		assert.Equal(t, "{\n  \"a\": 1,\n  \"c\": \"s\"\n}", patchedString(t, p))
+	})
+
+	t.Run("remove last property", func(t *testing.T) {
+		p := newPatchWriterForTest(t, data)
+		p.Remove("c")
+		assert.Equal(t, "{\n  \"a\": 1,\n  \"b\":   {\"x\" : [1,2]}\n}", patchedString(t, p))
+	})
+
+	t.Run("remove all properties", func(t *testing.T) {
+		p := newPatchWriterForTest(t, data)
+		p.Remove("a")
+		p.Remove("b")
+		p.Remove("c")
+		assert.Equal(t, "{}", patchedString(t, p))
+		require.NoError(t, p.Set("d", []byte(`1`)))
+		assert.Equal(t, "{\n  \"d\": 1\n}", patchedString(t, p))
+	})
+
+	t.Run("remove then set", func(t *testing.T) {
+		p := newPatchWriterForTest(t, data)
+		p.Remove("a")
+		require.NoError(t, p.Set("a", []byte(`5`)))
+		require.NoError(t, p.Set("z", []byte(`6`)))
+		p.Remove("z")
+		assert.Equal(t, "{\n  \"a\": 5,\n  \"b\":   {\"x\" : [1,2]},\n  \"c\": \"s\"\n}", patchedString(t, p))
+	})
+
+	t.Run("names with escapes", func(t *testing.T) {
+		p := newPatchWriterForTest(t, `{"\u0061":1,"b":2}`)
+		require.NoError(t, p.Set("a", []byte(`3`)))
+		assert.Equal(t, `{"\u0061":3,"b":2}`, patchedString(t, p))
+	})
+
+	t.Run("duplicate names", func(t *testing.T) {
+		p := newPatchWriterForTest(t, `{"a":1,"b":2,"a":3}`)
+		require.NoError(t, p.Set("a", []byte(`4`)))
+		assert.Equal(t, `{"a":4,"b":2,"a":4}`, patchedString(t, p))
+		p.Remove("a")
+		assert.Equal(t, `{"b":2}`, patchedString(t, p))
+		p.Remove("b")
+		assert.Equal(t, `{}`, patchedString(t, p))
+	})
+
+	t.Run("empty object", func(t *testing.T) {
+		p := newPatchWriterForTest(t, `{ }`)
+		assert.Equal(t, `{ }`, patchedString(t, p))
+		require.NoError(t, p.Set("a", []byte(`1`)))
+		require.NoError(t, p.Set("b", []byte(`2`)))
+		assert.Equal(t, `{"a":1,"b":2}`, patchedString(t, p))
+	})
+
+	t.Run("single property", func(t *testing.T) {
+		p := newPatchWriterForTest(t, `{ "a" : 1 }`)
+		require.NoError(t, p.Set("b", []byte(`2`)))
+		assert.Equal(t, `{ "a" : 1,"b" : 2 }`, patchedString(t, p))
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		p := newPatchWriterForTest(t, data)
+		require.Error(t, p.Set("a", []byte(`[1,`)))
+		require.IsType(t, SyntaxError{}, p.Set("a", []byte(`1 2`)))
+		assert.Equal(t, data, patchedString(t, p))
+	})
+
+	t.Run("value is copied", func(t *testing.T) {
+		p := newPatchWriterForTest(t, `{"a":1}`)
+		value := []byte(`"x"`)
+		require.NoError(t, p.Set("a", value))
+		value[1] = 'y'
+		assert.Equal(t, `{"a":"x"}`, patchedString(t, p))
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		data := []byte(`[1]`)
+		_, err := NewPatchWriter(data, treeForTest(t, data))
+		require.IsType(t, UsageError{}, err)
+		_, err = NewPatchWriter(data, nil)
+		require.IsType(t, UsageError{}, err)
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		p := newPatchWriterForTest(t, data)
+		_, err := p.WriteTo(failingWriter{})
+		require.Equal(t, errFailingWriter, err)
+	})
+}
+
+var errFailingWriter = errors.New("write failed") //nolint:gochecknoglobals
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errFailingWriter }
@@ -0,0 +1,180 @@
+package jreader
+
+import "bytes"
+
+// This file contains helpers for scanning raw JSON input without tokenizing it. They are used by
+// methods that need to look ahead in the input without consuming anything, and assume that the
+// input is well-formed; they only detect errors that would make it impossible to find the end of a
+// value, such as an unterminated string.
+
+// skipRawWhitespace returns the position of the first non-whitespace byte at or after pos.
+func skipRawWhitespace(data []byte, pos int) int {
+	for pos < len(data) {
+		switch data[pos] {
+		case ' ', '\t', '\n', '\r':
+			pos++
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// skipRawString returns the position just after the closing quote of a string whose opening quote
+// is at pos, or -1 if the string is unterminated.
+func skipRawString(data []byte, pos int) int {
+	for i := pos + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// decodeName returns a property name, as it appears in the input or as ObjectState.Name returns it,
+// with any escape sequences decoded. A name that has none, or that has an invalid one, is returned
+// as is. Otherwise it is decoded into buf[:0], and the second return value is the buffer to pass
+// to the next call, so that its space can be reused.
+func decodeName(buf, raw []byte) (name, newBuf []byte) {
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return raw, buf
+	}
+	decoded, ok := unescapeString(buf[:0], raw)
+	if !ok {
+		return raw, buf
+	}
+	return decoded, decoded
+}
+
+// propertyNameEquals returns true if the raw property name, as it appears in the input, is equal
+// to name after any escape sequences in it are decoded.
+func propertyNameEquals(raw []byte, name string) bool {
+	var buf [64]byte
+	decoded, _ := decodeName(buf[:0], raw)
+	return string(decoded) == name
+}
+
+// countRawProperties counts the properties of the object whose opening brace is at pos, by counting
+// colons at nesting depth 1. It returns false if the end of the input is reached before the end of
+// the object.
+func countRawProperties(data []byte, pos int) (int, bool) {
+	count := 0
+	depth := 0
+	for i := pos; i < len(data); i++ {
+		switch data[i] {
+		case '"':
+			next := skipRawString(data, i)
+			if next < 0 {
+				return 0, false
+			}
+			i = next - 1
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return count, true
+			}
+		case ':':
+			if depth == 1 {
+				count++
+			}
+		}
+	}
+	return 0, false
+}
+
+// countRawItems counts the direct children of the array or object whose opening delimiter is at
+// pos, by counting commas at nesting depth 1. It returns false if the end of the input is reached
+// before the end of the array or object.
+func countRawItems(data []byte, pos int) (int, bool) {
+	first := skipRawWhitespace(data, pos+1)
+	if first < len(data) && (data[first] == ']' || data[first] == '}') {
+		return 0, true
+	}
+	count := 1
+	depth := 0
+	for i := pos; i < len(data); i++ {
+		switch data[i] {
+		case '"':
+			next := skipRawString(data, i)
+			if next < 0 {
+				return 0, false
+			}
+			i = next - 1
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return count, true
+			}
+		case ',':
+			if depth == 1 {
+				count++
+			}
+		}
+	}
+	return 0, false
+}
+
+// skipRawValue returns the position just after the end of the value that begins at pos. It returns
+// false if the end of the input is reached first; for a number or a literal such as true, which has
+// no closing delimiter, this means that the value might continue in more input.
+func skipRawValue(data []byte, pos int) (int, bool) {
+	switch data[pos] {
+	case '"':
+		next := skipRawString(data, pos)
+		return next, next >= 0
+	case '[', '{':
+		depth := 0
+		for i := pos; i < len(data); i++ {
+			switch data[i] {
+			case '"':
+				next := skipRawString(data, i)
+				if next < 0 {
+					return 0, false
+				}
+				i = next - 1
+			case '[', '{':
+				depth++
+			case ']', '}':
+				depth--
+				if depth == 0 {
+					return i + 1, true
+				}
+			}
+		}
+		return 0, false
+	}
+	for i := pos; i < len(data); i++ {
+		switch data[i] {
+		case ' ', '\t', '\n', '\r', ',', ':', '[', ']', '{', '}', '"':
+			return i, true
+		}
+	}
+	return len(data), false
+}
+
+// valueKindFromFirstByte guesses the kind of a JSON value from its first character. The second
+// return value is false if the character cannot start a JSON value.
+func valueKindFromFirstByte(b byte) (ValueKind, bool) {
+	switch {
+	case b == '{':
+		return ObjectValue, true
+	case b == '[':
+		return ArrayValue, true
+	case b == '"':
+		return StringValue, true
+	case b == 't' || b == 'f':
+		return BoolValue, true
+	case b == 'n':
+		return NullValue, true
+	case b == '-' || (b >= '0' && b <= '9'):
+		return NumberValue, true
+	}
+	return NullValue, false
+}
@@ -1,7 +1,11 @@
 package jreader
 
 import (
+	"bytes"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"math"
 	"strconv"
 )
 
@@ -27,16 +31,55 @@ import (
 type Reader struct {
 	tr                tokenReader
 	awaitingReadValue bool // used by ArrayState & ObjectState
+	valueWasAwaited   bool // awaitingReadValue as it was when the current read began
 	err               error
+	detectMisuse      bool
+	containers        []ValueKind // only maintained if detectMisuse is set
+	internedKeys      map[string]string
+	composedName      []byte // the current property name in ReadComposed
+	normalizeKey      func([]byte) []byte
+	foundInArray      ArrayState // the array in which FindInArray last found a match
+	decoder           *Decoder   // set while a Decoder is decoding with this Reader
+	decodePath        []byte     // JSON Pointer of the value a Decoder is decoding; see pushDecodePath
+	defaultToNull     bool
+	lazyContainers    []int       // tree indices of the open containers; see SetDefaultToNull
+	inputCheck        inputSample // recorded by PreProcessWith, verified by lazy reads
+	deferred          deferredValues
+}
+
+// deferredValues records which kinds of values the last PreProcessWith call left to be computed
+// when they are read.
+type deferredValues struct {
+	strings bool
+	numbers bool
 }
 
 // Reset drops all states and reset all buffers to nils
 func (r *Reader) Reset(data []byte) {
 	r.err = nil
 	r.awaitingReadValue = false
+	r.containers = r.containers[:0]
+	r.lazyContainers = r.lazyContainers[:0]
+	r.foundInArray = ArrayState{}
+	r.inputCheck = inputSample{}
+	r.deferred = deferredValues{}
 	r.tr.Reset(data)
 }
 
+// Clone returns a copy of the Reader in its current state, which can read ahead independently of
+// the original: reading from the copy does not change the position or the error state of the
+// original, and vice versa. This can be used to try reading a value in one way, and to fall back
+// to reading it another way if that fails; see ReadFirstOf.
+//
+// The copy shares the input data and the buffers that were passed to NewReaderWithBuffers with
+// the original, so the two must not be used concurrently.
+func (r *Reader) Clone() Reader {
+	c := *r
+	c.containers = append([]ValueKind(nil), r.containers...)
+	c.lazyContainers = append([]int(nil), r.lazyContainers...)
+	return c
+}
+
 // Error returns the first error that the Reader encountered, if the Reader is in a failed state,
 // or nil if it is still in a good state.
 func (r *Reader) Error() error {
@@ -69,12 +112,86 @@ func (r *Reader) ReplaceError(err error) {
 	}
 }
 
+// ClearErrorAndResync takes the Reader out of a failed state that was caused by a value of the wrong
+// type or a number that could not be converted, and positions it just after that value, so that
+// reading can continue with whatever follows it. This allows a tolerant reader to note that an
+// element of an array was bad and go on to the next one:
+//
+//	for arr := r.Array(); arr.Next(); {
+//	    n := r.Int64()
+//	    if err := r.Error(); err != nil {
+//	        log.Printf("skipping bad element: %s", err)
+//	        if r.ClearErrorAndResync() {
+//	            continue
+//	        }
+//	        break
+//	    }
+//	    ...
+//	}
+//
+// It returns true if the error was cleared, which is only the case if it was a TypeError or a
+// NumberFormatError. For any other error, including a SyntaxError, after which the position in the
+// input is not reliable, or if the Reader is not in a failed state, it returns false and changes
+// nothing.
+//
+// Afterward, the Reader is in the same state as if the value had been read successfully: the
+// ArrayState or ObjectState that the value belongs to continues with the next element or property.
+// This includes the case of an array or object value that was of the wrong type, or whose reading
+// had started, for which the rest of it is skipped. The states of any containers that enclose that
+// one are also still valid, and calling their Next methods until they return false skips the rest
+// of them, if the caller wants to abandon them. In streaming mode, the rest of the value is skipped
+// by matching brackets and quotes, without checking that it is well-formed.
+func (r *Reader) ClearErrorAndResync() bool {
+	var offset int
+	switch e := r.err.(type) {
+	case TypeError:
+		offset = e.Offset
+	case NumberFormatError:
+		offset = e.Offset
+	default:
+		return false
+	}
+	if r.tr.options.lazyRead {
+		tree := *r.tr.structBuffer.values
+		i := r.tr.structBuffer.pos
+		if i >= len(tree) {
+			i = len(tree) - 1
+		}
+		for i >= 0 && tree[i].start != offset {
+			i--
+		}
+		if i < 0 {
+			return false
+		}
+		r.tr.structBuffer.pos = i + tree[i].subTreeSize
+	} else {
+		if offset < 0 || offset >= len(r.tr.data) || offset > r.tr.getPos() {
+			return false
+		}
+		if _, ok := valueKindFromFirstByte(r.tr.data[offset]); !ok {
+			return false
+		}
+		end, complete := skipRawValue(r.tr.data, offset)
+		if end < 0 || (!complete && end != len(r.tr.data)) {
+			return false
+		}
+		r.tr.pos = end
+		r.tr.hasUnread = false
+	}
+	r.err = nil
+	r.awaitingReadValue = false
+	return true
+}
+
 // Null attempts to read a null value, returning an error if the next token is not a null.
 func (r *Reader) Null() error {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return r.err
 	}
+	if r.missingValue() {
+		return nil
+	}
 	isNull, err := r.tr.Null()
 	if isNull || err != nil {
 		return err
@@ -82,15 +199,44 @@ func (r *Reader) Null() error {
 	return r.typeErrorForCurrentToken(NullValue, false)
 }
 
+// ReadNullOrError checks whether the next value is a null. If it is, the null is consumed and the
+// return values are (true, nil); if it is any other kind of value, nothing is consumed and the
+// return values are (false, nil), so the value can then be read with whichever method is
+// appropriate for it:
+//
+//	if isNull, err := r.ReadNullOrError(); err == nil && !isNull {
+//	    item = readItem(r)
+//	}
+//
+// This differs from Null, which returns an error if the value is not a null. If there is a parsing
+// error, or there is no next value, the return values are (false, err) and the Reader enters a
+// failed state.
+func (r *Reader) ReadNullOrError() (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	if kind, _, ok := r.peekKind(); ok && kind != NullValue {
+		return false, nil
+	}
+	if err := r.Null(); err != nil {
+		r.AddError(err)
+		return false, r.err
+	}
+	return true, nil
+}
+
 // Bool attempts to read a boolean value.
 //
 // If there is a parsing error, or the next value is not a boolean, the return value is false
 // and the Reader enters a failed state, which you can detect with Error().
 func (r *Reader) Bool() bool {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return false
 	}
+	if r.missingValue() {
+		return false
+	}
 	val, err := r.tr.Bool()
 	if err != nil {
 		r.err = err
@@ -105,10 +251,13 @@ func (r *Reader) Bool() bool {
 // If there is a parsing error, or the next value is neither a boolean nor a null, the return values
 // are (false, false) and the Reader enters a failed state, which you can detect with Error().
 func (r *Reader) BoolOrNull() (value bool, nonNull bool) {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return false, false
 	}
+	if r.missingValue() {
+		return false, false
+	}
 	isNull, err := r.tr.Null()
 	if isNull || err != nil {
 		r.err = err
@@ -122,11 +271,34 @@ func (r *Reader) BoolOrNull() (value bool, nonNull bool) {
 	return val, true
 }
 
+// ReadOptionalBool is a helper for boolean properties that are omitted when false. During an
+// iteration of obj, if the current property name is equal to name, it reads the property value as
+// a boolean and returns it; otherwise it returns false and does not consume anything, so the
+// caller can go on to check for other property names.
+//
+//	for obj := r.Object(); obj.Next(); {
+//	    if r.ReadOptionalBool("active", &obj) {
+//	        result.active = true
+//	    }
+//	}
+//
+// If there is a parsing error, or the property value is not a boolean, the return value is false
+// and the Reader enters a failed state, which you can detect with Error().
+func (r *Reader) ReadOptionalBool(name string, obj *ObjectState) bool {
+	if obj == nil || string(obj.Name()) != name {
+		return false
+	}
+	return r.Bool()
+}
+
 func (r *Reader) NumberProps() *NumberProps {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return nil
 	}
+	if r.missingValue() {
+		return nil
+	}
 	val, err := r.tr.Number()
 	if err != nil {
 		r.err = err
@@ -136,10 +308,13 @@ func (r *Reader) NumberProps() *NumberProps {
 }
 
 func (r *Reader) NumberPropsOrNull() (*NumberProps, bool) {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return nil, false
 	}
+	if r.missingValue() {
+		return nil, false
+	}
 	isNull, err := r.tr.Null()
 	if isNull || err != nil {
 		r.err = err
@@ -154,10 +329,13 @@ func (r *Reader) NumberPropsOrNull() (*NumberProps, bool) {
 }
 
 func (r *Reader) Number() []byte {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return nil
 	}
+	if r.missingValue() {
+		return nil
+	}
 	val, err := r.tr.Number()
 	if err != nil {
 		r.err = err
@@ -167,10 +345,13 @@ func (r *Reader) Number() []byte {
 }
 
 func (r *Reader) NumberOrNull() ([]byte, bool) {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return nil, false
 	}
+	if r.missingValue() {
+		return nil, false
+	}
 	isNull, err := r.tr.Null()
 	if isNull || err != nil {
 		r.err = err
@@ -184,34 +365,55 @@ func (r *Reader) NumberOrNull() ([]byte, bool) {
 	return val.raw, true
 }
 
+// ReadNumberAsString reads a numeric value and returns its text exactly as it appears in the input,
+// such as "1.50" or "-2e3", without converting it to a Go numeric type. This is useful for passing
+// a number on to a system that stores numbers as text, without any loss of precision.
+//
+// If there is a parsing error, or the next value is not a number, the return value is an empty
+// string and the Reader enters a failed state, which you can detect with Error().
+func (r *Reader) ReadNumberAsString() string {
+	return string(r.Number())
+}
+
+// ReadNumberAsStringOrNull is the same as ReadNumberAsString, except that it also accepts a null. In
+// the case of a number, the return values are (text, true); for a null, they are ("", false).
+//
+// If there is a parsing error, or the next value is neither a number nor a null, the return values
+// are ("", false) and the Reader enters a failed state, which you can detect with Error().
+func (r *Reader) ReadNumberAsStringOrNull() (string, bool) {
+	raw, nonNull := r.NumberOrNull()
+	return string(raw), nonNull
+}
+
 func (r *Reader) UInt64() uint64 {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return 0
 	}
+	if r.missingValue() {
+		return 0
+	}
 	val, err := r.tr.Number()
 	if err != nil {
 		r.err = err
 		return 0
 	}
-	if r.IsNumbersRaw() {
-		result, _ := strconv.ParseUint(string(val.raw), 10, 64)
-		return result
-	} else {
-		result, err := val.UInt64()
-		if err != nil {
-			r.err = err
-			return 0
-		}
-		return result
+	result, err := r.numberToUInt64(val)
+	if err != nil {
+		r.err = err
+		return 0
 	}
+	return result
 }
 
 func (r *Reader) UInt64OrNull() (uint64, bool) {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return 0, false
 	}
+	if r.missingValue() {
+		return 0, false
+	}
 	isNull, err := r.tr.Null()
 	if isNull || err != nil {
 		r.err = err
@@ -222,17 +424,12 @@ func (r *Reader) UInt64OrNull() (uint64, bool) {
 		r.err = typeErrorForNullableValue(err)
 		return 0, false
 	}
-	if r.IsNumbersRaw() {
-		result, err := strconv.ParseUint(string(val.raw), 10, 64)
-		return result, err == nil
-	} else {
-		result, err := val.UInt64()
-		if err != nil {
-			r.err = err
-			return 0, false
-		}
-		return result, true
+	result, err := r.numberToUInt64(val)
+	if err != nil {
+		r.err = err
+		return 0, false
 	}
+	return result, true
 }
 
 // Int64 attempts to read a numeric value and returns it as an int.
@@ -241,26 +438,24 @@ func (r *Reader) UInt64OrNull() (uint64, bool) {
 // the Reader enters a failed state, which you can detect with Error(). Non-numeric types are never
 // converted to numbers.
 func (r *Reader) Int64() int64 {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return 0
 	}
+	if r.missingValue() {
+		return 0
+	}
 	val, err := r.tr.Number()
 	if err != nil {
 		r.err = err
 		return 0
 	}
-	if r.IsNumbersRaw() {
-		result, _ := strconv.ParseInt(string(val.raw), 10, 64)
-		return result
-	} else {
-		result, err := val.Int64()
-		if err != nil {
-			r.err = err
-			return 0
-		}
-		return result
+	result, err := r.numberToInt64(val)
+	if err != nil {
+		r.err = err
+		return 0
 	}
+	return result
 }
 
 // Int64OrNull attempts to read either an integer numeric value or a null. In the case of a number, the
@@ -269,10 +464,13 @@ func (r *Reader) Int64() int64 {
 // If there is a parsing error, or the next value is neither a number nor a null, the return values
 // are (0, false) and the Reader enters a failed state, which you can detect with Error().
 func (r *Reader) Int64OrNull() (int64, bool) {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return 0, false
 	}
+	if r.missingValue() {
+		return 0, false
+	}
 	isNull, err := r.tr.Null()
 	if isNull || err != nil {
 		r.err = err
@@ -283,17 +481,12 @@ func (r *Reader) Int64OrNull() (int64, bool) {
 		r.err = typeErrorForNullableValue(err)
 		return 0, false
 	}
-	if r.IsNumbersRaw() {
-		result, err := strconv.ParseInt(string(val.raw), 10, 64)
-		return result, err == nil
-	} else {
-		result, err := val.Int64()
-		if err != nil {
-			r.err = err
-			return 0, false
-		}
-		return result, true
+	result, err := r.numberToInt64(val)
+	if err != nil {
+		r.err = err
+		return 0, false
 	}
+	return result, true
 }
 
 // Float64 attempts to read a numeric value and returns it as a float64.
@@ -302,26 +495,24 @@ func (r *Reader) Int64OrNull() (int64, bool) {
 // the Reader enters a failed state, which you can detect with Error(). Non-numeric types are never
 // converted to numbers.
 func (r *Reader) Float64() float64 {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return 0
 	}
+	if r.missingValue() {
+		return 0
+	}
 	val, err := r.tr.Number()
 	if err != nil {
 		r.err = err
 		return 0
 	}
-	if r.IsNumbersRaw() {
-		result, _ := strconv.ParseFloat(string(val.raw), 64)
-		return result
-	} else {
-		result, err := val.Float64()
-		if err != nil {
-			r.err = err
-			return 0
-		}
-		return result
+	result, err := r.numberToFloat64(val)
+	if err != nil {
+		r.err = err
+		return 0
 	}
+	return result
 }
 
 // Float64OrNull attempts to read either a numeric value or a null. In the case of a number, the
@@ -330,10 +521,13 @@ func (r *Reader) Float64() float64 {
 // If there is a parsing error, or the next value is neither a number nor a null, the return values
 // are (0, false) and the Reader enters a failed state, which you can detect with Error().
 func (r *Reader) Float64OrNull() (float64, bool) {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return 0, false
 	}
+	if r.missingValue() {
+		return 0, false
+	}
 	isNull, err := r.tr.Null()
 	if isNull || err != nil {
 		r.err = err
@@ -344,17 +538,182 @@ func (r *Reader) Float64OrNull() (float64, bool) {
 		r.err = typeErrorForNullableValue(err)
 		return 0, false
 	}
-	if r.IsNumbersRaw() {
-		result, err := strconv.ParseFloat(string(val.raw), 64)
-		return result, err == nil
-	} else {
-		result, err := val.Float64()
-		if err != nil {
-			r.err = err
-			return 0, false
+	result, err := r.numberToFloat64(val)
+	if err != nil {
+		r.err = err
+		return 0, false
+	}
+	return result, true
+}
+
+// Float64OrNaN attempts to read either a numeric value or a null, returning math.NaN() for a null.
+// This is convenient for data such as metric series where a null represents a missing sample,
+// since NaN propagates through arithmetic and is ignored by many statistics functions. It does not
+// change how numbers themselves are parsed; a JSON number is never read as NaN.
+//
+// If there is a parsing error, or the next value is neither a number nor a null, the return value
+// is zero and the Reader enters a failed state, which you can detect with Error().
+func (r *Reader) Float64OrNaN() float64 {
+	val, nonNull := r.Float64OrNull()
+	if !nonNull && r.err == nil {
+		return math.NaN()
+	}
+	return val
+}
+
+// Numberish reads a numeric value that may be either an integer or a floating-point number, as in
+// a column of mixed numeric data. If the number is integral and fits in an int64, as 3 and 3.0 do,
+// the return values are (value, float64(value), true, true); otherwise they are (0, value, false,
+// true), as for 3.5 or for an integer too large for an int64. An integral number in floating-point
+// syntax, such as 3.0 or 1e3, is first parsed as a float64, so one with more than 15 or so
+// significant digits may not be converted exactly.
+//
+// If there is a parsing error, or the next value is not a number, the return values are
+// (0, 0, false, false) and the Reader enters a failed state, which you can detect with Error().
+func (r *Reader) Numberish() (intVal int64, floatVal float64, isInt bool, ok bool) {
+	r.beginValue()
+	if r.err != nil {
+		return 0, 0, false, false
+	}
+	if r.missingValue() {
+		return 0, 0, true, true
+	}
+	val, err := r.tr.Number()
+	if err != nil {
+		r.err = err
+		return 0, 0, false, false
+	}
+	intVal, floatVal, isInt, err = r.numberishValue(val)
+	if err != nil {
+		r.err = err
+		return 0, 0, false, false
+	}
+	return intVal, floatVal, isInt, true
+}
+
+// numberishValue converts a number that was read by the Reader as Numberish describes.
+func (r *Reader) numberishValue(val *NumberProps) (int64, float64, bool, error) {
+	if n, err := r.numberToInt64(val); err == nil {
+		return n, float64(n), true, nil
+	}
+	f, err := r.numberToFloat64(val)
+	if err != nil {
+		return 0, 0, false, r.withNumberOffset(err)
+	}
+	if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+		return int64(f), f, true, nil
+	}
+	return 0, f, false, nil
+}
+
+// PeekNumberKind returns whether the next value is a number written as an integer or one with a
+// fractional part or exponent, without consuming it, so that the caller can choose between Int64
+// and Float64:
+//
+//	if kind, err := r.PeekNumberKind(); err == nil && kind == jreader.NumberInt {
+//	    n := r.Int64()
+//	    ...
+//
+// This only looks at how the number is written, so 2.0 and 1e3 are NumberFloat even though their
+// values are integers; to classify numbers by value, use Numberish. The number is not validated
+// until it is read.
+//
+// If the next value is not a number, the error is a TypeError; if there is no next value, it is
+// io.EOF. Unlike the Reader's other methods, this does not put the Reader into a failed state, and
+// if the Reader is already in a failed state, it returns the Reader's error.
+func (r *Reader) PeekNumberKind() (NumberKind, error) {
+	if r.err != nil {
+		return NumberInt, r.err
+	}
+	kind, pos, ok := r.peekKind()
+	if !ok {
+		if (r.tr.options.lazyRead && !r.tr.structBuffer.HasNext()) || pos >= len(r.tr.data) {
+			return NumberInt, io.EOF
 		}
-		return result, true
+		return NumberInt, SyntaxError{Message: errMsgUnexpectedChar, Offset: pos, Value: string(r.tr.data[pos])}
+	}
+	if kind != NumberValue {
+		return NumberInt, TypeError{Expected: NumberValue, Actual: kind, Offset: pos}
+	}
+	for i := pos; i < len(r.tr.data); i++ {
+		switch r.tr.data[i] {
+		case '.', 'e', 'E':
+			return NumberFloat, nil
+		case '-', '+', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		default:
+			return NumberInt, nil
+		}
+	}
+	return NumberInt, nil
+}
+
+// ReadNumberBitLen reads a numeric value and returns the minimum number of bits needed to hold it,
+// which is useful for suggesting the smallest Go type for values observed in a JSON field.
+//
+// A number with an integer value, as Numberish describes, returns 8, 16, 32, or 64 according to
+// whether it fits in an int8, int16, int32, or int64; an integer too large for an int64 returns 64.
+// Any other number returns 32 if converting it to a float32 loses nothing, or 64 otherwise.
+//
+// If there is a parsing error, or the next value is not a number, the return value is 0 and the
+// Reader enters a failed state, which you can detect with Error().
+func (r *Reader) ReadNumberBitLen() int {
+	intVal, floatVal, isInt, ok := r.Numberish()
+	switch {
+	case !ok:
+		return 0
+	case isInt:
+		return intBitLen(intVal)
+	case floatVal == math.Trunc(floatVal) || float64(float32(floatVal)) != floatVal:
+		return 64
+	default:
+		return 32
+	}
+}
+
+func intBitLen(n int64) int {
+	switch {
+	case n >= math.MinInt8 && n <= math.MaxInt8:
+		return 8
+	case n >= math.MinInt16 && n <= math.MaxInt16:
+		return 16
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		return 32
+	default:
+		return 64
+	}
+}
+
+// ScaledInt reads a numeric value as a fixed-point decimal with the specified number of fractional
+// digits, returning it multiplied by 10 to the power of decimals: for instance, with decimals = 2,
+// 12.34 is returned as 1234 and 12.3 as 1230. This is useful for amounts of money, since the
+// conversion is exact and never goes through a float64.
+//
+// A number that has more significant fractional digits than decimals, such as 12.345 with
+// decimals = 2, causes a NumberFormatError with the reason NotAnInteger; trailing zeros, as in
+// 12.340, are allowed. A number whose scaled value does not fit in an int64 causes a
+// NumberFormatError with the reason OutOfRange. In either case, or if there is a parsing error or
+// the next value is not a number, the return values are (0, false) and the Reader enters a failed
+// state, which you can detect with Error(). They are also (0, false), without an error, for a
+// missing value (see SetDefaultToNull). Otherwise, they are (value, true).
+func (r *Reader) ScaledInt(decimals int) (int64, bool) {
+	r.beginValue()
+	if r.err != nil {
+		return 0, false
+	}
+	if r.missingValue() {
+		return 0, false
+	}
+	val, err := r.tr.Number()
+	if err != nil {
+		r.err = err
+		return 0, false
+	}
+	result, reason := scaleDecimal(val.raw, decimals)
+	if reason != 0 {
+		r.err = r.withNumberOffset(NumberFormatError{Raw: val.raw, Reason: reason})
+		return 0, false
 	}
+	return result, true
 }
 
 // String attempts to read a string value.
@@ -363,10 +722,13 @@ func (r *Reader) Float64OrNull() (float64, bool) {
 // the Reader enters a failed state, which you can detect with Error(). Types other than string
 // are never converted to strings.
 func (r *Reader) String() []byte {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return []byte("")
 	}
+	if r.missingValue() {
+		return []byte("")
+	}
 	val, err := r.tr.String()
 	if err != nil {
 		r.err = err
@@ -375,16 +737,75 @@ func (r *Reader) String() []byte {
 	return val
 }
 
+// ReadStringWithMaxLen is the same as String, except that a string whose decoded content is longer
+// than maxLen bytes causes a ValueError, so that a value which is expected to be short, such as a
+// name in untrusted input, cannot make the caller hold on to an arbitrarily large string. The limit
+// only applies to this call.
+//
+// In lazy mode, the error's Property is the name of the object property whose value this was, if
+// any. Got is not set, since the string may be very long.
+func (r *Reader) ReadStringWithMaxLen(maxLen int) []byte {
+	s := r.String()
+	if r.err != nil || len(s) <= maxLen || len(r.decodedString(nil, s)) <= maxLen {
+		return s
+	}
+	err := ValueError{
+		Message: errMsgStringTooLong + " of " + strconv.Itoa(maxLen) + " bytes",
+		Offset:  r.tr.LastPos(),
+	}
+	if r.tr.options.lazyRead && r.tr.structBuffer.pos > 0 {
+		err.Property = r.mapKey((*r.tr.structBuffer.values)[r.tr.structBuffer.pos-1].key)
+	}
+	r.err = err
+	return []byte("")
+}
+
+// StringUsing is the same as String, except that the decoded string is appended to buf[:0], which
+// grows if necessary, and the result is returned. Unlike the value returned by String, the result
+// does not refer to the input or to the Reader's internal buffers, so it remains valid after further
+// reads; and if the caller keeps reusing the same buffer, reading strings does not allocate once the
+// buffer is large enough:
+//
+//	var buf []byte
+//	for arr := r.Array(); arr.Next(); {
+//	    var ok bool
+//	    if buf, ok = r.StringUsing(buf); ok {
+//	        process(buf)
+//	    }
+//	}
+//
+// Unlike String, StringUsing always decodes escape sequences. If the Reader computes strings (see
+// BufferConfig.ComputedValuesBuffer), the value from String is already decoded and is copied as it
+// is; otherwise String returns the raw string content, which is decoded into buf. If there is a
+// parsing error, or the next value is not a string, the return values are (buf[:0], false) and the
+// Reader enters a failed state, which you can detect with Error().
+func (r *Reader) StringUsing(buf []byte) ([]byte, bool) {
+	s := r.String()
+	if r.err != nil {
+		return buf[:0], false
+	}
+	if r.tr.options.computeString {
+		return append(buf[:0], s...), true
+	}
+	if decoded, ok := unescapeString(buf[:0], s); ok {
+		return decoded, true
+	}
+	return append(buf[:0], s...), true
+}
+
 // StringOrNull attempts to read either a string value or a null. In the case of a string, the
 // return values are (value, true); for a null, they are ("", false).
 //
 // If there is a parsing error, or the next value is neither a string nor a null, the return values
 // are ("", false) and the Reader enters a failed state, which you can detect with Error().
 func (r *Reader) StringOrNull() ([]byte, bool) {
-	r.awaitingReadValue = false
+	r.beginValue()
 	if r.err != nil {
 		return []byte(""), false
 	}
+	if r.missingValue() {
+		return []byte(""), false
+	}
 	isNull, err := r.tr.Null()
 	if isNull || err != nil {
 		r.err = err
@@ -398,6 +819,243 @@ func (r *Reader) StringOrNull() ([]byte, bool) {
 	return val, true
 }
 
+// ReadEnumString reads a string value that must be one of a fixed set of values, such as the names
+// of the constants of an enumerated type, and returns the index of the matching value:
+//
+//	const (
+//	    ColorRed Color = iota
+//	    ColorGreen
+//	    ColorBlue
+//	)
+//
+//	var colorNames = jreader.MakeEnumTable("red", "green", "blue")
+//
+//	func readColor(r *jreader.Reader) Color {
+//	    i, _ := r.ReadEnumString(colorNames)
+//	    return Color(i)
+//	}
+//
+// The string is compared against each value in turn without allocating a string, which is the
+// fastest approach for the small value sets that are typical of enums.
+//
+// If the string does not match any of the values, the return values are -1 and a ValueError, and
+// the Reader enters a failed state. If there is a parsing error, or the next value is not a string,
+// the return values are -1 and the error, and the Reader enters a failed state.
+func (r *Reader) ReadEnumString(values []string) (int, error) {
+	s := r.String()
+	if r.err != nil {
+		return -1, r.err
+	}
+	var buf [64]byte
+	s = r.decodedString(buf[:0], s)
+	for i, v := range values {
+		if string(s) == v {
+			return i, nil
+		}
+	}
+	r.err = ValueError{Message: errMsgNotEnumValue, Got: string(s), Offset: r.tr.LastPos()}
+	return -1, r.err
+}
+
+// MakeEnumTable returns the specified values as a slice for use with ReadEnumString. It exists to
+// make it clear at the declaration site that the order of the values is significant: the position
+// of each value is the number that ReadEnumString returns for it, which normally corresponds to an
+// iota constant.
+func MakeEnumTable(values ...string) []string {
+	return append([]string(nil), values...)
+}
+
+// ReadExactString reads a string value that must be equal to expected, such as a protocol version
+// or other magic value, returning nil if it is:
+//
+//	case "version":
+//	    r.ReadExactString([]byte("2.0"))
+//
+// The comparison is done on the string after any escape sequences in it are decoded.
+//
+// If the string has a different value, the return value is a ValueError whose Expected and Got
+// fields are the expected and actual strings, and the Reader enters a failed state. If there is a
+// parsing error, or the next value is not a string, the return value is the error and the Reader
+// enters a failed state.
+func (r *Reader) ReadExactString(expected []byte) error {
+	s := r.String()
+	if r.err != nil {
+		return r.err
+	}
+	var buf [64]byte
+	s = r.decodedString(buf[:0], s)
+	if !bytes.Equal(s, expected) {
+		r.err = ValueError{Message: errMsgNotExpectedString, Got: string(s), Expected: string(expected),
+			Offset: r.tr.LastPos()}
+		return r.err
+	}
+	return nil
+}
+
+// ReadExactInt64 reads an integer value that must be equal to expected, such as a protocol version
+// number, returning nil if it is. It is the numeric counterpart of ReadExactString.
+//
+// If the number has a different value, the return value is a ValueError whose Expected and Got
+// fields are the expected and actual numbers in decimal, and the Reader enters a failed state. If
+// there is a parsing error, or the next value is not an integer, the return value is the error and
+// the Reader enters a failed state.
+func (r *Reader) ReadExactInt64(expected int64) error {
+	n := r.Int64()
+	if r.err != nil {
+		return r.err
+	}
+	if n != expected {
+		r.err = ValueError{Message: errMsgNotExpectedNumber, Got: strconv.FormatInt(n, 10),
+			Expected: strconv.FormatInt(expected, 10), Offset: r.tr.LastPos()}
+		return r.err
+	}
+	return nil
+}
+
+// ReadHexString reads a string value that must consist only of hexadecimal digits (0-9, a-f, and
+// A-F), such as a hash or an identifier, and returns the string itself rather than decoding it to
+// binary. An empty string is allowed. As with String, the returned slice may refer directly to the
+// source data, so it should be copied if it is to be retained.
+//
+// If the string contains any other characters, the return value is nil, and the Reader enters a
+// failed state with a ValueError. If there is a parsing error, or the next value is not a string,
+// the return value is nil and the Reader enters a failed state.
+func (r *Reader) ReadHexString() []byte {
+	s := r.String()
+	if r.err != nil {
+		return nil
+	}
+	s = r.decodedString(nil, s)
+	for _, ch := range s {
+		if !isHexDigit(ch) {
+			r.err = ValueError{Message: errMsgNotHexString, Got: string(s), Offset: r.tr.LastPos()}
+			return nil
+		}
+	}
+	return s
+}
+
+func isHexDigit(ch byte) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+// HexBytes reads a string value that encodes binary data in hexadecimal, such as "deadbeef", and
+// appends the decoded bytes to dst, returning the extended slice; so a caller that reads many such
+// values can reuse one buffer by passing buf[:0]. Upper and lower case digits are both accepted. The
+// string is decoded directly from the input unless it contains escape sequences.
+//
+// If the string has an odd number of characters or contains anything other than hexadecimal
+// digits, the return value is dst, and the Reader enters a failed state with a ValueError. If there
+// is a parsing error, or the next value is not a string, the return value is dst and the Reader
+// enters a failed state.
+func (r *Reader) HexBytes(dst []byte) []byte {
+	s := r.String()
+	if r.err != nil {
+		return dst
+	}
+	return r.appendHexBytes(dst, s)
+}
+
+// HexBytesOrNull is the same as HexBytes, except that it also accepts a null. In the case of a
+// string, the return values are (extended slice, true); for a null, or if there is an error, they
+// are (dst, false).
+func (r *Reader) HexBytesOrNull(dst []byte) ([]byte, bool) {
+	s, nonNull := r.StringOrNull()
+	if !nonNull {
+		return dst, false
+	}
+	result := r.appendHexBytes(dst, s)
+	return result, r.err == nil
+}
+
+// CopyHexTo reads a string value that encodes binary data in hexadecimal, as HexBytes does, and
+// writes the decoded bytes to w in small chunks, so that a very large value is never decoded into
+// memory all at once. It returns the number of bytes written.
+//
+// The whole string is checked before anything is written, so if it is not valid hexadecimal, nothing
+// is written, and the Reader enters a failed state with a ValueError, which is also returned. An
+// error from w is returned as it is, without putting the Reader in a failed state, since the value
+// has been read.
+func (r *Reader) CopyHexTo(w io.Writer) (int64, error) {
+	s := r.String()
+	if r.err != nil {
+		return 0, r.err
+	}
+	s = r.decodedString(nil, s)
+	if err := checkHexString(s); err != nil {
+		r.err = hexValueError(s, err, r.tr.LastPos())
+		return 0, r.err
+	}
+	var chunk [512]byte
+	var written int64
+	for len(s) > 0 {
+		n := len(s)
+		if n > 2*len(chunk) {
+			n = 2 * len(chunk)
+		}
+		decoded, _ := hex.Decode(chunk[:], s[:n])
+		s = s[n:]
+		m, err := w.Write(chunk[:decoded])
+		written += int64(m)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// appendHexBytes decodes the hexadecimal string s, as returned by String, and appends the result to
+// dst, or puts the Reader in a failed state if s is not valid hexadecimal.
+func (r *Reader) appendHexBytes(dst, s []byte) []byte {
+	s = r.decodedString(nil, s)
+	n := len(dst)
+	size := hex.DecodedLen(len(s))
+	if cap(dst)-n < size {
+		grown := make([]byte, n, n+size)
+		copy(grown, dst)
+		dst = grown
+	}
+	if _, err := hex.Decode(dst[n:n+size], s); err != nil {
+		r.err = hexValueError(s, err, r.tr.LastPos())
+		return dst[:n]
+	}
+	return dst[:n+size]
+}
+
+// checkHexString returns the error that hex.Decode would return for s, without decoding it.
+func checkHexString(s []byte) error {
+	for i, ch := range s {
+		if !isHexDigit(ch) {
+			return hex.InvalidByteError(s[i])
+		}
+	}
+	if len(s)%2 != 0 {
+		return hex.ErrLength
+	}
+	return nil
+}
+
+func hexValueError(s []byte, err error, offset int) error {
+	message := errMsgNotHexString
+	if err == hex.ErrLength {
+		message = errMsgOddHexString
+	}
+	return ValueError{Message: message, Got: string(s), Offset: offset}
+}
+
+// decodedString returns the decoded form of a value that was returned by String. This is only
+// different from the value itself if the Reader is not configured to compute strings, in which
+// case String returns the raw string content; in that case, the decoded form is appended to buf.
+func (r *Reader) decodedString(buf []byte, s []byte) []byte {
+	if r.tr.options.computeString || bytes.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	if decoded, ok := unescapeString(buf, s); ok {
+		return decoded
+	}
+	return s
+}
+
 // Array attempts to begin reading a JSON array value. If successful, the return value will be an
 // ArrayState containing the necessary state for iterating through the array elements.
 //
@@ -430,8 +1088,8 @@ func (r *Reader) ArrayOrNull() ArrayState {
 }
 
 func (r *Reader) tryArray(allowNull bool) ArrayState {
-	r.awaitingReadValue = false
-	if r.err != nil {
+	r.beginValue()
+	if r.err != nil || r.missingValue() {
 		return ArrayState{}
 	}
 	if allowNull {
@@ -450,8 +1108,9 @@ func (r *Reader) tryArray(allowNull bool) ArrayState {
 		return ArrayState{}
 	}
 	if gotDelim {
+		r.enterContainer(ArrayValue)
 		if r.tr.options.lazyRead {
-			return ArrayState{r: r, arrayIndex: r.tr.structBuffer.Pos}
+			return ArrayState{r: r, arrayIndex: r.tr.structBuffer.pos}
 		} else {
 			return ArrayState{r: r}
 		}
@@ -492,8 +1151,8 @@ func (r *Reader) ObjectOrNull() ObjectState {
 }
 
 func (r *Reader) tryObject(allowNull bool) ObjectState {
-	r.awaitingReadValue = false
-	if r.err != nil {
+	r.beginValue()
+	if r.err != nil || r.missingValue() {
 		return ObjectState{}
 	}
 	if allowNull {
@@ -509,8 +1168,9 @@ func (r *Reader) tryObject(allowNull bool) ObjectState {
 		return ObjectState{}
 	}
 	if gotDelim {
+		r.enterContainer(ObjectValue)
 		if r.tr.options.lazyRead {
-			return ObjectState{r: r, objectIndex: r.tr.structBuffer.Pos}
+			return ObjectState{r: r, objectIndex: r.tr.structBuffer.pos}
 		} else {
 			return ObjectState{r: r}
 		}
@@ -527,17 +1187,18 @@ func (r *Reader) tryObject(allowNull bool) ObjectState {
 // or ObjectValue, the AnyValue's Array or Object field has been initialized with an ArrayState or
 // ObjectState just as if you had called the Reader's Array or Object method.
 //
-// If there is a parsing error, the return value is the same as for a null and the Reader enters
-// a failed state, which you can detect with Error().
+// The return value is never nil. If there is a parsing error, or if the Reader was already in a
+// failed state, the return value is the same as for a null (a zero AnyValue whose Kind is
+// NullValue) and the Reader enters or stays in a failed state, which you can detect with Error().
 func (r *Reader) Any() *AnyValue {
-	r.awaitingReadValue = false
-	if r.err != nil {
-		return nil
+	r.beginValue()
+	if r.err != nil || r.missingValue() {
+		return r.failedAnyValue()
 	}
 	v, err := r.tr.Any()
 	if err != nil {
 		r.err = err
-		return nil
+		return r.failedAnyValue()
 	}
 	switch v.Kind {
 	case BoolValue:
@@ -547,11 +1208,13 @@ func (r *Reader) Any() *AnyValue {
 	case StringValue:
 		return v
 	case ArrayValue:
-		v.Array.arrayIndex = r.tr.structBuffer.Pos
+		r.enterContainer(ArrayValue)
+		v.Array.arrayIndex = r.tr.structBuffer.pos
 		v.Array.r = r
 		return v
 	case ObjectValue:
-		v.Object.objectIndex = r.tr.structBuffer.Pos
+		r.enterContainer(ObjectValue)
+		v.Object.objectIndex = r.tr.structBuffer.pos
 		v.Object.r = r
 		return v
 	default:
@@ -559,35 +1222,442 @@ func (r *Reader) Any() *AnyValue {
 	}
 }
 
+// ReadAllAny reads the entire input, which must be a single JSON value, and returns it as a tree of
+// AnyValues: for an array, the elements are in the Elements field, and for an object, the
+// properties are in the Properties field, recursively. This is the simplest way to read a document
+// of unknown structure when streaming is not needed.
+//
+// The result does not share any memory with the input or with the Reader's buffers, so it remains
+// valid after the Reader is reset or reused. Strings and property names have their escape sequences
+// decoded. The Array and Object fields of each AnyValue are not initialized.
+//
+// If there is a parsing error, or if there is any data after the value (not counting whitespace),
+// the return value is nil and the Reader enters a failed state; the error is also returned.
+func (r *Reader) ReadAllAny() (*AnyValue, error) {
+	value := r.readAnyTree()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if !r.tr.options.lazyRead {
+		if err := r.RequireEOF(); err != nil {
+			r.err = err
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// ReadAll reads all of the remaining input, which may contain any number of JSON values separated
+// by whitespace, such as a stream of newline-delimited JSON, and returns each value as a tree of
+// AnyValues in the same form as ReadAllAny. If there is no more input (not counting whitespace),
+// the result is an empty slice.
+//
+// In lazy mode (see Reader.PreProcess), the pre-processed value is returned first, if it has not
+// already been read; the Reader then returns to streaming mode, as if SyncWithPreProcess had been
+// called, to read the values after it.
+//
+// If there is a parsing error, the return value is nil and the Reader enters a failed state; the
+// error is also returned.
+func (r *Reader) ReadAll() ([]*AnyValue, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	values := []*AnyValue{}
+	if r.tr.options.lazyRead {
+		if r.tr.structBuffer.HasNext() {
+			value := r.readAnyTree()
+			if r.err != nil {
+				return nil, r.err
+			}
+			values = append(values, value)
+		}
+		r.SyncWithPreProcess()
+	}
+	for !r.tr.EOF() {
+		value := r.readAnyTree()
+		if r.err != nil {
+			return nil, r.err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// ReadJSONLines treats the Reader's entire input as newline-delimited JSON, and calls fn for each
+// line that is not blank, with the line number (counting from 1) and a Reader for that line:
+//
+//	r := jreader.NewReader(data)
+//	err := r.ReadJSONLines(func(lineNum int, lr *jreader.Reader) error {
+//	    event, err := readEvent(lr)
+//	    ...
+//	})
+//
+// Lines are separated by '\n' bytes, and a line that is empty or contains only whitespace is
+// skipped. The line Reader uses the same buffers as the Reader that ReadJSONLines was called on,
+// which are reset for each line, so it must not be retained after fn returns; it has the same number
+// parsing options, and it is in streaming mode, but fn can call PreProcess on it if the Reader was
+// created with NewReaderWithBuffers. Offsets in its errors are relative to the start of the line.
+//
+// The input is read from the beginning regardless of what has already been read from the Reader.
+// Afterward, the Reader is at the end of its input, in streaming mode.
+//
+// If fn returns an error, or leaves the line Reader in a failed state, no more lines are read, and
+// the Reader enters a failed state with a LineError that wraps that error; the LineError is also
+// returned. fn does not have to read the whole line.
+func (r *Reader) ReadJSONLines(fn func(lineNum int, r *Reader) error) error {
+	if r.err != nil {
+		return r.err
+	}
+	rawNumbers := r.tr.options.readRawNumbers
+	lineReader := Reader{tr: newTokenReader(nil, r.tr.structBuffer.values, r.tr.charBuffer,
+		r.tr.computedValuesBuffer)}
+	for i, line := range bytes.Split(r.tr.data, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		lineReader.Reset(line)
+		lineReader.tr.options.readRawNumbers = rawNumbers
+		err := fn(i+1, &lineReader)
+		if err == nil {
+			err = lineReader.err
+		}
+		if err != nil {
+			r.err = LineError{Line: i + 1, Err: err}
+			return r.err
+		}
+	}
+	r.tr.Reset(r.tr.data)
+	r.tr.options.readRawNumbers = rawNumbers
+	r.tr.pos = r.tr.len
+	return nil
+}
+
+// ReadFirstOf tries several ways of reading the next value, in order, and returns the result of
+// the first one that succeeds. This is useful for a value that may have one of several forms, such
+// as a union type, without having to inspect it first:
+//
+//	value, err := r.ReadFirstOf(readShapeAsObject, readShapeAsArray)
+//
+// Each strategy is called with a clone of the Reader (see Clone). A strategy fails if it returns an
+// error or leaves its Reader in a failed state. When a strategy succeeds, the Reader continues from
+// where that strategy's clone left off, and the remaining strategies are not called. If all of the
+// strategies fail, the Reader enters a failed state with the error from the last one, which is also
+// returned, and the Reader's position is unchanged. If there are no strategies, the return values
+// are nil and the Reader's current error, if any.
+func (r *Reader) ReadFirstOf(strategies ...func(*Reader) (*AnyValue, error)) (*AnyValue, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var lastErr error
+	for _, strategy := range strategies {
+		c := r.Clone()
+		value, err := strategy(&c)
+		if err == nil {
+			err = c.err
+		}
+		if err == nil {
+			*r = c
+			return value, nil
+		}
+		lastErr = err
+	}
+	r.AddError(lastErr)
+	return nil, r.err
+}
+
+func (r *Reader) readAnyTree() *AnyValue {
+	v := r.Any()
+	if r.err != nil {
+		return nil
+	}
+	result := &AnyValue{Kind: v.Kind}
+	switch v.Kind {
+	case BoolValue:
+		result.Bool = v.Bool
+	case NumberValue:
+		result.Number = v.Number
+		result.Number.raw = append([]byte(nil), v.Number.raw...)
+	case StringValue:
+		result.String = append([]byte{}, r.decodedString(nil, v.String)...)
+	case ArrayValue:
+		arr := v.Array
+		result.Elements = []*AnyValue{}
+		for arr.Next() {
+			element := r.readAnyTree()
+			if r.err != nil {
+				return nil
+			}
+			result.Elements = append(result.Elements, element)
+		}
+	case ObjectValue:
+		obj := v.Object
+		result.Properties = []AnyProperty{}
+		for obj.Next() {
+			name := append([]byte{}, r.decodedString(nil, obj.Name())...)
+			value := r.readAnyTree()
+			if r.err != nil {
+				return nil
+			}
+			result.Properties = append(result.Properties, AnyProperty{Name: name, Value: value})
+		}
+	}
+	if r.err != nil {
+		return nil
+	}
+	return result
+}
+
+func (r *Reader) failedAnyValue() *AnyValue {
+	r.tr.anyValueBuffer = AnyValue{}
+	return &r.tr.anyValueBuffer
+}
+
 // SkipValue consumes and discards the next JSON value of any type. For an array or object value, it
-// recurses to also consume and discard all array elements or object properties.
+// also consumes and discards all array elements or object properties. This does not use recursion,
+// so skipping a deeply nested value cannot exhaust the stack.
 func (r *Reader) SkipValue() error {
+	_, err := r.SkipValueKind()
+	return err
+}
+
+// SkipValueKind is the same as SkipValue, but also returns the kind of the value that was skipped.
+// This is useful when the caller only needs to know what type of value was present, without
+// decoding it.
+//
+// If there is an error, the returned kind is NullValue and should be ignored.
+func (r *Reader) SkipValueKind() (ValueKind, error) {
+	if r.err != nil {
+		return NullValue, r.err
+	}
 	if r.tr.options.lazyRead {
-		skipped := r.tr.structBuffer.SkipSubTree()
-		if skipped {
-			return nil
-		} else {
-			return fmt.Errorf("subtree can't be skipped")
+		r.beginValue()
+		if r.err != nil {
+			return NullValue, r.err
 		}
+		node, err := r.tr.currentNode()
+		if err != nil {
+			r.err = err
+			return NullValue, err
+		}
+		r.tr.structBuffer.SkipSubTree()
+		return node.Kind(), nil
 	} else {
-		r.awaitingReadValue = false
+		kind := r.Any().Kind // copied now, since the returned value is reused by nested reads
+		if r.err == nil && (kind == ArrayValue || kind == ObjectValue) {
+			if err := r.skipContainerContents(kind == ObjectValue); err != nil {
+				r.err = err
+				return NullValue, err
+			}
+			r.exitContainer()
+		}
 		if r.err != nil {
-			return r.err
+			return NullValue, r.err
+		}
+		return kind, nil
+	}
+}
+
+// skipContainerContents consumes the rest of an array or object whose opening delimiter has already
+// been read, including its closing delimiter. Instead of recursing into nested arrays and objects,
+// it keeps track of the enclosing ones in a stack.
+func (r *Reader) skipContainerContents(isObject bool) error {
+	var enclosingBuf [32]bool
+	enclosing := enclosingBuf[:0] // isObject values for the containers that we are inside of
+	afterFirst := false
+	for {
+		closing := byte(']')
+		if isObject {
+			closing = '}'
+		}
+		var isEnd bool
+		var err error
+		if afterFirst {
+			isEnd, err = r.tr.EndDelimiterOrComma(closing)
+		} else {
+			r.tr.options.readKey = isObject
+			isEnd, err = r.tr.Delimiter(closing)
+			r.tr.options.readKey = false
 		}
-		v := r.Any()
-		if v.Kind == ArrayValue {
-			arr := v.Array
-			for arr.Next() {
+		if err != nil {
+			return err
+		}
+		if isEnd {
+			if len(enclosing) == 0 {
+				return nil
 			}
-		} else if v.Kind == ObjectValue {
-			obj := v.Object
-			for obj.Next() {
+			isObject = enclosing[len(enclosing)-1]
+			enclosing = enclosing[:len(enclosing)-1]
+			afterFirst = true
+			continue
+		}
+		if isObject {
+			if _, err := r.tr.PropertyName(); err != nil {
+				return err
 			}
 		}
-		return r.err
+		v, err := r.tr.Any()
+		if err != nil {
+			return err
+		}
+		afterFirst = true
+		if v.Kind == ArrayValue || v.Kind == ObjectValue {
+			enclosing = append(enclosing, isObject)
+			isObject = v.Kind == ObjectValue
+			afterFirst = false
+		}
 	}
 }
 
+// SetDetectMisuse enables or disables extra checks for incorrect use of the Reader API. Currently
+// this detects an attempt to read a value inside an object when the Reader is positioned at a
+// property name, which happens if the caller reads a property value without first calling the
+// ObjectState's Next method. Such a call is reported as a UsageError rather than silently reading
+// the property name as if it were a value.
+//
+// These checks add a small amount of overhead, so they are disabled by default.
+func (r *Reader) SetDetectMisuse(detect bool) {
+	r.detectMisuse = detect
+	r.containers = r.containers[:0]
+}
+
+// beginValue is called at the start of every method that consumes a value.
+func (r *Reader) beginValue() {
+	if r.detectMisuse && !r.awaitingReadValue && r.err == nil {
+		if n := len(r.containers); n > 0 && r.containers[n-1] == ObjectValue {
+			r.err = UsageError{Message: errMsgValueBeforeNext, Offset: r.tr.getPos()}
+		}
+	}
+	r.valueWasAwaited = r.awaitingReadValue
+	r.awaitingReadValue = false
+	if r.inputCheck.pending {
+		r.checkInput()
+	}
+}
+
+func (r *Reader) enterContainer(kind ValueKind) {
+	if r.detectMisuse {
+		r.containers = append(r.containers, kind)
+	}
+	if r.defaultToNull && r.tr.options.lazyRead {
+		r.lazyContainers = append(r.lazyContainers, r.tr.structBuffer.pos)
+	}
+}
+
+func (r *Reader) exitContainer() {
+	if r.detectMisuse && len(r.containers) > 0 {
+		r.containers = r.containers[:len(r.containers)-1]
+	}
+	if r.tr.options.lazyRead {
+		r.dropFinishedContainers()
+		if n := len(r.lazyContainers); n > 0 {
+			r.lazyContainers = r.lazyContainers[:n-1]
+		}
+	}
+}
+
+// dropFinishedContainers removes the containers that end before the current tree node from
+// lazyContainers, such as those whose iteration was abandoned before the end.
+func (r *Reader) dropFinishedContainers() {
+	values := *r.tr.structBuffer.values
+	pos := r.tr.structBuffer.pos
+	for n := len(r.lazyContainers); n > 0; n-- {
+		index := r.lazyContainers[n-1]
+		if index+values[index].subTreeSize >= pos {
+			break
+		}
+		r.lazyContainers = r.lazyContainers[:n-1]
+	}
+}
+
+// SetDefaultToNull enables or disables treating a missing value as a null. When enabled, if a
+// method that reads a value is called when there is no value to read, because the Reader is at the
+// end of an array or object or at the end of the input, it consumes nothing and returns as it would
+// for a null that was allowed: a zero value, or, for an OrNull variant, a zero value and false. This
+// applies to the methods that read a null, boolean, number, or string (such as Null, Bool, Int64,
+// Float64, Numberish, String, StringUsing, or one of their OrNull variants); Array, Object, and
+// their OrNull variants return a stub state whose Next method returns false, and Any returns a
+// NullValue. This can make a decoder for a struct more forgiving of a property that is absent
+// because the Reader has already moved past it, instead of putting the Reader into a failed state.
+//
+// In lazy mode (see PreProcess), this applies within the arrays and objects that were entered
+// while it was enabled, whose ends are found from their nodes in the pre-processed tree; a value in
+// one of them is missing if it is read without a call to Next after the previous value, just as in
+// streaming mode. Elsewhere a value is only treated as missing at the end of the tree. This is
+// disabled by default, and Reset does not change it.
+func (r *Reader) SetDefaultToNull(enabled bool) {
+	r.defaultToNull = enabled
+}
+
+// missingValue returns true if SetDefaultToNull is enabled and there is no value at the Reader's
+// position.
+func (r *Reader) missingValue() bool {
+	if !r.defaultToNull {
+		return false
+	}
+	if r.tr.options.lazyRead {
+		if !r.tr.structBuffer.HasNext() {
+			return true
+		}
+		r.dropFinishedContainers()
+		n := len(r.lazyContainers)
+		if n == 0 {
+			return false
+		}
+		index := r.lazyContainers[n-1]
+		size := (*r.tr.structBuffer.values)[index].subTreeSize
+		if index == r.tr.structBuffer.pos { // Next has not been called yet
+			return size == 1
+		}
+		return !r.valueWasAwaited
+	}
+	if r.tr.hasUnread {
+		t := r.tr.unreadToken
+		return t.kind == delimiterToken && (t.delimiter == ',' || t.delimiter == ']' || t.delimiter == '}')
+	}
+	pos := skipRawWhitespace(r.tr.data, r.tr.pos)
+	return pos >= len(r.tr.data) || r.tr.data[pos] == ',' || r.tr.data[pos] == ']' || r.tr.data[pos] == '}'
+}
+
+// SetProgressFunc sets a function to be called periodically with the number of bytes of input that
+// have been consumed so far, for reporting progress while parsing very large documents. The function
+// is called at most once for every interval bytes consumed, and once more when the end of the input
+// is reached; it is called from the same goroutine that is using the Reader. PreProcess also
+// reports progress as it parses the input. In lazy mode, including when reading with
+// ReadObjectFlattened, the progress is the start of each value as it is read; a value that is
+// skipped as a whole, such as an array that ReadObjectFlattened copies as raw JSON, is not reported
+// separately.
+//
+// A nil function disables progress reporting, which is the default. When disabled, the overhead is
+// a single nil check per token.
+func (r *Reader) SetProgressFunc(fn func(consumedBytes int), interval int) {
+	if interval < 1 {
+		interval = 1
+	}
+	r.tr.progressFunc = fn
+	r.tr.progressInterval = interval
+	r.tr.progressLastReported = 0
+}
+
+// Consumed returns the number of bytes of input that have been consumed so far. In lazy mode, this
+// is the start of the next value in the preprocessed structure, or the length of the input if all
+// values have been read.
+func (r *Reader) Consumed() int {
+	if r.tr.options.lazyRead {
+		if currStruct, err := r.tr.structBuffer.CurrentStruct(); err == nil {
+			return currStruct.start
+		}
+		return r.tr.len
+	}
+	return r.tr.getPos()
+}
+
+// Tail returns the part of the input that has not been consumed yet, starting at Consumed. This is
+// useful when the input has something other than JSON after the values that have been read. The
+// result refers to the input without copying it.
+func (r *Reader) Tail() []byte {
+	return r.tr.data[r.Consumed():]
+}
+
 func (r *Reader) SetNumberRawRead(readRaw bool) {
 	r.tr.options.readRawNumbers = readRaw
 }
@@ -601,27 +1671,110 @@ func (r *Reader) IsNumbersRaw() bool {
 }
 
 func (r *Reader) SyncWithPreProcess() {
-	if r.tr.structBuffer.Values == nil {
+	if r.tr.structBuffer.values == nil {
 		return
 	}
 	if r.tr.options.lazyRead {
 		r.tr.options.lazyRead = false
-		bufferSize := len(*r.tr.structBuffer.Values)
+		bufferSize := len(*r.tr.structBuffer.values)
 		if bufferSize != 0 {
-			lastStruct := (*r.tr.structBuffer.Values)[0]
-			r.tr.pos = lastStruct.End
+			lastStruct := (*r.tr.structBuffer.values)[0]
+			r.tr.pos = lastStruct.end
 		}
 	}
 }
 
+// PreProcessOptions determines what Reader.PreProcessWith computes while it pre-processes the
+// input.
+type PreProcessOptions struct {
+	// ComputeStrings is true if string values are decoded while pre-processing, so that reading
+	// them in lazy mode does not need to decode them again. It has no effect unless the Reader was
+	// given a buffer for them in BufferConfig.ComputedValuesBuffer.
+	ComputeStrings bool
+
+	// ComputeNumbers is true if numbers are parsed while pre-processing. It has no effect unless
+	// the Reader was given a buffer for them in BufferConfig.ComputedValuesBuffer.
+	ComputeNumbers bool
+
+	// DeferStrings is true if, when ComputeStrings is also true, each string value is decoded the
+	// first time it is read rather than while pre-processing. Pre-processing only records which
+	// strings contain escape sequences; when one of those is first read, its decoded form is added
+	// to the computed values buffer, and later reads of the same value return it from there. A
+	// string without escape sequences is returned directly from the input, and never added to the
+	// buffer. This is faster than decoding every string up front if only a few of them are read.
+	DeferStrings bool
+
+	// DeferNumbers is true if, when ComputeNumbers is also true, each number is parsed the first
+	// time it is read rather than while pre-processing. When a number is first read, its parsed
+	// form is added to the computed values buffer, and later reads of the same value return it
+	// from there. This is faster than parsing every number up front if only a few of them are read.
+	// Each number is still checked against the JSON number grammar while pre-processing, so a
+	// malformed number causes a SyntaxError then, as it does without DeferNumbers when raw number
+	// reading is disabled.
+	DeferNumbers bool
+
+	// DisableMutationCheck is true if the Reader does not check whether the input was modified
+	// after pre-processing. Since the tree refers to the input by offsets, lazy reads of an input
+	// that has changed, such as a pooled buffer that was reused too early, would return the wrong
+	// values; so by default, the first value that is read in lazy mode after PreProcessWith is
+	// preceded by a check of the input's length and of a sample of its bytes at the start and end
+	// of the pre-processed value, and the Reader enters a failed state with a StateError if they
+	// differ. This is done only once, so it does not catch a change made after the first lazy read,
+	// or one that is outside the sample; its cost does not depend on the size of the input.
+	DisableMutationCheck bool
+
+	// BuildIndex must be true. It requests the tree that describes the structure of the input, which
+	// is what the Reader reads from in lazy mode, and it exists so that PreProcessOptions{} is not
+	// mistaken for the default options that PreProcess uses: if it is false, PreProcessWith only
+	// puts the Reader into a failed state with a UsageError.
+	BuildIndex bool
+}
+
+// PreProcess is the same as PreProcessWith, computing the values that the Reader was given buffers
+// for in BufferConfig.ComputedValuesBuffer.
 func (r *Reader) PreProcess() {
-	if r.tr.structBuffer.Values == nil || r.tr.charBuffer == nil {
+	r.PreProcessWith(PreProcessOptions{
+		ComputeStrings: r.tr.computedValuesBuffer.StringValues != nil,
+		ComputeNumbers: r.tr.computedValuesBuffer.NumberValues != nil,
+		BuildIndex:     true,
+	})
+}
+
+// PreProcessWith parses the next value, builds a tree that describes its structure, and switches
+// the Reader to lazy mode, in which values are read from the tree; opts overrides the settings from
+// BufferConfig for this call only. For instance, a fast index-only pass that is enough for
+// navigating the structure, with strings and numbers left as raw text until they are read, is:
+//
+//	r.PreProcessWith(jreader.PreProcessOptions{BuildIndex: true})
+//
+// A later call to PreProcess, or to Reset, restores the settings from BufferConfig. This method has
+// no effect if the Reader was not given a tree buffer and a char buffer in BufferConfig, or if it was
+// created by a ReaderView, whose tree it must not overwrite.
+func (r *Reader) PreProcessWith(opts PreProcessOptions) {
+	if r.tr.structBuffer.values == nil || r.tr.charBuffer == nil || r.tr.options.sharedTree {
 		return
 	}
+	if !opts.BuildIndex {
+		r.AddError(UsageError{Message: errMsgPreProcessNoIndex, Offset: r.tr.getPos()})
+		return
+	}
+	r.tr.options.computeString = opts.ComputeStrings && r.tr.computedValuesBuffer.StringValues != nil
+	r.tr.options.computeNumber = opts.ComputeNumbers && r.tr.computedValuesBuffer.NumberValues != nil
 	r.tr.options.lazyParse = true
 	r.tr.options.lazyRead = false
+	r.deferred = deferredValues{}
 	cr := *r
-	*r.tr.structBuffer.Values = (*r.tr.structBuffer.Values)[:0]
+	if opts.DeferStrings && r.tr.options.computeString {
+		cr.tr.options.computeString = false
+		cr.tr.options.deferString = true
+		r.deferred.strings = true
+	}
+	if opts.DeferNumbers && r.tr.options.computeNumber {
+		cr.tr.options.computeNumber = false
+		cr.tr.options.deferNumber = true
+		r.deferred.numbers = true
+	}
+	*r.tr.structBuffer.values = (*r.tr.structBuffer.values)[:0]
 	*r.tr.charBuffer = (*r.tr.charBuffer)[:0]
 	if r.tr.options.computeString {
 		*r.tr.computedValuesBuffer.StringValues = (*r.tr.computedValuesBuffer.StringValues)[:0]
@@ -629,35 +1782,71 @@ func (r *Reader) PreProcess() {
 	if r.tr.options.computeNumber {
 		*r.tr.computedValuesBuffer.NumberValues = (*r.tr.computedValuesBuffer.NumberValues)[:0]
 	}
-	r.tr.structBuffer.Pos = 0
+	r.tr.structBuffer.pos = 0
 	cr.preProcess()
+	if cr.err != nil {
+		r.err = cr.err
+	}
+	r.inputCheck = inputSample{}
+	values := *r.tr.structBuffer.values
+	if !opts.DisableMutationCheck && len(values) > 0 && values[0].end <= len(r.tr.data) {
+		r.inputCheck = inputSample{
+			length:  len(r.tr.data),
+			sum:     inputChecksum(r.tr.data, values[0].start, values[0].end),
+			enabled: true,
+			pending: true,
+		}
+	}
 	r.tr.options.lazyRead = true
 	r.tr.options.lazyParse = false
 }
 
+// checkInput verifies, on the first lazy read after PreProcessWith, that the input has the same
+// length and the same sample of bytes as when it was pre-processed.
+func (r *Reader) checkInput() {
+	r.inputCheck.pending = false
+	if r.err != nil || !r.tr.options.lazyRead {
+		return
+	}
+	root := (*r.tr.structBuffer.values)[0]
+	if len(r.tr.data) != r.inputCheck.length ||
+		inputChecksum(r.tr.data, root.start, root.end) != r.inputCheck.sum {
+		r.err = StateError{Message: errMsgInputChanged, Offset: root.start}
+	}
+}
+
 func (r *Reader) preProcess() {
 	value := r.Any()
 
-	if value == nil {
-		r.err = fmt.Errorf("can't parse value")
+	if r.err != nil {
 		return
 	}
 
-	tree := r.tr.structBuffer.Values
+	tree := r.tr.structBuffer.values
 
 	pos := len(*tree)
-	*tree = append(*tree, JsonTreeStruct{Start: r.tr.lastPos, SubTreeSize: 1})
+	*tree = append(*tree, JsonTreeStruct{start: r.tr.lastPos, subTreeSize: 1, kind: value.Kind})
 
 	switch value.Kind {
 	case NumberValue:
 		if r.tr.options.computeNumber {
-			(*tree)[pos].ComputedValueType = NumberComputed
-			(*tree)[pos].ComputedValueIndex = len(*r.tr.computedValuesBuffer.NumberValues) - 1
+			nValues := r.tr.computedValuesBuffer.NumberValues
+			(*tree)[pos].computedType = NumberComputed
+			(*tree)[pos].computedIndex = len(*nValues)
+			*nValues = append(*nValues, value.Number)
+		} else if r.tr.options.deferNumber {
+			(*tree)[pos].computedType = numberDeferred
 		}
 	case StringValue:
 		if r.tr.options.computeString {
-			(*tree)[pos].ComputedValueType = StringComputed
-			(*tree)[pos].ComputedValueIndex = len(*r.tr.computedValuesBuffer.StringValues) - 1
+			(*tree)[pos].computedType = StringComputed
+			(*tree)[pos].computedIndex = len(*r.tr.computedValuesBuffer.StringValues) - 1
+		} else if r.tr.options.deferString && bytes.IndexByte(value.String, '\\') >= 0 {
+			if !validEscapes(value.String) {
+				r.err = SyntaxError{Message: errMsgInvalidString, Offset: r.tr.lastPos}
+				return
+			}
+			(*tree)[pos].computedType = stringDeferred
 		}
 	case ObjectValue:
 		for kv := value.Object; kv.Next(); {
@@ -665,8 +1854,8 @@ func (r *Reader) preProcess() {
 			key := kv.Name()
 			r.preProcess()
 			if len(*tree) > nextPos {
-				(*tree)[pos].SubTreeSize += (*tree)[nextPos].SubTreeSize
-				(*tree)[nextPos].AssocValue = key
+				(*tree)[pos].subTreeSize += (*tree)[nextPos].subTreeSize
+				(*tree)[nextPos].key = key
 			}
 		}
 	case ArrayValue:
@@ -674,11 +1863,11 @@ func (r *Reader) preProcess() {
 			nextPos := len(*tree)
 			r.preProcess()
 			if len(*tree) > nextPos {
-				(*tree)[pos].SubTreeSize += (*tree)[nextPos].SubTreeSize
+				(*tree)[pos].subTreeSize += (*tree)[nextPos].subTreeSize
 			}
 		}
 	}
-	(*tree)[pos].End = r.tr.pos
+	(*tree)[pos].end = r.tr.pos
 }
 
 func typeErrorForNullableValue(err error) error {
@@ -700,9 +1889,12 @@ func (r *Reader) typeErrorForCurrentToken(expected ValueKind, nullable bool) err
 	return TypeError{Expected: expected, Actual: v.Kind, Offset: r.tr.LastPos(), Nullable: nullable}
 }
 
+// JsonStructPointer is the Reader's position within the pre-processed tree that is built by
+// Reader.PreProcess. Its state is only changed by the Reader, so that it always agrees with the
+// Reader's position in the input.
 type JsonStructPointer struct {
-	Pos    int
-	Values *[]JsonTreeStruct
+	pos    int
+	values *[]JsonTreeStruct
 }
 
 type JsonComputedValueType int32
@@ -711,6 +1903,8 @@ const (
 	NothingComputed JsonComputedValueType = iota
 	NumberComputed
 	StringComputed
+	stringDeferred // a string with escape sequences that is decoded when it is first read
+	numberDeferred // a number that is parsed when it is first read
 )
 
 type JsonComputedValues struct {
@@ -719,46 +1913,223 @@ type JsonComputedValues struct {
 }
 
 func (jPointer *JsonStructPointer) HasNext() bool {
-	return jPointer.Pos < len(*jPointer.Values)
+	return jPointer.pos < len(*jPointer.values)
 }
 
 func (jPointer *JsonStructPointer) Next() bool {
 	if !jPointer.HasNext() {
 		return false
 	}
-	jPointer.Pos++
+	jPointer.pos++
 	return true
 }
 
 func (jPointer *JsonStructPointer) SkipSubTree() bool {
-	if jPointer.Pos >= len(*jPointer.Values) {
+	if jPointer.pos >= len(*jPointer.values) {
 		return false
 	}
-	jPointer.Pos += (*jPointer.Values)[jPointer.Pos].SubTreeSize
+	jPointer.pos += (*jPointer.values)[jPointer.pos].subTreeSize
 	return true
 }
 
+// childCount returns the number of direct children of the array or object node at the specified
+// index.
+func (jPointer *JsonStructPointer) childCount(index int) int {
+	if index < 0 || index >= len(*jPointer.values) {
+		return 0
+	}
+	values := *jPointer.values
+	count := 0
+	end := index + values[index].subTreeSize
+	for pos := index + 1; pos < end; pos += values[pos].subTreeSize {
+		count++
+	}
+	return count
+}
+
+// findChild returns the index of the value of the property called name in the object whose node
+// is at index, or -1 if there is no such property. If the property appears more than once, the
+// last occurrence is returned, since that is the one that would take effect when decoding.
+func (jPointer *JsonStructPointer) findChild(index int, name string) int {
+	values := *jPointer.values
+	found := -1
+	end := index + values[index].subTreeSize
+	for pos := index + 1; pos < end; pos += values[pos].subTreeSize {
+		if propertyNameEquals(values[pos].key, name) {
+			found = pos
+		}
+	}
+	return found
+}
+
+// Position returns the index in the tree of the node for the next value that the Reader will read.
+func (jPointer *JsonStructPointer) Position() int {
+	return jPointer.pos
+}
+
+// Pos returns the index in the tree of the node for the next value that the Reader will read.
+//
+// Deprecated: this replaces the former Pos field; use Position instead.
+func (jPointer *JsonStructPointer) Pos() int {
+	return jPointer.pos
+}
+
+// Values returns the tree that the pointer is in.
+//
+// Deprecated: this replaces the former Values field. The tree is the slice that the caller passed
+// as BufferConfig.StructBuffer, which can be used directly.
+func (jPointer *JsonStructPointer) Values() *[]JsonTreeStruct {
+	return jPointer.values
+}
+
 func (jPointer *JsonStructPointer) CurrentStruct() (JsonTreeStruct, error) {
-	if jPointer.Pos >= len(*jPointer.Values) {
+	if jPointer.pos >= len(*jPointer.values) {
 		return JsonTreeStruct{}, fmt.Errorf("no elements in structure")
 	}
-	return (*jPointer.Values)[jPointer.Pos], nil
+	return (*jPointer.values)[jPointer.pos], nil
 }
 
+// ReturnBackOn moves the pointer back by shift nodes, if the result is within the tree.
+//
+// Deprecated: moving the pointer independently of the Reader's position in the input can leave
+// the Reader in an inconsistent state. This method is not used by the Reader and will be removed in
+// a future release.
 func (jPointer *JsonStructPointer) ReturnBackOn(shift int) bool {
-	jPointer.Pos -= shift
-	if jPointer.Pos < 0 || jPointer.Pos >= len(*jPointer.Values) {
-		jPointer.Pos += shift
+	jPointer.pos -= shift
+	if jPointer.pos < 0 || jPointer.pos >= len(*jPointer.values) {
+		jPointer.pos += shift
 		return false
 	}
 	return true
 }
 
+// JsonTreeStruct is a node in the tree that Reader.PreProcess builds to describe the structure of a
+// JSON value. The tree is a slice in depth-first order: each node is followed by the nodes of its
+// array elements or object property values, so the subtree of a node is the node itself and the
+// SubtreeLen()-1 nodes after it.
+//
+// The caller provides the slice for the tree as BufferConfig.StructBuffer, and can pass it to
+// functions such as FindAll and NewPatchWriter afterward, but the nodes are only meant to be created
+// by the Reader; their fields are read with the accessor methods.
 type JsonTreeStruct struct {
-	Start              int
-	End                int
-	SubTreeSize        int
-	AssocValue         []byte // for key:value it is key, else nil
-	ComputedValueType  JsonComputedValueType
-	ComputedValueIndex int
+	start         int
+	end           int
+	subTreeSize   int
+	key           []byte // for key:value it is key, else nil
+	kind          ValueKind
+	computedType  JsonComputedValueType
+	computedIndex int
+}
+
+// Span returns the positions in the input of the first character of the value and of the character
+// after its last one.
+func (node JsonTreeStruct) Span() (start, end int) {
+	return node.start, node.end
+}
+
+// Kind returns the type of the value.
+func (node JsonTreeStruct) Kind() ValueKind {
+	return node.kind
+}
+
+// Key returns the name of the object property whose value this is, as it appears in the input
+// (without decoding any escape sequences), or nil if the value is not an object property.
+func (node JsonTreeStruct) Key() []byte {
+	return node.key
+}
+
+// SubtreeLen returns the number of nodes in the subtree of the value, including the node itself;
+// for a value that is not an array or object, or an empty one, this is 1.
+func (node JsonTreeStruct) SubtreeLen() int {
+	return node.subTreeSize
+}
+
+// Computed returns where the value that PreProcessWith computed for this node is: for
+// NumberComputed, index is an index into JsonComputedValues.NumberValues, and for StringComputed,
+// into JsonComputedValues.StringValues. It returns NothingComputed if the value was not computed,
+// including when its computation was deferred until it is read.
+func (node JsonTreeStruct) Computed() (valueType JsonComputedValueType, index int) {
+	switch node.computedType { //nolint:exhaustive
+	case NumberComputed, StringComputed:
+		return node.computedType, node.computedIndex
+	}
+	return NothingComputed, 0
+}
+
+// Start returns the position in the input of the first character of the value.
+//
+// Deprecated: this replaces the former Start field; use Span instead.
+func (node JsonTreeStruct) Start() int {
+	return node.start
+}
+
+// End returns the position in the input of the character after the value.
+//
+// Deprecated: this replaces the former End field; use Span instead.
+func (node JsonTreeStruct) End() int {
+	return node.end
+}
+
+// SubTreeSize returns the number of nodes in the subtree of the value.
+//
+// Deprecated: this replaces the former SubTreeSize field; use SubtreeLen instead.
+func (node JsonTreeStruct) SubTreeSize() int {
+	return node.subTreeSize
+}
+
+// AssocValue returns the name of the object property whose value this is, or nil.
+//
+// Deprecated: this replaces the former AssocValue field; use Key instead.
+func (node JsonTreeStruct) AssocValue() []byte {
+	return node.key
+}
+
+// ComputedValueType returns the kind of value that PreProcessWith computed for this node.
+//
+// Deprecated: this replaces the former ComputedValueType field; use Computed instead.
+func (node JsonTreeStruct) ComputedValueType() JsonComputedValueType {
+	valueType, _ := node.Computed()
+	return valueType
+}
+
+// ComputedValueIndex returns the index of the value that PreProcessWith computed for this node.
+//
+// Deprecated: this replaces the former ComputedValueIndex field; use Computed instead.
+func (node JsonTreeStruct) ComputedValueIndex() int {
+	_, index := node.Computed()
+	return index
+}
+
+// inputSampleSize is the number of bytes at each end of a pre-processed value that inputSample
+// covers.
+const inputSampleSize = 64
+
+// inputSample is a cheap fingerprint of the input that a tree was built from.
+type inputSample struct {
+	length  int
+	sum     uint64
+	enabled bool // false if the check is disabled or PreProcessWith was not called
+	pending bool // true until the first lazy read after PreProcessWith
+}
+
+// inputChecksum computes an FNV-1a hash of the first and last inputSampleSize bytes of
+// data[start:end]. It returns 0 if the span is no longer within data.
+func inputChecksum(data []byte, start, end int) uint64 {
+	if end > len(data) {
+		return 0
+	}
+	sum := uint64(14695981039346656037)
+	add := func(b []byte) {
+		for _, c := range b {
+			sum ^= uint64(c)
+			sum *= 1099511628211
+		}
+	}
+	if end-start <= 2*inputSampleSize {
+		add(data[start:end])
+	} else {
+		add(data[start : start+inputSampleSize])
+		add(data[end-inputSampleSize : end])
+	}
+	return sum
 }
@@ -1,6 +1,9 @@
 package jreader
 
-import "fmt"
+import (
+	"fmt"
+	"math/rand"
+)
 
 // ArrayState is returned by Reader's Array and ArrayOrNull methods. Use it in conjunction with
 // Reader to iterate through a JSON array. To read the value of each array element, you will still
@@ -30,24 +33,35 @@ func (arr *ArrayState) IsDefined() bool {
 	return arr.r != nil
 }
 
+// Reader returns the Reader that the ArrayState was created by, which is the one to use for reading
+// the array elements, or nil if the ArrayState is not defined (see IsDefined).
+func (arr *ArrayState) Reader() *Reader {
+	return arr.r
+}
+
 // Next checks whether an array element is available and returns true if so. It returns false
 // if the Reader has reached the end of the array, or if any previous Reader operation failed,
 // or if the array was empty or null.
 //
 // If Next returns true, you can then use Reader methods such as Bool or String to read the
 // element value. If you do not care about the value, simply calling Next again without calling
-// a Reader method will discard the value, just as if you had called SkipValue on the reader.
+// a Reader method will discard the value, just as if you had called SkipValue on the reader. This
+// is the same in lazy mode, where Next moves past the element's subtree in the pre-processed tree.
 //
 // See ArrayState for example code.
 func (arr *ArrayState) Next() bool {
-	if arr.r == nil {
+	if arr.r == nil || arr.r.err != nil {
 		return false
 	}
 	if arr.r.tr.options.lazyRead {
 		reader := &arr.r.tr
 		tape := &reader.structBuffer
-		currPos := tape.Pos
 		initPos := arr.arrayIndex
+		if tape.pos != initPos && arr.r.awaitingReadValue {
+			arr.r.awaitingReadValue = false
+			tape.SkipSubTree()
+		}
+		currPos := tape.pos
 
 		if !tape.HasNext() {
 			return false
@@ -61,14 +75,21 @@ func (arr *ArrayState) Next() bool {
 
 		if initPos == currPos {
 			tape.Next()
-			return currStruct.SubTreeSize != 1
+			if currStruct.subTreeSize == 1 {
+				arr.r.exitContainer()
+				return false
+			}
+			arr.r.awaitingReadValue = true
+			return true
 		}
 
-		return (*tape.Values)[initPos].SubTreeSize+initPos != currPos
-	} else {
-		if arr.r == nil || arr.r.err != nil {
+		if (*tape.values)[initPos].subTreeSize+initPos == currPos {
+			arr.r.exitContainer()
 			return false
 		}
+		arr.r.awaitingReadValue = true
+		return true
+	} else {
 		var isEnd bool
 		var err error
 		if arr.afterFirst {
@@ -86,9 +107,326 @@ func (arr *ArrayState) Next() bool {
 			arr.r.AddError(err)
 			return false
 		}
-		if !isEnd {
-			arr.r.awaitingReadValue = true
+		if isEnd {
+			arr.r.exitContainer()
+			return false
+		}
+		arr.r.awaitingReadValue = true
+		return true
+	}
+}
+
+// At moves to the array element at the specified index, counting from zero, and returns true if
+// there is such an element. The element can then be read with the Reader's methods as usual. This
+// can be used at any point while iterating over the array; afterward, Next continues from the
+// element after the one that At moved to, so calling At(i) followed by Next is the same as
+// iterating from element i. If At is never called, iteration is not affected.
+//
+//	arr := r.Array()
+//	if arr.At(2) {
+//	    third := r.String()
+//	}
+//
+// If the index is out of range, At returns false and does not change the current position. At can
+// only be used in lazy mode (see Reader.PreProcess), where it finds the element by skipping over
+// the pre-processed subtrees of the elements before it; otherwise, it returns false and the Reader
+// enters a failed state with a StateError.
+func (arr *ArrayState) At(index int) bool {
+	if arr.r == nil || arr.r.err != nil {
+		return false
+	}
+	if !arr.r.tr.options.lazyRead {
+		arr.r.err = StateError{Message: errMsgAtNotInLazyMode, Offset: arr.r.tr.getPos()}
+		return false
+	}
+	tape := &arr.r.tr.structBuffer
+	values := *tape.values
+	end := arr.arrayIndex + values[arr.arrayIndex].subTreeSize
+	pos := arr.arrayIndex + 1
+	for i := 0; i < index && pos < end; i++ {
+		pos += values[pos].subTreeSize
+	}
+	if index < 0 || pos >= end {
+		return false
+	}
+	tape.pos = pos
+	arr.r.awaitingReadValue = true
+	return true
+}
+
+// SkipN skips over the next n array elements without reading them, so that the following call to
+// Next moves to the element after them. This is the same as calling Next n times, for instance to
+// resume reading a large array from a known index:
+//
+//	arr := r.Array()
+//	if err := arr.SkipN(5000); err != nil { ... }
+//	for arr.Next() {
+//	    // reads elements 5000 and onward
+//	}
+//
+// If SkipN is called after Next has returned true, the current element is not counted, whether or
+// not it has been read. In lazy mode (see Reader.PreProcess), the elements are skipped by moving
+// over their pre-processed subtrees, without looking at the input again; otherwise, each one is
+// parsed and discarded as by SkipValue. To move to an absolute index in lazy mode, use At instead.
+//
+// If there are fewer than n elements left, the error is a TupleLengthError whose Expected field is
+// n and whose Actual field is the number of elements that were skipped, and the Reader enters a
+// failed state. Skipping exactly to the end of the array is not an error; Next then returns false.
+func (arr *ArrayState) SkipN(n int) error {
+	if arr.r == nil {
+		if n > 0 {
+			return TupleLengthError{Expected: n}
+		}
+		return nil
+	}
+	r := arr.r
+	if r.err != nil || n <= 0 {
+		return r.err
+	}
+	if !r.tr.options.lazyRead {
+		for skipped := 0; skipped < n; skipped++ {
+			if !arr.Next() {
+				if r.err == nil {
+					r.err = TupleLengthError{Expected: n, Actual: skipped, Offset: r.tr.LastPos()}
+				}
+				return r.err
+			}
+		}
+		return nil
+	}
+	tape := &r.tr.structBuffer
+	values := *tape.values
+	end := arr.arrayIndex + values[arr.arrayIndex].subTreeSize
+	pos := tape.pos
+	switch {
+	case pos == arr.arrayIndex:
+		pos++
+	case r.awaitingReadValue:
+		pos += values[pos].subTreeSize
+	}
+	for skipped := 0; skipped < n; skipped++ {
+		if skipped > 0 {
+			pos += values[pos].subTreeSize
+		}
+		if pos >= end {
+			tape.pos = end
+			r.awaitingReadValue = false
+			r.err = TupleLengthError{Expected: n, Actual: skipped, Offset: values[arr.arrayIndex].end - 1}
+			return r.err
+		}
+	}
+	tape.pos = pos
+	r.awaitingReadValue = true
+	return nil
+}
+
+// ReadArrayEmpty checks whether the next JSON value is an empty array. If so, it consumes the array
+// and returns true; otherwise, it returns false without consuming anything, so the value can then be
+// read as usual. This is a cheaper way to handle the common case of an empty array than starting to
+// iterate over it with Array.
+//
+// A value that is not an array, or the end of the input, is not an error here; it is detected when
+// the value is read. The error return is only non-nil if the Reader was already in a failed state.
+func (r *Reader) ReadArrayEmpty() (bool, error) {
+	return r.readEmptyContainer('[', ']')
+}
+
+// readEmptyContainer implements ReadArrayEmpty and ReadObjectEmpty.
+func (r *Reader) readEmptyContainer(open, closing byte) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	if r.tr.options.lazyRead {
+		node, err := r.tr.structBuffer.CurrentStruct()
+		if err != nil || r.tr.data[node.start] != open || node.subTreeSize != 1 {
+			return false, nil
+		}
+	} else {
+		start := skipRawWhitespace(r.tr.data, r.tr.getPos())
+		if start >= len(r.tr.data) || r.tr.data[start] != open {
+			return false, nil
+		}
+		end := skipRawWhitespace(r.tr.data, start+1)
+		if end >= len(r.tr.data) || r.tr.data[end] != closing {
+			return false, nil
+		}
+	}
+	return true, r.SkipValue()
+}
+
+// StreamArray reads a JSON array and sends the text of each element to out, as a RawMessage, as
+// soon as the element has been parsed. This lets a pipeline stage decode or forward the elements
+// concurrently with the parsing of the rest of the array:
+//
+//	out := make(chan jreader.RawMessage, 100)
+//	go func() {
+//	    defer close(out)
+//	    if err := r.StreamArray(out); err != nil { ... }
+//	}()
+//	for element := range out { ... }
+//
+// Each RawMessage is a copy of the element's text, so it remains valid after the Reader has moved
+// on. StreamArray blocks whenever out is not ready to receive; it never closes out, since the
+// channel belongs to the caller.
+//
+// The return value is the Reader's error, if any, once the end of the array is reached. If there is
+// a parsing error, or the next value is not an array, elements that were parsed before the error
+// have already been sent, and the Reader enters a failed state.
+func (r *Reader) StreamArray(out chan<- RawMessage) error {
+	for arr := r.Array(); arr.Next(); {
+		raw := r.readRawValue()
+		if r.err != nil {
+			break
+		}
+		out <- append(RawMessage(nil), raw...)
+	}
+	return r.err
+}
+
+// SampleArray reads a JSON array and returns the text of a random sample of n of its elements,
+// chosen by reservoir sampling: the array is read once, and at most n elements are held in memory
+// at a time, however long the array is. This is useful for sampling very large arrays, such as
+// batches of telemetry events:
+//
+//	sample, err := r.SampleArray(100, rand.New(rand.NewSource(seed)))
+//
+// Every element has the same chance of being in the sample. If the array has n elements or fewer,
+// the sample has all of them, in the order they appear; otherwise, the order of the sample is not
+// meaningful. Random numbers are taken from rng, or from the default source of math/rand if it is
+// nil. Each RawMessage is a copy of the element's text, so it remains valid after the Reader has
+// moved on. If n is zero or negative, the array is skipped and the sample is an empty non-nil slice.
+//
+// If there is a parsing error, or the next value is not an array, the return value is nil and the
+// Reader enters a failed state; the error is also returned.
+func (r *Reader) SampleArray(n int, rng *rand.Rand) ([]RawMessage, error) {
+	if n < 0 {
+		n = 0
+	}
+	intn := rand.Intn
+	if rng != nil {
+		intn = rng.Intn
+	}
+	sample := make([]RawMessage, 0, n)
+	for arr, i := r.Array(), 0; arr.Next(); i++ {
+		slot := i
+		if i >= n {
+			if slot = intn(i + 1); slot >= n {
+				_ = r.SkipValue()
+				continue
+			}
+		}
+		raw := r.readRawValue()
+		if r.err != nil {
+			break
+		}
+		if slot < len(sample) {
+			sample[slot] = append(sample[slot][:0], raw...)
+		} else {
+			sample = append(sample, append(RawMessage(nil), raw...))
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return sample, nil
+}
+
+// ReadColumns reads a JSON array of objects and pivots it into columns: the result has an entry for
+// each property name that appears in any of the objects, whose value has the text of that property's
+// value in each object, in the order of the array. This is useful for loading data into a
+// column-oriented store:
+//
+//	columns, err := r.ReadColumns() // [{"a":1,"b":2},{"a":3}]
+//	// columns["a"] is [1, 3]; columns["b"] is [2, nil]
+//
+// Every column has one element per object. If an object does not have a property, the element for
+// it in that property's column is nil; if it has the same property more than once, the first value
+// is kept, as in BuildColumns. Property names are decoded, and each RawMessage is a copy of the
+// value's text, so it remains valid after the Reader has moved on. If the array is empty, the
+// result is an empty non-nil map.
+//
+// If there is a parsing error, or the next value is not an array, or an element of the array is not
+// an object, the return value is nil and the Reader enters a failed state; the error is also
+// returned.
+func (r *Reader) ReadColumns() (map[string][]RawMessage, error) {
+	columns := make(map[string][]RawMessage)
+	rows := 0
+	for arr := r.Array(); arr.Next(); rows++ {
+		for obj := r.Object(); obj.Next(); {
+			key := r.mapKey(obj.Name())
+			raw := r.readRawValue()
+			if r.err != nil {
+				break
+			}
+			column := columns[key]
+			if len(column) > rows { // the same property appeared earlier in this object
+				continue
+			}
+			column = append(column, make([]RawMessage, rows-len(column))...)
+			columns[key] = append(column, append(RawMessage(nil), raw...))
+		}
+		if r.err != nil {
+			break
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	for key, column := range columns {
+		columns[key] = append(column, make([]RawMessage, rows-len(column))...)
+	}
+	return columns, nil
+}
+
+// FindInArray reads a JSON array up to the first element for which match returns true. The match
+// function is called for each element, and should read the element with the Reader's methods;
+// if it returns true, FindInArray returns true, leaving the Reader positioned after that element.
+// This avoids parsing the rest of a large array once the element of interest has been found:
+//
+//	var user User
+//	found := r.FindInArray(func(r *jreader.Reader) bool {
+//	    user = readUser(r)
+//	    return user.ID == wantedID
+//	})
+//	if found {
+//	    err := r.Discard()
+//	}
+//
+// After FindInArray returns true, the rest of the array has not been read, so the caller must
+// call Discard to skip it before reading anything that comes after the array. Alternatively, the
+// caller can call FindInArray again, which continues the search from the next element of the
+// same array instead of starting a new one.
+//
+// If no element matches, the whole array is consumed and the return value is false. If there is a
+// parsing error, or the next value is not an array, the return value is false and the Reader enters
+// a failed state. If match causes an error, FindInArray returns false.
+func (r *Reader) FindInArray(match func(*Reader) bool) bool {
+	arr := r.foundInArray
+	r.foundInArray = ArrayState{}
+	if arr.IsDefined() {
+		arr.r = r // in case the Reader has been copied with Clone
+	} else {
+		arr = r.Array()
+	}
+	for arr.Next() {
+		if match(r) && r.err == nil {
+			r.foundInArray = arr
+			return true
+		}
+	}
+	return false
+}
+
+// Discard skips the rest of the array in which FindInArray last found a match, so that the Reader
+// is positioned after the end of the array. If FindInArray has not found a match since the last
+// call to Discard, it does nothing. The return value is the Reader's error, if any.
+func (r *Reader) Discard() error {
+	arr := r.foundInArray
+	r.foundInArray = ArrayState{}
+	if arr.IsDefined() {
+		arr.r = r
+		for arr.Next() {
 		}
-		return !isEnd
 	}
+	return r.err
 }
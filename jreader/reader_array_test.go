@@ -2,6 +2,9 @@ package jreader
 
 import (
 	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -29,3 +32,458 @@ func TestSyntaxErrorStopsArrayParsing(t *testing.T) {
 	require.Equal(t, int64(0), r.Int64())
 	require.Error(t, r.Error())
 }
+
+func TestReadArrayEmpty(t *testing.T) {
+	data := `[[], [ 1 ], [ ], {}, null]`
+
+	check := func(t *testing.T, r *Reader) {
+		arr := r.Array()
+
+		require.True(t, arr.Next())
+		empty, err := r.ReadArrayEmpty()
+		require.NoError(t, err)
+		require.True(t, empty)
+
+		require.True(t, arr.Next())
+		empty, err = r.ReadArrayEmpty()
+		require.NoError(t, err)
+		require.False(t, empty)
+		inner := r.Array()
+		require.True(t, inner.Next())
+		require.Equal(t, int64(1), r.Int64())
+		require.False(t, inner.Next())
+
+		require.True(t, arr.Next())
+		empty, err = r.ReadArrayEmpty()
+		require.NoError(t, err)
+		require.True(t, empty)
+
+		require.True(t, arr.Next())
+		empty, err = r.ReadArrayEmpty()
+		require.NoError(t, err)
+		require.False(t, empty)
+		obj := r.Object()
+		require.False(t, obj.Next())
+
+		require.True(t, arr.Next())
+		empty, err = r.ReadArrayEmpty()
+		require.NoError(t, err)
+		require.False(t, empty)
+		require.NoError(t, r.Null())
+
+		require.False(t, arr.Next())
+		require.NoError(t, r.Error())
+	}
+
+	t.Run("direct mode", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		check(t, &r)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		check(t, &r)
+	})
+
+	t.Run("end of input", func(t *testing.T) {
+		r := NewReader([]byte(` [ `))
+		empty, err := r.ReadArrayEmpty()
+		require.NoError(t, err)
+		require.False(t, empty)
+	})
+
+	t.Run("after error", func(t *testing.T) {
+		myErr := errors.New("sorry")
+		r := NewReader([]byte(`[]`))
+		r.AddError(myErr)
+		empty, err := r.ReadArrayEmpty()
+		require.Equal(t, myErr, err)
+		require.False(t, empty)
+	})
+}
+
+func TestArrayStateAt(t *testing.T) {
+	data := `[["a", "b"], {"c": 1}, "d", [], 5]`
+
+	t.Run("first", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		arr := r.Array()
+		require.True(t, arr.At(0))
+		require.Equal(t, []string{"a", "b"}, readStrings(&r))
+		require.NoError(t, r.Error())
+	})
+
+	t.Run("middle", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		arr := r.Array()
+		require.True(t, arr.At(2))
+		require.Equal(t, "d", string(r.String()))
+		require.True(t, arr.Next())
+		require.True(t, arr.Next())
+		require.Equal(t, int64(5), r.Int64())
+		require.False(t, arr.Next())
+		require.NoError(t, r.Error())
+	})
+
+	t.Run("last", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		arr := r.Array()
+		require.True(t, arr.At(4))
+		require.Equal(t, int64(5), r.Int64())
+		require.False(t, arr.Next())
+		require.NoError(t, r.Error())
+	})
+
+	t.Run("backward while iterating", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		arr := r.Array()
+		require.True(t, arr.Next())
+		require.True(t, arr.Next())
+		require.True(t, arr.Next())
+		require.True(t, arr.At(1))
+		m, err := r.Int64Map()
+		require.NoError(t, err)
+		require.Equal(t, map[string]int64{"c": 1}, m)
+		require.True(t, arr.Next())
+		require.Equal(t, "d", string(r.String()))
+	})
+
+	t.Run("element is skipped if not read", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		arr := r.Array()
+		require.True(t, arr.At(0))
+		require.True(t, arr.Next())
+		require.True(t, arr.Next())
+		require.Equal(t, "d", string(r.String()))
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		arr := r.Array()
+		require.False(t, arr.At(5))
+		require.False(t, arr.At(-1))
+		require.True(t, arr.Next())
+		require.Equal(t, []string{"a", "b"}, readStrings(&r))
+		require.NoError(t, r.Error())
+	})
+
+	t.Run("not in lazy mode", func(t *testing.T) {
+		r := NewReader([]byte(`[1, 2]`))
+		arr := r.Array()
+		require.False(t, arr.At(1))
+		require.IsType(t, StateError{}, r.Error())
+	})
+}
+
+func TestArrayStateSkipN(t *testing.T) {
+	const data = `[["a", "b"], {"c": 1}, "d", [], 5]`
+	modes := []struct {
+		name      string
+		newReader func() Reader
+	}{
+		{"direct", func() Reader { return NewReader([]byte(data)) }},
+		{"lazy", func() Reader { return newLazyReaderForTest(t, data) }},
+	}
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			t.Run("from the start", func(t *testing.T) {
+				r := mode.newReader()
+				arr := r.Array()
+				require.NoError(t, arr.SkipN(2))
+				require.True(t, arr.Next())
+				require.Equal(t, "d", string(r.String()))
+				require.True(t, arr.Next())
+				require.True(t, arr.Next())
+				require.Equal(t, int64(5), r.Int64())
+				require.False(t, arr.Next())
+				require.NoError(t, r.RequireEOF())
+			})
+
+			t.Run("while iterating", func(t *testing.T) {
+				r := mode.newReader()
+				arr := r.Array()
+				require.True(t, arr.Next())
+				require.NoError(t, arr.SkipN(1))
+				require.True(t, arr.Next())
+				require.Equal(t, "d", string(r.String()))
+				require.NoError(t, arr.SkipN(1))
+				require.True(t, arr.Next())
+				require.Equal(t, int64(5), r.Int64())
+			})
+
+			t.Run("zero", func(t *testing.T) {
+				r := mode.newReader()
+				arr := r.Array()
+				require.NoError(t, arr.SkipN(0))
+				require.True(t, arr.Next())
+				require.Equal(t, []string{"a", "b"}, readStrings(&r))
+			})
+
+			t.Run("exactly to the end", func(t *testing.T) {
+				r := mode.newReader()
+				arr := r.Array()
+				require.NoError(t, arr.SkipN(5))
+				require.False(t, arr.Next())
+				require.NoError(t, r.RequireEOF())
+			})
+
+			t.Run("past the end", func(t *testing.T) {
+				r := mode.newReader()
+				arr := r.Array()
+				require.True(t, arr.Next())
+				err := arr.SkipN(5)
+				require.Equal(t, TupleLengthError{Expected: 5, Actual: 4, Offset: len(data) - 1}, err)
+				require.Equal(t, err, r.Error())
+				require.False(t, arr.Next())
+			})
+		})
+	}
+
+	t.Run("empty array", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[]`)
+		arr := r.Array()
+		require.Equal(t, TupleLengthError{Expected: 1, Actual: 0, Offset: 1}, arr.SkipN(1))
+	})
+
+	t.Run("null", func(t *testing.T) {
+		r := NewReader([]byte(`null`))
+		arr := r.ArrayOrNull()
+		require.NoError(t, arr.SkipN(0))
+		require.Equal(t, TupleLengthError{Expected: 1}, arr.SkipN(1))
+	})
+}
+
+func readStrings(r *Reader) []string {
+	var values []string
+	for arr := r.Array(); arr.Next(); {
+		values = append(values, string(r.String()))
+	}
+	return values
+}
+
+func TestFindInArray(t *testing.T) {
+	const data = `{"items": [{"id": 1}, {"id": 2, "x": [3]}, {"id": 3}, {"id": 2}], "after": true}`
+	modes := []struct {
+		name      string
+		newReader func() Reader
+	}{
+		{"direct", func() Reader { return NewReader([]byte(data)) }},
+		{"lazy", func() Reader { return newLazyReaderForTest(t, data) }},
+	}
+	readID := func(r *Reader) int64 {
+		var id int64
+		for obj := r.Object(); obj.Next(); {
+			if string(obj.Name()) == "id" {
+				id = r.Int64()
+			}
+		}
+		return id
+	}
+
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			t.Run("found in the middle", func(t *testing.T) {
+				r := mode.newReader()
+				obj := r.Object()
+				require.True(t, obj.Next())
+				var visited []int64
+				found := r.FindInArray(func(r *Reader) bool {
+					id := readID(r)
+					visited = append(visited, id)
+					return id == 2
+				})
+				require.True(t, found)
+				require.Equal(t, []int64{1, 2}, visited)
+				require.NoError(t, r.Discard())
+				require.True(t, obj.Next())
+				require.Equal(t, "after", string(obj.Name()))
+				require.True(t, r.Bool())
+				require.False(t, obj.Next())
+				require.NoError(t, r.RequireEOF())
+			})
+
+			t.Run("continue searching", func(t *testing.T) {
+				r := mode.newReader()
+				obj := r.Object()
+				require.True(t, obj.Next())
+				var visited []int64
+				match := func(r *Reader) bool {
+					id := readID(r)
+					visited = append(visited, id)
+					return id == 2
+				}
+				require.True(t, r.FindInArray(match))
+				require.True(t, r.FindInArray(match))
+				require.False(t, r.FindInArray(match))
+				require.Equal(t, []int64{1, 2, 3, 2}, visited)
+				require.NoError(t, r.Discard())
+				require.True(t, obj.Next())
+				require.Equal(t, "after", string(obj.Name()))
+			})
+
+			t.Run("not found", func(t *testing.T) {
+				r := mode.newReader()
+				obj := r.Object()
+				require.True(t, obj.Next())
+				require.False(t, r.FindInArray(func(r *Reader) bool { return readID(r) == 5 }))
+				require.NoError(t, r.Discard())
+				require.True(t, obj.Next())
+				require.Equal(t, "after", string(obj.Name()))
+			})
+		})
+	}
+
+	t.Run("element not read by match function", func(t *testing.T) {
+		r := NewReader([]byte(`[1, 2, 3] 4`))
+		calls := 0
+		require.True(t, r.FindInArray(func(r *Reader) bool {
+			calls++
+			return calls == 2
+		}))
+		require.NoError(t, r.Discard())
+		require.Equal(t, int64(4), r.Int64())
+	})
+
+	t.Run("error in match function", func(t *testing.T) {
+		r := NewReader([]byte(`["x", 1]`))
+		require.False(t, r.FindInArray(func(r *Reader) bool {
+			_ = r.Int64()
+			return true
+		}))
+		require.IsType(t, TypeError{}, r.Error())
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		require.False(t, r.FindInArray(func(r *Reader) bool { return true }))
+		require.IsType(t, TypeError{}, r.Error())
+		require.Equal(t, r.Error(), r.Discard())
+	})
+}
+
+func TestReadColumns(t *testing.T) {
+	data := `[{"a":1,"b":"x"}, {"b":[true],"c":{"d":null}}, {}, {"a":2,"a":3,"c!":4}]`
+	expected := map[string][]RawMessage{
+		"a":  {RawMessage(`1`), nil, nil, RawMessage(`2`)},
+		"b":  {RawMessage(`"x"`), RawMessage(`[true]`), nil, nil},
+		"c":  {nil, RawMessage(`{"d":null}`), nil, nil},
+		"c!": {nil, nil, nil, RawMessage(`4`)},
+	}
+
+	t.Run("direct mode", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		columns, err := r.ReadColumns()
+		require.NoError(t, err)
+		require.Equal(t, expected, columns)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		columns, err := r.ReadColumns()
+		require.NoError(t, err)
+		require.Equal(t, expected, columns)
+	})
+
+	t.Run("duplicate property keeps the first value", func(t *testing.T) {
+		r := NewReader([]byte(`[{"a":1,"\u0061":2,"b":3,"a":4}, {"a":5}]`))
+		columns, err := r.ReadColumns()
+		require.NoError(t, err)
+		require.Equal(t, map[string][]RawMessage{
+			"a": {RawMessage(`1`), RawMessage(`5`)},
+			"b": {RawMessage(`3`), nil},
+		}, columns)
+	})
+
+	t.Run("empty array", func(t *testing.T) {
+		r := NewReader([]byte(`[]`))
+		columns, err := r.ReadColumns()
+		require.NoError(t, err)
+		require.Equal(t, map[string][]RawMessage{}, columns)
+	})
+
+	t.Run("element is not an object", func(t *testing.T) {
+		r := NewReader([]byte(`[{"a":1}, 2]`))
+		columns, err := r.ReadColumns()
+		require.IsType(t, TypeError{}, err)
+		require.Nil(t, columns)
+		require.Equal(t, err, r.Error())
+	})
+}
+
+func TestSampleArray(t *testing.T) {
+	makeArray := func(n int) string {
+		elements := make([]string, n)
+		for i := range elements {
+			elements[i] = fmt.Sprintf(`{"i":%d}`, i)
+		}
+		return "[" + strings.Join(elements, ",") + "]"
+	}
+
+	t.Run("sample is smaller than the array", func(t *testing.T) {
+		for _, lazy := range []bool{false, true} {
+			data := makeArray(1000)
+			r := NewReader([]byte(data))
+			if lazy {
+				r = newLazyReaderForTest(t, data)
+			}
+			sample, err := r.SampleArray(10, rand.New(rand.NewSource(1)))
+			require.NoError(t, err)
+			require.Len(t, sample, 10)
+			seen := make(map[string]bool)
+			for _, element := range sample {
+				require.Regexp(t, `^\{"i":\d+\}$`, string(element))
+				require.False(t, seen[string(element)], "duplicate element %s", element)
+				seen[string(element)] = true
+			}
+			require.NoError(t, r.RequireEOF())
+		}
+	})
+
+	t.Run("every element can be chosen", func(t *testing.T) {
+		data := []byte(makeArray(20))
+		rng := rand.New(rand.NewSource(2))
+		chosen := make(map[string]bool)
+		for i := 0; i < 200; i++ {
+			r := NewReader(data)
+			sample, err := r.SampleArray(3, rng)
+			require.NoError(t, err)
+			require.Len(t, sample, 3)
+			for _, element := range sample {
+				chosen[string(element)] = true
+			}
+		}
+		require.Len(t, chosen, 20)
+	})
+
+	t.Run("sample covers the whole array", func(t *testing.T) {
+		for _, n := range []int{5, 6, 100} {
+			r := NewReader([]byte(`[1, "a", [2], {"b":3}, null]`))
+			sample, err := r.SampleArray(n, nil)
+			require.NoError(t, err)
+			require.Equal(t, []RawMessage{RawMessage(`1`), RawMessage(`"a"`), RawMessage(`[2]`),
+				RawMessage(`{"b":3}`), RawMessage(`null`)}, sample)
+		}
+	})
+
+	t.Run("empty sample", func(t *testing.T) {
+		for _, input := range []string{`[]`, `[1, 2]`} {
+			r := NewReader([]byte(input))
+			sample, err := r.SampleArray(0, nil)
+			require.NoError(t, err)
+			require.Equal(t, []RawMessage{}, sample)
+			require.NoError(t, r.RequireEOF())
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, input := range []string{`{}`, `[1, 2,]`} {
+			r := NewReader([]byte(input))
+			sample, err := r.SampleArray(1, nil)
+			require.Error(t, err)
+			require.Nil(t, sample)
+			require.Equal(t, err, r.Error())
+		}
+	})
+}
@@ -2,6 +2,7 @@ package jreader
 
 import (
 	"bytes"
+	"strconv"
 	"testing"
 
 	"github.com/Brat-vseznamus/go-jsonstream/v3/internal/commontest"
@@ -176,3 +177,201 @@ func failBenchmarkOnReaderError(b *testing.B, r *Reader) {
 		b.FailNow()
 	}
 }
+
+var benchmarkWideObjectFieldNames = []string{ //nolint:gochecknoglobals
+	"id", "name", "email", "created", "updated", "active", "score", "rank", "country", "city",
+	"street", "zip", "phone", "company", "title", "department", "manager", "salary", "currency",
+	"language", "timezone", "locale", "avatar", "bio", "website", "twitter", "github", "tags",
+	"notes", "version",
+}
+
+func makeWideObjectJSON() []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range benchmarkWideObjectFieldNames {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`"` + name + `":1`)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+func BenchmarkReadWideObjectWithSwitch(b *testing.B) {
+	data := makeWideObjectJSON()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var fields [30]int64
+		r := NewReader(data)
+		for obj := r.Object(); obj.Next(); {
+			switch string(obj.Name()) {
+			case "id":
+				fields[0] = r.Int64()
+			case "name":
+				fields[1] = r.Int64()
+			case "email":
+				fields[2] = r.Int64()
+			case "created":
+				fields[3] = r.Int64()
+			case "updated":
+				fields[4] = r.Int64()
+			case "active":
+				fields[5] = r.Int64()
+			case "score":
+				fields[6] = r.Int64()
+			case "rank":
+				fields[7] = r.Int64()
+			case "country":
+				fields[8] = r.Int64()
+			case "city":
+				fields[9] = r.Int64()
+			case "street":
+				fields[10] = r.Int64()
+			case "zip":
+				fields[11] = r.Int64()
+			case "phone":
+				fields[12] = r.Int64()
+			case "company":
+				fields[13] = r.Int64()
+			case "title":
+				fields[14] = r.Int64()
+			case "department":
+				fields[15] = r.Int64()
+			case "manager":
+				fields[16] = r.Int64()
+			case "salary":
+				fields[17] = r.Int64()
+			case "currency":
+				fields[18] = r.Int64()
+			case "language":
+				fields[19] = r.Int64()
+			case "timezone":
+				fields[20] = r.Int64()
+			case "locale":
+				fields[21] = r.Int64()
+			case "avatar":
+				fields[22] = r.Int64()
+			case "bio":
+				fields[23] = r.Int64()
+			case "website":
+				fields[24] = r.Int64()
+			case "twitter":
+				fields[25] = r.Int64()
+			case "github":
+				fields[26] = r.Int64()
+			case "tags":
+				fields[27] = r.Int64()
+			case "notes":
+				fields[28] = r.Int64()
+			case "version":
+				fields[29] = r.Int64()
+			}
+		}
+		failBenchmarkOnReaderError(b, &r)
+		if fields[29] != 1 {
+			b.FailNow()
+		}
+	}
+}
+
+func BenchmarkReadWideObjectWithFieldMatcher(b *testing.B) {
+	data := makeWideObjectJSON()
+	m := NewFieldMatcher(benchmarkWideObjectFieldNames)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var fields [30]int64
+		r := NewReader(data)
+		for obj := r.Object(); ; {
+			index, ok := obj.NextMatch(m)
+			if !ok {
+				break
+			}
+			if index >= 0 {
+				fields[index] = r.Int64()
+			}
+		}
+		failBenchmarkOnReaderError(b, &r)
+		if fields[29] != 1 {
+			b.FailNow()
+		}
+	}
+}
+
+func makeMatrixJSON(rows, columns int) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('[')
+		for j := 0; j < columns; j++ {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(strconv.FormatFloat(float64(i*columns+j)/8, 'f', -1, 64))
+		}
+		buf.WriteByte(']')
+	}
+	buf.WriteByte(']')
+	return buf.Bytes()
+}
+
+func BenchmarkReadFloat64MatrixWithNestedLoop(b *testing.B) {
+	data := makeMatrixJSON(1000, 128)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := NewReader(data)
+		var rows [][]float64
+		for arr := r.Array(); arr.Next(); {
+			var row []float64
+			for rowArr := r.Array(); rowArr.Next(); {
+				row = append(row, r.Float64())
+			}
+			rows = append(rows, row)
+		}
+		failBenchmarkOnReaderError(b, &r)
+		if len(rows) != 1000 {
+			b.FailNow()
+		}
+	}
+}
+
+func BenchmarkReadFloat64Matrix(b *testing.B) {
+	data := makeMatrixJSON(1000, 128)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := NewReader(data)
+		rows, err := r.Float64Matrix(true)
+		if err != nil || len(rows) != 1000 {
+			b.FailNow()
+		}
+	}
+}
+
+var benchmarkEscapedStringsJSON = []byte(`["plain", "tab\there", "quote\"d", "café", "line\nbreak"]`) //nolint:gochecknoglobals
+
+func BenchmarkReadStringsCopied(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r := NewReader(benchmarkEscapedStringsJSON)
+		for arr := r.Array(); arr.Next(); {
+			val := r.String()
+			_ = append([]byte(nil), r.decodedString(nil, val)...)
+		}
+		failBenchmarkOnReaderError(b, &r)
+	}
+}
+
+func BenchmarkReadStringsUsingBuffer(b *testing.B) {
+	b.ReportAllocs()
+	buf := make([]byte, 0, 64)
+	for i := 0; i < b.N; i++ {
+		r := NewReader(benchmarkEscapedStringsJSON)
+		for arr := r.Array(); arr.Next(); {
+			buf, _ = r.StringUsing(buf)
+		}
+		failBenchmarkOnReaderError(b, &r)
+	}
+}
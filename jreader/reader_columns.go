@@ -0,0 +1,124 @@
+package jreader
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ColumnBuilder receives the values of one column from Reader.BuildColumns. It is meant to be
+// implemented by a thin wrapper around a builder for a column-oriented in-memory format, such as an
+// Apache Arrow array builder.
+//
+// BuildColumns calls the method that matches the JSON type of each value, so a builder for a column
+// of a single type can convert or reject values of the other types as it sees fit: for instance, a
+// builder for a float64 column would implement AppendInt64 by converting the value to a float64.
+// Every call appends exactly one element to the column.
+type ColumnBuilder interface {
+	// AppendNull appends a null, for a JSON null or for a row that does not have the property.
+	AppendNull()
+
+	// AppendBool appends a JSON boolean.
+	AppendBool(value bool)
+
+	// AppendInt64 appends a JSON number that is integral and fits in an int64; see Reader.Numberish.
+	AppendInt64(value int64)
+
+	// AppendFloat64 appends any other JSON number.
+	AppendFloat64(value float64)
+
+	// AppendString appends a JSON string, with its escape sequences decoded. It is also called with
+	// the compact JSON text of a nested array or object, for a row that is not flat.
+	AppendString(value string)
+}
+
+// BuildColumns reads an array of flat objects, each of which is a row, and appends the value of each
+// property that is in schema to the ColumnBuilder for that property name. This is the glue for
+// loading JSON rows into a column-oriented format without going through an intermediate
+// representation:
+//
+//	// [{"id":1,"name":"a"},{"id":2}]
+//	err := r.BuildColumns(map[string]jreader.ColumnBuilder{"id": ids, "name": names})
+//	// ids got AppendInt64(1), AppendInt64(2); names got AppendString("a"), AppendNull()
+//
+// To keep the columns aligned, each builder gets exactly one value per row: AppendNull for a row
+// that does not have the property, and only the first value for a row that has it more than once,
+// as in ReadColumns. Properties that are not in schema are skipped. Property names are compared
+// after decoding any escape sequences in them.
+//
+// If there is a parsing error, or the next value is not an array, or an element of the array is not
+// an object, the Reader enters a failed state and the error is returned. The builders may then have
+// received part of the values of the row that caused the error.
+func (r *Reader) BuildColumns(schema map[string]ColumnBuilder) error {
+	index := make(map[string]int, len(schema))
+	builders := make([]ColumnBuilder, 0, len(schema))
+	for name, b := range schema {
+		index[name] = len(builders)
+		builders = append(builders, b)
+	}
+	lastRow := make([]int, len(builders)) // the last row that each column got a value for, plus 1
+	var scratch []byte
+	row := 0
+	for arr := r.Array(); arr.Next(); {
+		row++
+		for obj := r.Object(); obj.Next(); {
+			var name []byte
+			name, scratch = decodeName(scratch, obj.Name())
+			i, ok := index[string(name)]
+			if !ok || lastRow[i] == row {
+				_ = r.SkipValue()
+				continue
+			}
+			lastRow[i] = row
+			scratch = r.appendColumnValue(builders[i], scratch[:0])
+		}
+		if r.err != nil {
+			return r.err
+		}
+		for i, b := range builders {
+			if lastRow[i] != row {
+				b.AppendNull()
+			}
+		}
+	}
+	return r.err
+}
+
+// appendColumnValue reads the next value and appends it to b. The scratch buffer, which can be
+// reused for the next value, is returned.
+func (r *Reader) appendColumnValue(b ColumnBuilder, scratch []byte) []byte {
+	if kind, _, _ := r.peekKind(); kind == ArrayValue || kind == ObjectValue {
+		raw := r.readRawValue()
+		if r.err != nil {
+			return scratch
+		}
+		buf := bytes.NewBuffer(scratch)
+		if err := json.Compact(buf, raw); err != nil {
+			b.AppendString(string(raw))
+		} else {
+			b.AppendString(buf.String())
+		}
+		return buf.Bytes()
+	}
+	v := r.Any()
+	switch v.Kind {
+	case NullValue:
+		if r.err == nil {
+			b.AppendNull()
+		}
+	case BoolValue:
+		b.AppendBool(v.Bool)
+	case NumberValue:
+		intVal, floatVal, isInt, err := r.numberishValue(&v.Number)
+		switch {
+		case err != nil:
+			r.err = err
+		case isInt:
+			b.AppendInt64(intVal)
+		default:
+			b.AppendFloat64(floatVal)
+		}
+	case StringValue:
+		b.AppendString(string(r.decodedString(scratch, v.String)))
+	}
+	return scratch
+}
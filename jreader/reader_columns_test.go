@@ -0,0 +1,92 @@
+package jreader
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingColumn is a ColumnBuilder that records a description of each value it is given.
+type recordingColumn []string
+
+func (c *recordingColumn) AppendNull()             { *c = append(*c, "null") }
+func (c *recordingColumn) AppendBool(value bool)   { *c = append(*c, fmt.Sprintf("bool %t", value)) }
+func (c *recordingColumn) AppendInt64(value int64) { *c = append(*c, fmt.Sprintf("int %d", value)) }
+func (c *recordingColumn) AppendFloat64(value float64) {
+	*c = append(*c, fmt.Sprintf("float %g", value))
+}
+func (c *recordingColumn) AppendString(value string) {
+	*c = append(*c, fmt.Sprintf("string %q", value))
+}
+
+func TestBuildColumns(t *testing.T) {
+	data := `[
+		{"id": 1, "name": "a", "score": 1.5, "ok": true},
+		{"id": 2, "extra": [1, {"x": 2}]},
+		{"n\u0061me": "b\tc", "score": 2, "id": 3, "id": 4},
+		{},
+		{"id": null, "name": {"nested": [1, 2]}, "ok": false}
+	]`
+	expected := map[string]recordingColumn{
+		"id":    {"int 1", "int 2", "int 3", "null", "null"},
+		"name":  {`string "a"`, "null", `string "b\tc"`, "null", `string "{\"nested\":[1,2]}"`},
+		"score": {"float 1.5", "null", "int 2", "null", "null"},
+		"ok":    {"bool true", "null", "null", "null", "bool false"},
+	}
+
+	check := func(t *testing.T, r *Reader) {
+		columns := map[string]*recordingColumn{}
+		schema := map[string]ColumnBuilder{}
+		for name := range expected {
+			columns[name] = &recordingColumn{}
+			schema[name] = columns[name]
+		}
+		require.NoError(t, r.BuildColumns(schema))
+		for name, values := range expected {
+			assert.Equal(t, values, *columns[name], name)
+		}
+	}
+
+	t.Run("direct mode", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		check(t, &r)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		check(t, &r)
+	})
+
+	t.Run("duplicate property keeps the first value", func(t *testing.T) {
+		r := NewReader([]byte(`[{"a":1,"\u0061":2,"b":3,"a":4}, {"a":5}]`))
+		a, b := &recordingColumn{}, &recordingColumn{}
+		require.NoError(t, r.BuildColumns(map[string]ColumnBuilder{"a": a, "b": b}))
+		assert.Equal(t, recordingColumn{"int 1", "int 5"}, *a)
+		assert.Equal(t, recordingColumn{"int 3", "null"}, *b)
+	})
+
+	t.Run("empty array", func(t *testing.T) {
+		r := NewReader([]byte(`[]`))
+		var c recordingColumn
+		require.NoError(t, r.BuildColumns(map[string]ColumnBuilder{"a": &c}))
+		assert.Len(t, c, 0)
+	})
+
+	t.Run("row is not an object", func(t *testing.T) {
+		r := NewReader([]byte(`[{"a":1}, 2]`))
+		var c recordingColumn
+		err := r.BuildColumns(map[string]ColumnBuilder{"a": &c})
+		require.IsType(t, TypeError{}, err)
+		assert.Equal(t, err, r.Error())
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		r := NewReader([]byte(`[{"a":1}, {"a":]`))
+		var c recordingColumn
+		err := r.BuildColumns(map[string]ColumnBuilder{"a": &c})
+		require.IsType(t, SyntaxError{}, err)
+	})
+}
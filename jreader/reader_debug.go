@@ -0,0 +1,109 @@
+package jreader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// debugNodeCount is the number of upcoming tree nodes that Reader.DebugString describes.
+const debugNodeCount = 3
+
+// debugValueLen is the maximum number of bytes of a scalar value's text that DumpTree prints.
+const debugValueLen = 24
+
+// DebugString returns a one-line summary of the Reader's internal state, for diagnosing problems
+// such as a lazy-mode read that returns the wrong value: the mode flags, the position in the input,
+// the position in the pre-processed tree, the error if any, and the next few nodes of the tree.
+//
+// The format is meant for people and may change in any release; do not parse it.
+func (r *Reader) DebugString() string {
+	var b strings.Builder
+	opts := r.tr.options
+	fmt.Fprintf(&b, "lazyParse=%t lazyRead=%t computeString=%t computeNumber=%t readRawNumbers=%t",
+		opts.lazyParse, opts.lazyRead, opts.computeString, opts.computeNumber, opts.readRawNumbers)
+	fmt.Fprintf(&b, " pos=%d len=%d lastPos=%d", r.tr.pos, r.tr.len, r.tr.lastPos)
+	if r.tr.hasUnread {
+		b.WriteString(" hasUnread")
+	}
+	if r.awaitingReadValue {
+		b.WriteString(" awaitingValue")
+	}
+	if opts.lazyRead && r.tr.structBuffer.values != nil {
+		tree := *r.tr.structBuffer.values
+		pos := r.tr.structBuffer.pos
+		fmt.Fprintf(&b, " node=%d/%d", pos, len(tree))
+		for i := pos; i < len(tree) && i < pos+debugNodeCount; i++ {
+			b.WriteString(" [")
+			writeNodeSummary(&b, tree[i], r.tr.data)
+			b.WriteString("]")
+		}
+	}
+	if r.err != nil {
+		fmt.Fprintf(&b, " err=%q", r.err.Error())
+	}
+	return b.String()
+}
+
+// DumpTree writes an indented outline of a tree that was built by Reader.PreProcess for data, one
+// node per line, showing each value's property name if any, its kind, its span in data, and the
+// size of its subtree. The text of short scalar values is shown too.
+//
+// This is meant for diagnosing problems with the tree, and for tests that compare a tree to an
+// expected outline; the format may change in any release.
+func DumpTree(w io.Writer, tree []JsonTreeStruct, data []byte) error {
+	return dumpTree(w, tree, data, -1)
+}
+
+// DumpTree writes an outline of the tree that the pointer moves through, as described for the
+// DumpTree function, marking the node that it currently points to with ">".
+func (jPointer *JsonStructPointer) DumpTree(w io.Writer, data []byte) error {
+	if jPointer.values == nil {
+		return nil
+	}
+	return dumpTree(w, *jPointer.values, data, jPointer.pos)
+}
+
+func dumpTree(w io.Writer, tree []JsonTreeStruct, data []byte, current int) error {
+	bw := bufio.NewWriter(w)
+	var ends []int // the end index of each array or object that contains the current node
+	for i, node := range tree {
+		for len(ends) > 0 && i >= ends[len(ends)-1] {
+			ends = ends[:len(ends)-1]
+		}
+		if i == current {
+			bw.WriteString("> ")
+		} else {
+			bw.WriteString("  ")
+		}
+		for range ends {
+			bw.WriteString("  ")
+		}
+		writeNodeSummary(bw, node, data)
+		bw.WriteByte('\n')
+		if node.subTreeSize > 1 {
+			ends = append(ends, i+node.subTreeSize)
+		}
+	}
+	return bw.Flush()
+}
+
+func writeNodeSummary(w io.Writer, node JsonTreeStruct, data []byte) {
+	if node.key != nil {
+		fmt.Fprintf(w, "%q: ", node.key)
+	}
+	fmt.Fprintf(w, "%s [%d,%d) size=%d", node.kind, node.start, node.end, node.subTreeSize)
+	if node.kind == ArrayValue || node.kind == ObjectValue {
+		return
+	}
+	if node.start < 0 || node.end > len(data) || node.start > node.end {
+		return
+	}
+	text := data[node.start:node.end]
+	if len(text) > debugValueLen {
+		fmt.Fprintf(w, " %s...", text[:debugValueLen])
+	} else {
+		fmt.Fprintf(w, " %s", text)
+	}
+}
@@ -0,0 +1,76 @@
+package jreader
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata instead of comparing against them")
+
+func TestDumpTreeGolden(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "tree", "fixture.json"))
+	require.NoError(t, err)
+	r := newLazyReaderForTest(t, string(data))
+
+	var buf bytes.Buffer
+	require.NoError(t, DumpTree(&buf, *r.tr.structBuffer.values, data))
+
+	goldenPath := filepath.Join("testdata", "tree", "fixture.golden")
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(goldenPath, buf.Bytes(), 0o644))
+	}
+	expected, err := os.ReadFile(goldenPath)
+	require.NoError(t, err, "run the test with -update to create the golden file")
+	assert.Equal(t, string(expected), buf.String())
+}
+
+func TestStructPointerDumpTreeMarksCurrentNode(t *testing.T) {
+	data := `[1, [2]]`
+	r := newLazyReaderForTest(t, data)
+	arr := r.Array()
+	require.True(t, arr.Next())
+	r.Int64()
+
+	var buf bytes.Buffer
+	require.NoError(t, r.tr.structBuffer.DumpTree(&buf, []byte(data)))
+	assert.Equal(t, ""+
+		"  array [0,8) size=4\n"+
+		"    number [1,2) size=1 1\n"+
+		">   array [4,7) size=2\n"+
+		"      number [5,6) size=1 2\n",
+		buf.String())
+}
+
+func TestReaderDebugString(t *testing.T) {
+	t.Run("direct", func(t *testing.T) {
+		r := NewReader([]byte(`[1, true]`))
+		r.Array()
+		s := r.DebugString()
+		assert.Contains(t, s, "lazyRead=false")
+		assert.Contains(t, s, "pos=1")
+		assert.NotContains(t, s, "node=")
+		assert.NotContains(t, s, "err=")
+	})
+
+	t.Run("lazy", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"a": 1, "b": [true]}`)
+		s := r.DebugString()
+		assert.Contains(t, s, "lazyRead=true")
+		assert.Contains(t, s, "node=0/4 [object [0,21) size=4]")
+		assert.Contains(t, s, `["a": number [6,7) size=1 1] ["b": array [14,20) size=2]`)
+		assert.NotContains(t, s, "boolean")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		r := NewReader([]byte(`true`))
+		r.AddError(errors.New("sample problem"))
+		assert.Contains(t, r.DebugString(), `err="sample problem"`)
+	})
+}
@@ -0,0 +1,102 @@
+package jreader
+
+// Values of DiffEntry.Op.
+const (
+	// DiffAdd means that a property is in the object that was read, but not in the base object.
+	DiffAdd = "add"
+
+	// DiffRemove means that a property is in the base object, but not in the object that was read.
+	DiffRemove = "remove"
+
+	// DiffChange means that a property is in both objects, with values that are not equal.
+	DiffChange = "change"
+)
+
+// DiffEntry describes one difference between two JSON objects, as returned by
+// Reader.ReadObjectDiff.
+type DiffEntry struct {
+	// Op is DiffAdd, DiffRemove, or DiffChange.
+	Op string
+
+	// Key is the property name, with any escape sequences decoded.
+	Key string
+
+	// OldValue is the property value in the base object, or nil for DiffAdd.
+	OldValue *AnyValue
+
+	// NewValue is the property value in the object that was read, or nil for DiffRemove.
+	NewValue *AnyValue
+}
+
+// ReadObjectDiff reads a JSON object and compares it to the JSON object in base, returning a
+// DiffEntry for each property that was added, removed, or changed relative to base. This is useful
+// for auditing or for building a minimal update from two versions of a document:
+//
+//	changes, err := r.ReadObjectDiff(previousVersion)
+//	for _, c := range changes {
+//	    log.Printf("%s %s", c.Op, c.Key)
+//	}
+//
+// Only the first level of properties is compared: if a property value is an array or object that
+// differs in any way, the whole value is reported as a DiffChange. Values are compared in the same
+// way as by AnyValue.Equal, so, for instance, 1.0 and 1 are not a change, and if a property name
+// appears more than once in an object, only its last value counts. The OldValue and NewValue fields
+// are in the same form as the result of ReadAllAny, and do not share any memory with either input.
+//
+// Added and changed properties are listed first, in the order they appear in the object that was
+// read, followed by removed properties in the order they appear in base. If there are no
+// differences, the result is an empty non-nil slice. Both objects are read in full before they are
+// compared, so the Reader does not need to be in lazy mode, although it can be.
+//
+// If base is not well-formed JSON, or is not a single object, its parsing error is returned and
+// nothing is consumed from the Reader. If there is a parsing error in the Reader's input, or the next
+// value is not an object, the return value is nil and the Reader enters a failed state; the error is
+// also returned.
+func (r *Reader) ReadObjectDiff(base []byte) ([]DiffEntry, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	br := NewReader(base)
+	baseValue := br.readAnyObjectTree()
+	if br.err == nil {
+		br.err = br.RequireEOF()
+	}
+	if br.err != nil {
+		return nil, br.err
+	}
+	value := r.readAnyObjectTree()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	oldProps, newProps := lastPropertyValues(baseValue.Properties), lastPropertyValues(value.Properties)
+	diff := []DiffEntry{}
+	for _, p := range value.Properties {
+		key := string(p.Name)
+		if newProps[key] != p.Value {
+			continue // an earlier occurrence of a property that appears more than once
+		}
+		if oldValue, ok := oldProps[key]; !ok {
+			diff = append(diff, DiffEntry{Op: DiffAdd, Key: key, NewValue: p.Value})
+		} else if !oldValue.Equal(p.Value) {
+			diff = append(diff, DiffEntry{Op: DiffChange, Key: key, OldValue: oldValue, NewValue: p.Value})
+		}
+	}
+	for _, p := range baseValue.Properties {
+		key := string(p.Name)
+		if _, ok := newProps[key]; ok || oldProps[key] != p.Value {
+			continue
+		}
+		diff = append(diff, DiffEntry{Op: DiffRemove, Key: key, OldValue: p.Value})
+	}
+	return diff, nil
+}
+
+// readAnyObjectTree is the same as readAnyTree, except that the value must be an object.
+func (r *Reader) readAnyObjectTree() *AnyValue {
+	if kind, pos, ok := r.peekKind(); ok && kind != ObjectValue {
+		r.err = TypeError{Expected: ObjectValue, Actual: kind, Offset: pos}
+		return nil
+	}
+	return r.readAnyTree()
+}
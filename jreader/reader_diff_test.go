@@ -0,0 +1,85 @@
+package jreader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadObjectDiff(t *testing.T) {
+	base := []byte(`{"same": 1, "changed": "a", "nested": {"x": [1, 2]}, "removed": true, "dup": 1, "dup": 2}`)
+
+	summarize := func(diff []DiffEntry) []string {
+		var result []string
+		for _, d := range diff {
+			s := d.Op + " " + d.Key
+			if d.OldValue != nil {
+				s += " old=" + d.OldValue.Kind.String()
+			}
+			if d.NewValue != nil {
+				s += " new=" + d.NewValue.Kind.String()
+			}
+			result = append(result, s)
+		}
+		return result
+	}
+
+	for _, lazy := range []bool{false, true} {
+		t.Run(map[bool]string{false: "streaming", true: "lazy"}[lazy], func(t *testing.T) {
+			data := `{"added": null, "nested": {"x": [1, 3]}, "same": 1.0, "changed": "b", "dup": 2}`
+			r := NewReader([]byte(data))
+			if lazy {
+				r = newLazyReaderForTest(t, data)
+			}
+			diff, err := r.ReadObjectDiff(base)
+			require.NoError(t, err)
+			assert.Equal(t, []string{
+				"add added new=null",
+				"change nested old=object new=object",
+				"change changed old=string new=string",
+				"remove removed old=boolean",
+			}, summarize(diff))
+			assert.Equal(t, "a", string(diff[2].OldValue.String))
+			assert.Equal(t, "b", string(diff[2].NewValue.String))
+			assert.Len(t, diff[1].NewValue.Properties[0].Value.Elements, 2)
+		})
+	}
+
+	t.Run("no differences", func(t *testing.T) {
+		r := NewReader([]byte(`{"b": [true], "a": "x"}`))
+		diff, err := r.ReadObjectDiff([]byte(` {"a": "x", "b": [true]} `))
+		require.NoError(t, err)
+		assert.Equal(t, []DiffEntry{}, diff)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("duplicate property in new object", func(t *testing.T) {
+		r := NewReader([]byte(`{"a": 1, "b": 2, "a": 3}`))
+		diff, err := r.ReadObjectDiff([]byte(`{"a": 1, "b": 2}`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"change a old=number new=number"}, summarize(diff))
+	})
+
+	t.Run("bad base", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		_, err := r.ReadObjectDiff([]byte(`[1]`))
+		require.IsType(t, TypeError{}, err)
+		_, err = r.ReadObjectDiff([]byte(`{"a":}`))
+		require.IsType(t, SyntaxError{}, err)
+		_, err = r.ReadObjectDiff([]byte(`{} {}`))
+		require.IsType(t, SyntaxError{}, err)
+		require.NoError(t, r.Error())
+		diff, err := r.ReadObjectDiff([]byte(`{}`))
+		require.NoError(t, err)
+		assert.Empty(t, diff)
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		r := NewReader([]byte(`[{}]`))
+		diff, err := r.ReadObjectDiff(base)
+		require.Equal(t, TypeError{Expected: ObjectValue, Actual: ArrayValue, Offset: 0}, err)
+		assert.Nil(t, diff)
+		assert.Equal(t, err, r.Error())
+	})
+}
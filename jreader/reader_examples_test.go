@@ -226,3 +226,28 @@ func ExampleReader_Any() {
 	// Output: a number: 123
 	// an array with 2 elements
 }
+
+func ExampleOpt() {
+	// An optional boolean property that may be absent, null, or a value. Without Opt, telling these
+	// apart needs an interface{} or pointer field and a call to BoolOrNull.
+	r := NewReader([]byte(`[{"flag": true}, {"flag": null}, {}]`))
+	for arr := r.Array(); arr.Next(); {
+		var flag Opt[bool]
+		for obj := r.Object(); obj.Next(); {
+			if string(obj.Name()) == "flag" {
+				flag.ReadFromJSONReader(&r)
+			}
+		}
+		switch {
+		case !flag.Set:
+			fmt.Println("absent")
+		case flag.Null:
+			fmt.Println("null")
+		default:
+			fmt.Println("value:", flag.Value)
+		}
+	}
+	// Output: value: true
+	// null
+	// absent
+}
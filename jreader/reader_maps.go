@@ -0,0 +1,540 @@
+package jreader
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// StringMap reads a JSON object whose property values are all strings, returning it as a map. If
+// the same property name appears more than once, the last value wins.
+//
+// This is equivalent to iterating over an ObjectState and calling String for each property, but
+// avoids the per-property overhead of a general-purpose callback. In lazy mode, the map is
+// preallocated with the number of properties in the object.
+//
+// If there is a parsing error, or the next value is not an object, or one of the property values
+// is not a string, the return value is nil and the Reader enters a failed state; the error is also
+// returned. For a property value of the wrong type, the error is a TypeError whose Property field
+// is the property name.
+func (r *Reader) StringMap() (map[string]string, error) {
+	return r.stringMap(false)
+}
+
+// StringMapOrNull is the same as StringMap, except that it also accepts a null, in which case it
+// returns a nil map and a nil error. An empty JSON object produces an empty non-nil map.
+func (r *Reader) StringMapOrNull() (map[string]string, error) {
+	return r.stringMap(true)
+}
+
+// Int64Map reads a JSON object whose property values are all integers, returning it as a map.
+// It follows the same rules as StringMap.
+func (r *Reader) Int64Map() (map[string]int64, error) {
+	return r.int64Map(false)
+}
+
+// Int64MapOrNull is the same as Int64Map, except that it also accepts a null, in which case it
+// returns a nil map and a nil error.
+func (r *Reader) Int64MapOrNull() (map[string]int64, error) {
+	return r.int64Map(true)
+}
+
+// Float64Map reads a JSON object whose property values are all numbers, returning it as a map.
+// It follows the same rules as StringMap.
+func (r *Reader) Float64Map() (map[string]float64, error) {
+	return r.float64Map(false)
+}
+
+// Float64MapOrNull is the same as Float64Map, except that it also accepts a null, in which case it
+// returns a nil map and a nil error.
+func (r *Reader) Float64MapOrNull() (map[string]float64, error) {
+	return r.float64Map(true)
+}
+
+// DecodeEnumObject reads a JSON object whose property values are all strings, where each property
+// may only have one of the values that spec allows for it, such as a configuration section whose
+// settings are chosen from fixed lists:
+//
+//	var logSpec = map[string][]string{
+//	    "level":  {"debug", "info", "warn", "error"},
+//	    "format": {"text", "json"},
+//	}
+//
+//	settings, err := r.DecodeEnumObject(logSpec)
+//
+// It returns the properties as a map. Properties are not required; use ObjectState.TrackPresence
+// or ReadObjectWithSchema if some must be present. If the same property name appears more than
+// once, the last value wins. Each value is compared in the same way as by ReadEnumString.
+//
+// If a property name is not in spec, the error is a ValueError whose Got field is the name. If a
+// value is not one of the allowed values for its property, the error is a ValueError whose Got field
+// is the value and whose Property field is the name. For a value that is not a string, the error is
+// a TypeError whose Property field is the name. In all of these cases, and if there is a parsing
+// error or the next value is not an object, the return value is nil and the Reader enters a failed
+// state; the error is also returned.
+func (r *Reader) DecodeEnumObject(spec map[string][]string) (map[string]string, error) {
+	obj := r.Object()
+	if !obj.IsDefined() {
+		return nil, r.err
+	}
+	m := make(map[string]string, r.objectSizeHint(&obj))
+	for obj.Next() {
+		key := r.mapKey(obj.Name())
+		allowed, ok := spec[key]
+		if !ok {
+			r.err = ValueError{Message: errMsgUnknownProperty, Got: key, Offset: r.tr.LastPos()}
+			return nil, r.err
+		}
+		i, err := r.ReadEnumString(allowed)
+		if err != nil {
+			if ve, ok := err.(ValueError); ok {
+				ve.Property = key
+				r.err = ve
+			} else {
+				r.err = typeErrorForProperty(err, obj.Name())
+			}
+			return nil, r.err
+		}
+		m[key] = allowed[i]
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return m, nil
+}
+
+// ReadObjectFlattened reads a JSON object, including any objects nested in it up to maxDepth levels
+// deep, into a flat map whose keys are the paths of the values, with the property names joined by
+// separator. This is useful for storing a document in a flat key-value form, such as labels or
+// environment variables:
+//
+//	// {"db": {"host": "x", "port": 5432}, "tags": ["a", "b"]}
+//	m, err := r.ReadObjectFlattened(2, ".")
+//	// m is {"db.host": "x", "db.port": "5432", "tags": `["a","b"]`}
+//
+// Values are converted to strings as follows: a string is decoded; a number is its text as it
+// appears in the input; a boolean is "true" or "false"; and a null is "". An array, or an object at
+// depth maxDepth, is serialized as compact JSON, as is an empty object at any depth, so that it is
+// not lost. A maxDepth of 1 (or less) means that only the top-level properties are in the map.
+//
+// Property names have their escape sequences decoded. If two values have the same path, because a
+// property appears more than once or because a property name contains the separator, the last one
+// wins. If there is a parsing error, or the next value is not an object, the return value is nil and
+// the Reader enters a failed state; the error is also returned.
+func (r *Reader) ReadObjectFlattened(maxDepth int, separator string) (map[string]string, error) {
+	obj := r.Object()
+	if !obj.IsDefined() {
+		return nil, r.err
+	}
+	m := make(map[string]string, r.objectSizeHint(&obj))
+	var scratch bytes.Buffer
+	r.flattenObject(&obj, "", 1, maxDepth, separator, m, &scratch)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return m, nil
+}
+
+func (r *Reader) flattenObject(obj *ObjectState, prefix string, depth, maxDepth int, separator string,
+	m map[string]string, scratch *bytes.Buffer) {
+	for obj.Next() {
+		key := r.mapKey(obj.Name())
+		if depth > 1 {
+			key = prefix + separator + key
+		}
+		kind, _, _ := r.peekKind()
+		if kind == ObjectValue && depth < maxDepth {
+			if empty, _ := r.ReadObjectEmpty(); empty {
+				m[key] = "{}"
+				continue
+			}
+			nested := r.Object()
+			r.flattenObject(&nested, key, depth+1, maxDepth, separator, m, scratch)
+			continue
+		}
+		if kind == ArrayValue || kind == ObjectValue {
+			raw := r.readRawValue()
+			if r.err != nil {
+				return
+			}
+			scratch.Reset()
+			if err := json.Compact(scratch, raw); err != nil {
+				m[key] = string(raw)
+			} else {
+				m[key] = scratch.String()
+			}
+			continue
+		}
+		v := r.Any()
+		switch v.Kind {
+		case StringValue:
+			m[key] = string(r.decodedString(nil, v.String))
+		case NumberValue:
+			m[key] = string(v.Number.raw)
+		case BoolValue:
+			m[key] = strconv.FormatBool(v.Bool)
+		case NullValue:
+			m[key] = ""
+		}
+	}
+}
+
+// ReadObjectToOrderedMap reads a JSON object into a map of AnyValues, and also returns its property
+// names in the order they appear in the object, which a map does not preserve. This allows the
+// object to be written back out with its properties in the same order, for instance to produce a
+// canonical form or a readable diff:
+//
+//	keys, values, err := r.ReadObjectToOrderedMap()
+//	for _, key := range keys {
+//	    writeProperty(key, values[key])
+//	}
+//
+// The values are in the same form as the result of ReadAllAny, including any nested arrays and
+// objects, and do not share any memory with the input. Property names are decoded. If a property
+// name appears more than once, it is only listed once in keys, at the position of its first
+// appearance, and the last value wins.
+//
+// If there is a parsing error, or the next value is not an object, the return values are nil and
+// the Reader enters a failed state; the error is also returned.
+func (r *Reader) ReadObjectToOrderedMap() ([]string, map[string]*AnyValue, error) {
+	obj := r.Object()
+	if !obj.IsDefined() {
+		return nil, nil, r.err
+	}
+	size := r.objectSizeHint(&obj)
+	keys := make([]string, 0, size)
+	values := make(map[string]*AnyValue, size)
+	for obj.Next() {
+		key := r.mapKey(obj.Name())
+		value := r.readAnyTree()
+		if r.err != nil {
+			break
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	return keys, values, nil
+}
+
+// KV is a property of an object, as returned by Reader.ReadObjectAsSlice.
+type KV struct {
+	// Key is the property name, with any escape sequences decoded.
+	Key []byte
+
+	// Value is the property value.
+	Value *AnyValue
+}
+
+// ReadObjectAsSlice reads an object into a slice of its properties, in the order in which they
+// appear in the input. This preserves the order of the properties without the overhead of a map,
+// which is useful for formats in which the order is significant.
+//
+// The values are in the same form as the result of ReadAllAny, and neither the keys nor the values
+// share any memory with the input. Every property is included, so if a property name appears more
+// than once, it appears in the result more than once; a caller that must reject duplicates can check
+// for them. To reduce the number of allocations, the result is allocated at its final size when the
+// Reader is in lazy mode, and the keys are all stored in a single buffer; each Key has a capacity
+// equal to its length, so appending to it does not affect the others.
+//
+// If there is a parsing error, or the next value is not an object, the return value is nil and the
+// Reader enters a failed state; the error is also returned.
+func (r *Reader) ReadObjectAsSlice() ([]KV, error) {
+	result := []KV{}
+	if err := r.ReadObjectIntoKV(&result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReadObjectIntoKV is the same as ReadObjectAsSlice, except that it appends the properties to the
+// slice that dst points to, so that a caller that reads many objects can reuse one backing array by
+// passing a slice of length zero:
+//
+//	var props []jreader.KV
+//	for arr := r.Array(); arr.Next(); {
+//	    props = props[:0]
+//	    err := r.ReadObjectIntoKV(&props)
+//	    ...
+//	}
+//
+// The keys and values are newly allocated as they are by ReadObjectAsSlice, so they remain valid
+// after the Reader is reset or reused, and after the next call with the same slice.
+//
+// If there is a parsing error, or the next value is not an object, the slice that dst points to is
+// left as it was, although elements after its end in the backing array may have been overwritten;
+// the Reader enters a failed state, and the error is returned.
+func (r *Reader) ReadObjectIntoKV(dst *[]KV) error {
+	obj := r.Object()
+	if !obj.IsDefined() {
+		return r.err
+	}
+	result := *dst
+	start := len(result)
+	size := r.objectSizeHint(&obj)
+	if cap(result)-start < size {
+		grown := make([]KV, start, start+size)
+		copy(grown, result)
+		result = grown
+	}
+	var keys []byte
+	keyEnds := make([]int, 0, size)
+	for obj.Next() {
+		name, _ := decodeName(nil, obj.Name())
+		keys = append(keys, name...)
+		keyEnds = append(keyEnds, len(keys))
+		value := r.readAnyTree()
+		if r.err != nil {
+			return r.err
+		}
+		result = append(result, KV{Value: value})
+	}
+	if r.err != nil {
+		return r.err
+	}
+	keyStart := 0
+	for i, end := range keyEnds {
+		result[start+i].Key = keys[keyStart:end:end]
+		keyStart = end
+	}
+	*dst = result
+	return nil
+}
+
+// ReadObjectIntoMap reads an object into the existing map dst, which must not be nil, setting an
+// entry for each property in the same form as ReadObjectToOrderedMap: property names are decoded,
+// and the values do not share any memory with the input. Entries that are already in dst are kept
+// unless the object has a property of the same name, and if a property name appears more than once,
+// the last value wins. This allows one map to be reused for many objects, after clearing it, or
+// several objects to be merged into one map.
+//
+// If there is a parsing error, or the next value is not an object, dst may have been partly filled;
+// the Reader enters a failed state, and the error is returned.
+func (r *Reader) ReadObjectIntoMap(dst map[string]*AnyValue) error {
+	for obj := r.Object(); obj.Next(); {
+		key := r.mapKey(obj.Name())
+		value := r.readAnyTree()
+		if r.err != nil {
+			break
+		}
+		dst[key] = value
+	}
+	return r.err
+}
+
+// ReadObjectWithDefaults reads an object into a map in the same form as ReadObjectIntoMap, and then
+// adds an entry for each key in defaults that the object does not have, so the result is the object
+// merged over the defaults:
+//
+//	config, err := r.ReadObjectWithDefaults(map[string]*jreader.AnyValue{
+//	    "enabled": {Kind: jreader.BoolValue, Bool: true},
+//	})
+//
+// The entries that come from defaults are the same *AnyValue pointers, not copies, so they should
+// not be modified through the result if defaults is reused. A property whose value is null counts as
+// present, so its default is not used. defaults is not modified, and may be nil.
+//
+// If there is a parsing error, or the next value is not an object, the return value is nil and the
+// Reader enters a failed state; the error is also returned.
+func (r *Reader) ReadObjectWithDefaults(defaults map[string]*AnyValue) (map[string]*AnyValue, error) {
+	result := make(map[string]*AnyValue, len(defaults))
+	if err := r.ReadObjectIntoMap(result); err != nil {
+		return nil, err
+	}
+	for key, value := range defaults {
+		if _, ok := result[key]; !ok {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+// ToAnyMap reads the properties of the object into a map of AnyValues, without reading anything
+// below the first level: a property whose value is a string, number, boolean, or null has it in the
+// AnyValue as Reader.Any would return it, but a property whose value is an array or object only has
+// an ArrayState or ObjectState for iterating over it later, if and when it is needed. This is a
+// convenient way to read a few dynamic properties of an object while ignoring the rest.
+//
+//	obj := r.Object()
+//	props, err := obj.ToAnyMap()
+//	if items, ok := props["items"]; ok && items.Kind == jreader.ArrayValue {
+//	    itemReader := items.Array.Reader()
+//	    for items.Array.Next() {
+//	        item := itemReader.String()
+//	        ...
+//	    }
+//	}
+//
+// Each array or object value is read with its own Reader (see NewSubReader), which is returned by
+// the Reader method of the ArrayState or ObjectState; use that Reader, not the original one, to read
+// its contents. The original Reader is positioned after the end of the object, as if the object had
+// been read with Next.
+//
+// ToAnyMap should be called instead of Next. It can only be used in lazy mode (see Reader.PreProcess),
+// since it relies on the pre-processed tree to find the nested values later; otherwise it returns a
+// StateError. If the same property name appears more than once, the last value wins. If the
+// ObjectState is for a null value (see Reader.ObjectOrNull), or is not defined because the value was
+// not an object, the result is a nil map and a nil error; in the latter case, the error is in the
+// Reader. If there is a parsing error, the return value is nil and the Reader enters a failed state;
+// the error is also returned.
+func (obj *ObjectState) ToAnyMap() (map[string]*AnyValue, error) {
+	r := obj.r
+	if r == nil {
+		return nil, nil
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if !r.tr.options.lazyRead {
+		r.err = StateError{Message: errMsgAnyMapNotInLazyMode, Offset: r.tr.getPos()}
+		return nil, r.err
+	}
+	m := make(map[string]*AnyValue, r.objectSizeHint(obj))
+	for obj.Next() {
+		key := r.mapKey(obj.Name())
+		node, err := r.tr.currentNode()
+		if err != nil {
+			r.err = err
+			return nil, err
+		}
+		var value AnyValue
+		if kind := node.Kind(); kind == ArrayValue || kind == ObjectValue {
+			sub := new(Reader)
+			*sub = NewSubReader(r, NodeRef{Index: r.tr.structBuffer.pos, Start: node.start, End: node.end})
+			value = *sub.Any()
+			if err := sub.Error(); err != nil {
+				r.err = err
+				return nil, err
+			}
+			_ = r.SkipValue()
+		} else {
+			value = *r.Any()
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		m[key] = &value
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return m, nil
+}
+
+// SetInternKeys enables or disables interning of the map keys produced by StringMap, Int64Map, and
+// Float64Map. When enabled, the Reader remembers each distinct key it has allocated, so that
+// reading many objects with the same property names (such as a list of labeled metrics) allocates
+// each name only once. The interned keys are kept until interning is disabled again.
+func (r *Reader) SetInternKeys(intern bool) {
+	if !intern {
+		r.internedKeys = nil
+	} else if r.internedKeys == nil {
+		r.internedKeys = make(map[string]string)
+	}
+}
+
+func (r *Reader) stringMap(allowNull bool) (map[string]string, error) {
+	obj := r.tryObject(allowNull)
+	if !obj.IsDefined() {
+		return nil, r.err
+	}
+	m := make(map[string]string, r.objectSizeHint(&obj))
+	for obj.Next() {
+		val := r.String()
+		if r.err != nil {
+			break
+		}
+		m[r.mapKey(obj.Name())] = string(val)
+	}
+	if r.err != nil {
+		r.err = typeErrorForProperty(r.err, obj.Name())
+		return nil, r.err
+	}
+	return m, nil
+}
+
+func (r *Reader) int64Map(allowNull bool) (map[string]int64, error) {
+	obj := r.tryObject(allowNull)
+	if !obj.IsDefined() {
+		return nil, r.err
+	}
+	m := make(map[string]int64, r.objectSizeHint(&obj))
+	for obj.Next() {
+		val := r.Int64()
+		if r.err != nil {
+			break
+		}
+		m[r.mapKey(obj.Name())] = val
+	}
+	if r.err != nil {
+		r.err = typeErrorForProperty(r.err, obj.Name())
+		return nil, r.err
+	}
+	return m, nil
+}
+
+func (r *Reader) float64Map(allowNull bool) (map[string]float64, error) {
+	obj := r.tryObject(allowNull)
+	if !obj.IsDefined() {
+		return nil, r.err
+	}
+	m := make(map[string]float64, r.objectSizeHint(&obj))
+	for obj.Next() {
+		val := r.Float64()
+		if r.err != nil {
+			break
+		}
+		m[r.mapKey(obj.Name())] = val
+	}
+	if r.err != nil {
+		r.err = typeErrorForProperty(r.err, obj.Name())
+		return nil, r.err
+	}
+	return m, nil
+}
+
+// objectSizeHint returns the number of properties in the object, if it can be determined without
+// parsing (that is, in lazy mode), or zero otherwise.
+func (r *Reader) objectSizeHint(obj *ObjectState) int {
+	if !r.tr.options.lazyRead {
+		return 0
+	}
+	return r.tr.structBuffer.childCount(obj.objectIndex)
+}
+
+// mapKey converts a property name, as returned by ObjectState.Name, to a string with any escape
+// sequences decoded.
+func (r *Reader) mapKey(name []byte) string {
+	name, _ = decodeName(nil, name)
+	if r.internedKeys == nil {
+		return string(name)
+	}
+	if key, ok := r.internedKeys[string(name)]; ok {
+		return key
+	}
+	key := string(name)
+	r.internedKeys[key] = key
+	return key
+}
+
+// typeErrorForProperty sets the Property field of err, if it is a TypeError, to the property name
+// as it appears in the input, with any escape sequences decoded.
+func typeErrorForProperty(err error, rawName []byte) error {
+	if rawName == nil {
+		return err
+	}
+	name, _ := decodeName(nil, rawName)
+	return typeErrorWithProperty(err, string(name))
+}
+
+func typeErrorWithProperty(err error, name string) error {
+	if te, ok := err.(TypeError); ok {
+		te.Property = name
+		return te
+	}
+	return err
+}
@@ -0,0 +1,572 @@
+package jreader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringMap(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		r := NewReader([]byte(`{"a":"x", "b":"y"}`))
+		m, err := r.StringMap()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"a": "x", "b": "y"}, m)
+	})
+
+	t.Run("empty object", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		m, err := r.StringMap()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{}, m)
+	})
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		r := NewReader([]byte(`{"a":"x", "a":"y"}`))
+		m, err := r.StringMap()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"a": "y"}, m)
+	})
+
+	t.Run("escaped key", func(t *testing.T) {
+		r := NewReader([]byte(`{"a\"b":"x"}`))
+		m, err := r.StringMap()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{`a"b`: "x"}, m)
+	})
+
+	t.Run("null", func(t *testing.T) {
+		r := NewReader([]byte(`null`))
+		_, err := r.StringMap()
+		require.IsType(t, TypeError{}, err)
+
+		r = NewReader([]byte(`null`))
+		m, err := r.StringMapOrNull()
+		require.NoError(t, err)
+		assert.Nil(t, m)
+	})
+
+	t.Run("wrong-typed value mid-object", func(t *testing.T) {
+		r := NewReader([]byte(`{"a":"x", "b":2, "c":"z"}`))
+		m, err := r.StringMap()
+		assert.Nil(t, m)
+		require.IsType(t, TypeError{}, err)
+		te := err.(TypeError)
+		assert.Equal(t, "b", te.Property)
+		assert.Equal(t, NumberValue, te.Actual)
+		assert.Equal(t, err, r.Error())
+		assert.Contains(t, err.Error(), `"b"`)
+	})
+}
+
+func TestInt64Map(t *testing.T) {
+	r := NewReader([]byte(`{"a":1, "b":-2}`))
+	m, err := r.Int64Map()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"a": 1, "b": -2}, m)
+
+	r = NewReader([]byte(`null`))
+	m, err = r.Int64MapOrNull()
+	require.NoError(t, err)
+	assert.Nil(t, m)
+
+	r = NewReader([]byte(`{"a":1, "b":true}`))
+	_, err = r.Int64Map()
+	require.IsType(t, TypeError{}, err)
+	assert.Equal(t, "b", err.(TypeError).Property)
+
+	r = NewReader([]byte(`{"a":1, "\u0062":true}`))
+	_, err = r.Int64Map()
+	require.IsType(t, TypeError{}, err)
+	assert.Equal(t, "b", err.(TypeError).Property)
+}
+
+func TestFloat64Map(t *testing.T) {
+	r := NewReader([]byte(`{"a":1.5, "b":2}`))
+	m, err := r.Float64Map()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"a": 1.5, "b": 2}, m)
+
+	r = NewReader([]byte(`{}`))
+	m, err = r.Float64MapOrNull()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{}, m)
+}
+
+func TestMapsInLazyMode(t *testing.T) {
+	r := newLazyReaderForTest(t, `{"a":{"x":1, "y":2}, "b":{}}`)
+
+	obj := r.Object()
+	require.True(t, obj.Next())
+	assert.Equal(t, 2, r.objectSizeHint(&ObjectState{objectIndex: r.tr.structBuffer.pos}))
+	m, err := r.Int64Map()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"x": 1, "y": 2}, m)
+	require.True(t, obj.Next())
+	m, err = r.Int64Map()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{}, m)
+	require.False(t, obj.Next())
+}
+
+func TestInternKeys(t *testing.T) {
+	r := NewReader([]byte(`[{"label":"a"}, {"label":"b"}]`))
+	r.SetInternKeys(true)
+	var maps []map[string]string
+	for arr := r.Array(); arr.Next(); {
+		m, err := r.StringMap()
+		require.NoError(t, err)
+		maps = append(maps, m)
+	}
+	require.Len(t, maps, 2)
+	assert.Len(t, r.internedKeys, 1)
+	assert.Equal(t, "b", maps[1]["label"])
+}
+
+func TestDecodeEnumObject(t *testing.T) {
+	spec := map[string][]string{
+		"level":  {"debug", "info", "warn"},
+		"format": {"text", "json"},
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		r := NewReader([]byte(`{"level": "info", "format": "json", "level": "warn"}`))
+		m, err := r.DecodeEnumObject(spec)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"level": "warn", "format": "json"}, m)
+	})
+
+	t.Run("empty object", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		m, err := r.DecodeEnumObject(spec)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{}, m)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		r := NewReader([]byte(`{"level": "info", "format": "xml"}`))
+		m, err := r.DecodeEnumObject(spec)
+		assert.Nil(t, m)
+		require.Equal(t, err, r.Error())
+		require.Equal(t, ValueError{Message: errMsgNotEnumValue, Got: "xml", Property: "format", Offset: 28}, err)
+		assert.Equal(t, `string is not one of the allowed values for property "format" at position 28 ("xml")`,
+			err.Error())
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		r := NewReader([]byte(`{"level": "info", "color": "red"}`))
+		m, err := r.DecodeEnumObject(spec)
+		assert.Nil(t, m)
+		var ve ValueError
+		require.True(t, errors.As(err, &ve))
+		assert.Equal(t, errMsgUnknownProperty, ve.Message)
+		assert.Equal(t, "color", ve.Got)
+	})
+
+	t.Run("value is not a string", func(t *testing.T) {
+		r := NewReader([]byte(`{"level": 1}`))
+		_, err := r.DecodeEnumObject(spec)
+		require.Equal(t, "level", err.(TypeError).Property)
+	})
+
+	t.Run("escaped key", func(t *testing.T) {
+		r := NewReader([]byte(`{"\u006cevel": "info", "form\u0061t": 1}`))
+		_, err := r.DecodeEnumObject(spec)
+		require.Equal(t, "format", err.(TypeError).Property)
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"level": "debug", "format": "text"}`)
+		m, err := r.DecodeEnumObject(spec)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"level": "debug", "format": "text"}, m)
+	})
+}
+
+func TestToAnyMap(t *testing.T) {
+	t.Run("first level", func(t *testing.T) {
+		buffer := make([]JsonTreeStruct, 0, 100)
+		charBuffer := make([]byte, 0, 100)
+		r := NewReaderWithBuffers([]byte(`{"s":"a\"b", "n":1.5, "b":true, "z":null, `+
+			`"arr":["x", "y"], "obj":{"k":"v\n", "deep":[1]}, "skip":{"q":"A"}}`),
+			BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer})
+		r.PreProcess()
+		require.NoError(t, r.Error())
+		charsAfterPreProcess := len(charBuffer)
+
+		obj := r.Object()
+		m, err := obj.ToAnyMap()
+		require.NoError(t, err)
+		require.NoError(t, r.RequireEOF())
+		assert.Len(t, m, 7)
+		assert.Equal(t, BoolValue, m["b"].Kind)
+		assert.True(t, m["b"].Bool)
+		assert.Equal(t, NumberValue, m["n"].Kind)
+		n, err := m["n"].Number.Float64()
+		require.NoError(t, err)
+		assert.Equal(t, 1.5, n)
+		assert.Equal(t, NullValue, m["z"].Kind)
+		assert.Equal(t, StringValue, m["s"].Kind)
+		assert.Equal(t, `a\"b`, string(m["s"].String))
+
+		arr := m["arr"]
+		require.Equal(t, ArrayValue, arr.Kind)
+		itemReader := arr.Array.Reader()
+		var items []string
+		for arr.Array.Next() {
+			items = append(items, string(itemReader.String()))
+		}
+		require.NoError(t, itemReader.Error())
+		assert.Equal(t, []string{"x", "y"}, items)
+
+		inner := m["obj"]
+		require.Equal(t, ObjectValue, inner.Kind)
+		innerReader := inner.Object.Reader()
+		require.True(t, inner.Object.Next())
+		assert.Equal(t, "k", string(inner.Object.Name()))
+		assert.Equal(t, `v\n`, string(innerReader.String()))
+		require.True(t, inner.Object.Next())
+		assert.Equal(t, "deep", string(inner.Object.Name()))
+		deep := innerReader.Array()
+		require.True(t, deep.Next())
+		assert.Equal(t, float64(1), innerReader.Float64())
+		require.False(t, deep.Next())
+		require.False(t, inner.Object.Next())
+		require.NoError(t, innerReader.Error())
+
+		// Neither reading the map nor touching some of the nested values decodes anything in the
+		// parent, and the value that was never touched has not decoded anything in its own Reader.
+		assert.Equal(t, charsAfterPreProcess, len(charBuffer))
+		skipped := m["skip"]
+		require.Equal(t, ObjectValue, skipped.Kind)
+		assert.Len(t, *skipped.Object.Reader().tr.charBuffer, 0)
+		assert.Equal(t, 0, skipped.Object.Reader().tr.structBuffer.pos)
+	})
+
+	t.Run("null", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `null`)
+		obj := r.ObjectOrNull()
+		m, err := obj.ToAnyMap()
+		require.NoError(t, err)
+		assert.Nil(t, m)
+	})
+
+	t.Run("not in lazy mode", func(t *testing.T) {
+		r := NewReader([]byte(`{"a":1}`))
+		obj := r.Object()
+		_, err := obj.ToAnyMap()
+		require.IsType(t, StateError{}, err)
+		assert.Equal(t, err, r.Error())
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[1]`)
+		obj := r.Object()
+		m, err := obj.ToAnyMap()
+		require.NoError(t, err)
+		assert.Nil(t, m)
+		require.IsType(t, TypeError{}, r.Error())
+	})
+}
+
+func TestReadObjectFlattened(t *testing.T) {
+	const data = `{"db": {"host": "x\"y", "port": 5432, "opts": {"tls": true, "ca": null}, "none": {}},
+		"tags": [ "a", {"b" : 1} ], "n": -1.50, "a.": 1}`
+
+	modes := []struct {
+		name      string
+		newReader func() Reader
+	}{
+		{"direct", func() Reader { return NewReader([]byte(data)) }},
+		{"lazy", func() Reader { return newLazyReaderForTest(t, data) }},
+	}
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			t.Run("nested", func(t *testing.T) {
+				r := mode.newReader()
+				m, err := r.ReadObjectFlattened(3, ".")
+				require.NoError(t, err)
+				require.NoError(t, r.RequireEOF())
+				assert.Equal(t, map[string]string{
+					"db.host":     `x"y`,
+					"db.port":     "5432",
+					"db.opts.tls": "true",
+					"db.opts.ca":  "",
+					"db.none":     "{}",
+					"tags":        `["a",{"b":1}]`,
+					"n":           "-1.50",
+					"a.":          "1",
+				}, m)
+			})
+
+			t.Run("depth limit", func(t *testing.T) {
+				r := mode.newReader()
+				m, err := r.ReadObjectFlattened(2, "/")
+				require.NoError(t, err)
+				assert.Equal(t, `{"tls":true,"ca":null}`, m["db/opts"])
+				assert.Equal(t, "5432", m["db/port"])
+				assert.Len(t, m, 7)
+			})
+
+			t.Run("top level only", func(t *testing.T) {
+				r := mode.newReader()
+				m, err := r.ReadObjectFlattened(1, ".")
+				require.NoError(t, err)
+				assert.Equal(t, `{"host":"x\"y","port":5432,"opts":{"tls":true,"ca":null},"none":{}}`, m["db"])
+				assert.Len(t, m, 4)
+			})
+		})
+	}
+
+	t.Run("not an object", func(t *testing.T) {
+		r := NewReader([]byte(`[1]`))
+		m, err := r.ReadObjectFlattened(2, ".")
+		require.IsType(t, TypeError{}, err)
+		assert.Nil(t, m)
+	})
+
+	t.Run("syntax error in nested object", func(t *testing.T) {
+		r := NewReader([]byte(`{"a": {"b": }}`))
+		m, err := r.ReadObjectFlattened(2, ".")
+		require.IsType(t, SyntaxError{}, err)
+		assert.Nil(t, m)
+	})
+}
+
+func TestReadObjectToOrderedMap(t *testing.T) {
+	data := `{"z":1, "a":"x\ty", "m":[true,null], "a":{"k":2}, "b":false}`
+
+	check := func(t *testing.T, r *Reader) {
+		keys, values, err := r.ReadObjectToOrderedMap()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"z", "a", "m", "b"}, keys)
+		require.Len(t, values, 4)
+		z, _ := values["z"].Number.Float64()
+		assert.Equal(t, 1.0, z)
+		require.Equal(t, ObjectValue, values["a"].Kind)
+		require.Len(t, values["a"].Properties, 1)
+		assert.Equal(t, "k", string(values["a"].Properties[0].Name))
+		require.Equal(t, ArrayValue, values["m"].Kind)
+		require.Len(t, values["m"].Elements, 2)
+		assert.True(t, values["m"].Elements[0].Bool)
+		assert.Equal(t, NullValue, values["m"].Elements[1].Kind)
+		assert.Equal(t, BoolValue, values["b"].Kind)
+	}
+
+	t.Run("direct mode", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		check(t, &r)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		check(t, &r)
+	})
+
+	t.Run("empty object", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		keys, values, err := r.ReadObjectToOrderedMap()
+		require.NoError(t, err)
+		assert.Equal(t, []string{}, keys)
+		assert.Equal(t, map[string]*AnyValue{}, values)
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		r := NewReader([]byte(`[1]`))
+		keys, values, err := r.ReadObjectToOrderedMap()
+		require.IsType(t, TypeError{}, err)
+		assert.Nil(t, keys)
+		assert.Nil(t, values)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		r := NewReader([]byte(`{"a":1,"b":[}`))
+		keys, values, err := r.ReadObjectToOrderedMap()
+		require.IsType(t, SyntaxError{}, err)
+		assert.Nil(t, keys)
+		assert.Nil(t, values)
+		assert.Equal(t, err, r.Error())
+	})
+}
+
+func TestReadObjectAsSlice(t *testing.T) {
+	data := `{"z":1, "a\u0062":"x\ty", "m":[true,null], "z":{"k":2}}`
+
+	check := func(t *testing.T, r *Reader) {
+		kvs, err := r.ReadObjectAsSlice()
+		require.NoError(t, err)
+		require.Len(t, kvs, 4)
+		var keys []string
+		for _, kv := range kvs {
+			keys = append(keys, string(kv.Key))
+			assert.Equal(t, len(kv.Key), cap(kv.Key))
+		}
+		assert.Equal(t, []string{"z", "ab", "m", "z"}, keys)
+		z, _ := kvs[0].Value.Number.Float64()
+		assert.Equal(t, 1.0, z)
+		assert.Equal(t, "x\ty", string(kvs[1].Value.String))
+		require.Equal(t, ArrayValue, kvs[2].Value.Kind)
+		require.Len(t, kvs[2].Value.Elements, 2)
+		require.Equal(t, ObjectValue, kvs[3].Value.Kind)
+		assert.Equal(t, "k", string(kvs[3].Value.Properties[0].Name))
+	}
+
+	t.Run("direct mode", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		check(t, &r)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		check(t, &r)
+	})
+
+	t.Run("empty object", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		kvs, err := r.ReadObjectAsSlice()
+		require.NoError(t, err)
+		assert.Len(t, kvs, 0)
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		r := NewReader([]byte(`[1]`))
+		kvs, err := r.ReadObjectAsSlice()
+		require.IsType(t, TypeError{}, err)
+		assert.Nil(t, kvs)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		r := NewReader([]byte(`{"a":1,"b":[}`))
+		kvs, err := r.ReadObjectAsSlice()
+		require.IsType(t, SyntaxError{}, err)
+		assert.Nil(t, kvs)
+		assert.Equal(t, err, r.Error())
+	})
+}
+
+func TestReadObjectIntoKV(t *testing.T) {
+	data := `[{"a":1,"b":"x"}, {"cd":[true]}, {}]`
+
+	check := func(t *testing.T, r *Reader) {
+		props := make([]KV, 0, 2)
+		backing := &props[:1][0]
+		var all [][]KV
+		for arr := r.Array(); arr.Next(); {
+			props = props[:0]
+			require.NoError(t, r.ReadObjectIntoKV(&props))
+			all = append(all, append([]KV(nil), props...))
+		}
+		require.NoError(t, r.Error())
+		require.Len(t, all, 3)
+		require.Len(t, all[0], 2)
+		assert.Equal(t, "a", string(all[0][0].Key))
+		assert.Equal(t, "b", string(all[0][1].Key))
+		assert.Equal(t, "x", string(all[0][1].Value.String))
+		require.Len(t, all[1], 1)
+		assert.Equal(t, "cd", string(all[1][0].Key))
+		assert.True(t, all[1][0].Value.Elements[0].Bool)
+		assert.Len(t, all[2], 0)
+		assert.Same(t, backing, &props[:1][0])
+	}
+
+	t.Run("direct mode", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		check(t, &r)
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		check(t, &r)
+	})
+
+	t.Run("appends", func(t *testing.T) {
+		r := NewReader([]byte(`{"b":2}`))
+		props := []KV{{Key: []byte("a")}}
+		require.NoError(t, r.ReadObjectIntoKV(&props))
+		require.Len(t, props, 2)
+		assert.Equal(t, "a", string(props[0].Key))
+		assert.Equal(t, "b", string(props[1].Key))
+	})
+
+	t.Run("error leaves slice unchanged", func(t *testing.T) {
+		r := NewReader([]byte(`{"b":2,"c":[}`))
+		props := []KV{{Key: []byte("a")}}
+		require.IsType(t, SyntaxError{}, r.ReadObjectIntoKV(&props))
+		require.Len(t, props, 1)
+	})
+}
+
+func TestReadObjectIntoMap(t *testing.T) {
+	t.Run("fills and overwrites", func(t *testing.T) {
+		r := NewReader([]byte(`{"a":1, "bb":"x", "a":[2]}`))
+		m := map[string]*AnyValue{"a": {Kind: NullValue}, "c": {Kind: BoolValue, Bool: true}}
+		require.NoError(t, r.ReadObjectIntoMap(m))
+		require.Len(t, m, 3)
+		assert.Equal(t, ArrayValue, m["a"].Kind)
+		assert.Equal(t, "x", string(m["bb"].String))
+		assert.True(t, m["c"].Bool)
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"a":{"b":null}}`)
+		m := map[string]*AnyValue{}
+		require.NoError(t, r.ReadObjectIntoMap(m))
+		require.Equal(t, ObjectValue, m["a"].Kind)
+		assert.Equal(t, "b", string(m["a"].Properties[0].Name))
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		r := NewReader([]byte(`[1]`))
+		m := map[string]*AnyValue{}
+		require.IsType(t, TypeError{}, r.ReadObjectIntoMap(m))
+		assert.Len(t, m, 0)
+	})
+}
+
+func TestReadObjectWithDefaults(t *testing.T) {
+	defaults := map[string]*AnyValue{
+		"timeout": {Kind: NumberValue, Number: NumberProps{raw: []byte("30"), trunc: true}},
+		"name":    {Kind: StringValue, String: []byte("default")},
+		"debug":   {Kind: BoolValue},
+	}
+	data := `{"name": "custom", "debug": null, "extra": [1]}`
+
+	check := func(t *testing.T, r *Reader) {
+		m, err := r.ReadObjectWithDefaults(defaults)
+		require.NoError(t, err)
+		require.Len(t, m, 4)
+		assert.Equal(t, "custom", string(m["name"].String))
+		assert.Equal(t, NullValue, m["debug"].Kind)
+		assert.Equal(t, ArrayValue, m["extra"].Kind)
+		assert.Same(t, defaults["timeout"], m["timeout"])
+		assert.Len(t, defaults, 3)
+	}
+
+	t.Run("direct mode", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		check(t, &r)
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		check(t, &r)
+	})
+
+	t.Run("nil defaults", func(t *testing.T) {
+		r := NewReader([]byte(`{"a":1}`))
+		m, err := r.ReadObjectWithDefaults(nil)
+		require.NoError(t, err)
+		assert.Len(t, m, 1)
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		r := NewReader([]byte(`null`))
+		m, err := r.ReadObjectWithDefaults(defaults)
+		require.IsType(t, TypeError{}, err)
+		assert.Nil(t, m)
+	})
+}
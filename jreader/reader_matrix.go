@@ -0,0 +1,93 @@
+package jreader
+
+// Float64Matrix reads a JSON array whose elements are all arrays of numbers, such as
+// [[1.0, 2.0], [3.0, 4.0]], returning it as a slice of rows. This is equivalent to iterating over
+// two levels of ArrayState and calling Float64 for each element, but avoids much of the per-element
+// overhead. An empty JSON array produces an empty non-nil slice, and so does an empty row. In lazy
+// mode, each slice is preallocated with the number of elements in the corresponding array;
+// otherwise, each row after the first is preallocated with the length of the first row.
+//
+// If requireUniformRows is true, every row must have the same number of elements as the first
+// row; otherwise, the rows may have different lengths.
+//
+// If there is a parsing error, or the next value is not an array, the return value is nil and the
+// Reader enters a failed state; the error is also returned. If the error is within one of the
+// rows, including a row that is not an array, a bad element, or a row of the wrong length, the
+// error is a MatrixError that identifies the row and column; a row of the wrong length is reported
+// as a MatrixError wrapping a TupleLengthError.
+func (r *Reader) Float64Matrix(requireUniformRows bool) ([][]float64, error) {
+	return readMatrix(r, requireUniformRows, (*Reader).Float64)
+}
+
+// Int64Matrix reads a JSON array whose elements are all arrays of integers, returning it as a
+// slice of rows. It follows the same rules as Float64Matrix.
+func (r *Reader) Int64Matrix(requireUniformRows bool) ([][]int64, error) {
+	return readMatrix(r, requireUniformRows, (*Reader).Int64)
+}
+
+// readMatrix implements Float64Matrix and Int64Matrix, reading each element with readElement.
+func readMatrix[T any](r *Reader, requireUniformRows bool, readElement func(*Reader) T) ([][]T, error) {
+	arr := r.Array()
+	if !arr.IsDefined() {
+		return nil, r.err
+	}
+	rows := make([][]T, 0, r.arraySizeHint(&arr))
+	width := 0 // the length of the first row
+	for arr.Next() {
+		rowArr := r.Array()
+		if !rowArr.IsDefined() {
+			return nil, r.matrixError(len(rows), -1)
+		}
+		row := make([]T, 0, r.matrixRowSizeHint(&rowArr, width))
+		for rowArr.Next() {
+			val := readElement(r)
+			if r.err != nil {
+				return nil, r.matrixError(len(rows), len(row))
+			}
+			row = append(row, val)
+		}
+		if r.err != nil {
+			return nil, r.matrixError(len(rows), len(row))
+		}
+		if len(rows) == 0 {
+			width = len(row)
+		} else if requireUniformRows && len(row) != width {
+			return nil, r.rowLengthError(len(rows), width, len(row))
+		}
+		rows = append(rows, row)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return rows, nil
+}
+
+// matrixRowSizeHint returns the number of elements in a row, if it can be determined without
+// parsing (that is, in lazy mode), or else the length of the first row, if it has been read.
+func (r *Reader) matrixRowSizeHint(rowArr *ArrayState, width int) int {
+	if r.tr.options.lazyRead {
+		return r.arraySizeHint(rowArr)
+	}
+	return width
+}
+
+// rowLengthError puts the Reader in a failed state for a row whose length differs from that of the
+// first row. The column is that of the first missing or unexpected element.
+func (r *Reader) rowLengthError(row, expected, actual int) error {
+	column := expected
+	if actual < expected {
+		column = actual
+	}
+	r.err = MatrixError{
+		Row:    row,
+		Column: column,
+		Err:    TupleLengthError{Expected: expected, Actual: actual, Offset: r.tr.LastPos()},
+	}
+	return r.err
+}
+
+// matrixError wraps the Reader's current error in a MatrixError for the specified element.
+func (r *Reader) matrixError(row, column int) error {
+	r.err = MatrixError{Row: row, Column: column, Err: r.err}
+	return r.err
+}
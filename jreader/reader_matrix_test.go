@@ -0,0 +1,129 @@
+package jreader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFloat64Matrix(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		r := NewReader([]byte(`[[1.0, 2.5], [3, -4e1]]`))
+		m, err := r.Float64Matrix(true)
+		require.NoError(t, err)
+		assert.Equal(t, [][]float64{{1, 2.5}, {3, -40}}, m)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		r := NewReader([]byte(`[]`))
+		m, err := r.Float64Matrix(true)
+		require.NoError(t, err)
+		assert.Equal(t, [][]float64{}, m)
+
+		r = NewReader([]byte(`[[], []]`))
+		m, err = r.Float64Matrix(true)
+		require.NoError(t, err)
+		assert.Equal(t, [][]float64{{}, {}}, m)
+	})
+
+	t.Run("ragged rows", func(t *testing.T) {
+		r := NewReader([]byte(`[[1], [2, 3], []]`))
+		m, err := r.Float64Matrix(false)
+		require.NoError(t, err)
+		assert.Equal(t, [][]float64{{1}, {2, 3}, {}}, m)
+	})
+
+	t.Run("ragged rows not allowed", func(t *testing.T) {
+		for input, column := range map[string]int{`[[1, 2], [3, 4], [5]]`: 1, `[[1, 2], [3, 4], [5, 6, 7]]`: 2} {
+			r := NewReader([]byte(input))
+			m, err := r.Float64Matrix(true)
+			assert.Nil(t, m)
+			require.Equal(t, err, r.Error())
+			var me MatrixError
+			require.True(t, errors.As(err, &me))
+			assert.Equal(t, 2, me.Row)
+			assert.Equal(t, column, me.Column)
+			require.IsType(t, TupleLengthError{}, me.Err)
+		}
+	})
+
+	t.Run("bad element", func(t *testing.T) {
+		r := NewReader([]byte(`[[1, 2], [3, null]]`))
+		m, err := r.Float64Matrix(false)
+		assert.Nil(t, m)
+		require.Equal(t, err, r.Error())
+		require.Equal(t, MatrixError{Row: 1, Column: 1,
+			Err: TypeError{Expected: NumberValue, Actual: NullValue, Offset: 13}}, err)
+		var te TypeError
+		require.True(t, errors.As(err, &te))
+	})
+
+	t.Run("row is not an array", func(t *testing.T) {
+		r := NewReader([]byte(`[[1], 2]`))
+		_, err := r.Float64Matrix(false)
+		var me MatrixError
+		require.True(t, errors.As(err, &me))
+		assert.Equal(t, 1, me.Row)
+		assert.Equal(t, -1, me.Column)
+		require.IsType(t, TypeError{}, me.Err)
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		_, err := r.Float64Matrix(false)
+		require.IsType(t, TypeError{}, err)
+	})
+
+	t.Run("syntax error in row", func(t *testing.T) {
+		r := NewReader([]byte(`[[1 2]]`))
+		_, err := r.Float64Matrix(false)
+		var me MatrixError
+		require.True(t, errors.As(err, &me))
+		require.IsType(t, SyntaxError{}, me.Err)
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[[1, 2, 3], [4, 5, 6]]`)
+		m, err := r.Float64Matrix(true)
+		require.NoError(t, err)
+		assert.Equal(t, [][]float64{{1, 2, 3}, {4, 5, 6}}, m)
+		assert.Equal(t, 2, cap(m))
+		assert.Equal(t, 3, cap(m[0]))
+	})
+}
+
+func TestInt64Matrix(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		r := NewReader([]byte(`[[1, -2], [3, 9007199254740993]]`))
+		m, err := r.Int64Matrix(true)
+		require.NoError(t, err)
+		assert.Equal(t, [][]int64{{1, -2}, {3, 9007199254740993}}, m)
+	})
+
+	t.Run("not an integer", func(t *testing.T) {
+		r := NewReader([]byte(`[[1, 2], [3.5]]`))
+		_, err := r.Int64Matrix(false)
+		var me MatrixError
+		require.True(t, errors.As(err, &me))
+		assert.Equal(t, 1, me.Row)
+		assert.Equal(t, 0, me.Column)
+		var nfe NumberFormatError
+		require.True(t, errors.As(err, &nfe))
+		assert.Equal(t, NotAnInteger, nfe.Reason)
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[[1], [2, 3]]`)
+		m, err := r.Int64Matrix(false)
+		require.NoError(t, err)
+		assert.Equal(t, [][]int64{{1}, {2, 3}}, m)
+	})
+}
+
+func TestMatrixErrorMessage(t *testing.T) {
+	inner := TypeError{Expected: NumberValue, Actual: NullValue, Offset: 5}
+	assert.Equal(t, "matrix row 1, column 2: "+inner.Error(), MatrixError{Row: 1, Column: 2, Err: inner}.Error())
+	assert.Equal(t, "matrix row 1: "+inner.Error(), MatrixError{Row: 1, Column: -1, Err: inner}.Error())
+}
@@ -1,6 +1,9 @@
 package jreader
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
 
 // ObjectState is returned by Reader's Object and ObjectOrNull methods. Use it in conjunction with
 // Reader to iterate through a JSON object. To read the value of each object property, you will
@@ -42,6 +45,8 @@ type ObjectState struct {
 	afterFirst  bool
 	name        []byte
 	objectIndex int
+	presence    *FieldPresence
+	nameBuf     []byte // the normalized name, reused for each property; see SetNormalizeKeys
 }
 
 // WithRequiredProperties adds a requirement that the specified JSON property name(s) must appear
@@ -62,6 +67,289 @@ type ObjectState struct {
 // For efficiency, it is best to preallocate the list of property names globally rather than creating
 // it inline.
 
+// ReadObjectCount returns the number of properties in the next JSON value, which must be an object,
+// without consuming it; the object can then be read as usual with Object. This is useful for
+// preallocating a map, or for choosing between representations based on the number of properties.
+//
+// In lazy mode, the count is taken from the preprocessed structure. Otherwise, it is computed by
+// scanning ahead in the input to the end of the object, which means that the object's contents are
+// scanned twice; syntax errors inside the object are not detected until it is actually read.
+//
+// If the next value is not an object, the return value is zero and the Reader enters a failed state.
+func (r *Reader) ReadObjectCount() (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.tr.options.lazyRead {
+		tape := &r.tr.structBuffer
+		currStruct, err := r.tr.currentNode()
+		if err != nil {
+			r.err = err
+			return 0, err
+		}
+		if r.tr.data[currStruct.start] != '{' {
+			r.err = r.typeErrorAtRawPosition(ObjectValue, currStruct.start)
+			return 0, r.err
+		}
+		return tape.childCount(tape.pos), nil
+	}
+	start := skipRawWhitespace(r.tr.data, r.tr.getPos())
+	if start >= len(r.tr.data) || r.tr.data[start] != '{' {
+		r.err = r.typeErrorAtRawPosition(ObjectValue, start)
+		return 0, r.err
+	}
+	count, ok := countRawItems(r.tr.data, start)
+	if !ok {
+		r.err = SyntaxError{Message: errMsgBadObjectItem, Offset: start}
+		return 0, r.err
+	}
+	return count, nil
+}
+
+// ReadObjectFieldCount returns the number of properties in the next JSON value, if it is an object,
+// without consuming it, for preallocating a map before the object is read:
+//
+//	var m map[string]string
+//	if count, _ := r.ReadObjectFieldCount(); count >= 0 {
+//	    m = make(map[string]string, count)
+//	}
+//	for obj := r.Object(); obj.Next(); { ... }
+//
+// Unlike ReadObjectCount, this never puts the Reader into a failed state: if the count cannot be
+// determined, because the next value is not an object or is not well-formed, the return value is -1
+// and a nil error, and the problem is reported when the value is actually read. The error is only
+// non-nil if the Reader was already in a failed state, in which case the count is also -1.
+//
+// In lazy mode, the count is the number of children of the object's node in the pre-processed tree.
+// Otherwise, it is the number of colons at the top level of the object, which are found by scanning
+// ahead in the input to the end of the object. A property that appears more than once is counted
+// each time.
+func (r *Reader) ReadObjectFieldCount() (int, error) {
+	if r.err != nil {
+		return -1, r.err
+	}
+	kind, pos, ok := r.peekKind()
+	if !ok || kind != ObjectValue {
+		return -1, nil
+	}
+	if r.tr.options.lazyRead {
+		tape := &r.tr.structBuffer
+		return tape.childCount(tape.pos), nil
+	}
+	count, ok := countRawProperties(r.tr.data, pos)
+	if !ok {
+		return -1, nil
+	}
+	return count, nil
+}
+
+// ReadObjectKeys reads the next JSON value, which must be an object, and returns the names of its
+// properties in the order they appear, skipping over the property values. This is useful for finding
+// out what properties an object has without reading them. Escape sequences in the names are
+// decoded, and each name is a new slice that does not refer to the Reader's buffers. If a property
+// appears more than once, so does its name.
+//
+// In lazy mode, the result is preallocated with the number of properties in the object.
+//
+// If there is a parsing error, or the next value is not an object, the return value is nil and the
+// Reader enters a failed state; the error is also returned.
+func (r *Reader) ReadObjectKeys() ([][]byte, error) {
+	obj := r.Object()
+	if !obj.IsDefined() {
+		return nil, r.err
+	}
+	keys := make([][]byte, 0, r.objectSizeHint(&obj))
+	for obj.Next() {
+		name := obj.Name()
+		key, ok := unescapeString(make([]byte, 0, len(name)), name)
+		if !ok {
+			key = append(key[:0], name...)
+		}
+		keys = append(keys, key)
+		if r.SkipValue() != nil {
+			break
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return keys, nil
+}
+
+// ReadObjectEmpty checks whether the next JSON value is an empty object. If so, it consumes the
+// object and returns true; otherwise, it returns false without consuming anything, so the value can
+// then be read as usual. It is the object counterpart of ReadArrayEmpty, and follows the same rules.
+func (r *Reader) ReadObjectEmpty() (bool, error) {
+	return r.readEmptyContainer('{', '}')
+}
+
+// DecodeTagged reads a JSON object that represents one variant of a tagged union, where the
+// property tagKey is a string that identifies the variant. It looks up the value of that property,
+// and then calls the corresponding function in dispatch to read the object, starting from the
+// beginning; the function should read the object in the usual way, for instance with Object, and
+// can simply ignore the tag property when it comes to it.
+//
+//	err := r.DecodeTagged("type", map[string]func(*jreader.Reader){
+//	    "circle": func(r *jreader.Reader) { shape = readCircle(r) },
+//	    "square": func(r *jreader.Reader) { shape = readSquare(r) },
+//	})
+//
+// Unlike a switch inside an ObjectState loop, this works even if the tag property comes after the
+// properties whose meaning depends on it. It relies on being able to look ahead in the object, so it
+// can only be used in lazy mode (see Reader.PreProcess); otherwise it returns a StateError.
+//
+// If the object does not have the tag property, the error is a RequiredPropertyError. If the tag
+// value is not a string, the error is a TypeError whose Property field is tagKey. If there is no
+// function for the tag value, the error is a ValueError. In all of these cases the Reader enters a
+// failed state, and the error is also returned. Otherwise, the return value is the Reader's error
+// after the function has been called.
+func (r *Reader) DecodeTagged(tagKey string, dispatch map[string]func(*Reader)) error {
+	if r.err != nil {
+		return r.err
+	}
+	if !r.tr.options.lazyRead {
+		r.err = StateError{Message: errMsgTaggedNotInLazyMode, Offset: r.tr.getPos()}
+		return r.err
+	}
+	tape := &r.tr.structBuffer
+	currStruct, err := r.tr.currentNode()
+	if err != nil {
+		r.err = err
+		return err
+	}
+	if r.tr.data[currStruct.start] != '{' {
+		r.err = r.typeErrorAtRawPosition(ObjectValue, currStruct.start)
+		return r.err
+	}
+	tagIndex := tape.findChild(tape.pos, tagKey)
+	if tagIndex < 0 {
+		r.err = RequiredPropertyError{Name: tagKey, Offset: currStruct.end}
+		return r.err
+	}
+	tagStruct := (*tape.values)[tagIndex]
+	if r.tr.data[tagStruct.start] != '"' {
+		r.err = typeErrorWithProperty(r.typeErrorAtRawPosition(StringValue, tagStruct.start), tagKey)
+		return r.err
+	}
+	var buf [64]byte
+	tag, _ := decodeName(buf[:0], r.tr.data[tagStruct.start+1:tagStruct.end-1])
+	handler, ok := dispatch[string(tag)]
+	if !ok {
+		r.err = ValueError{Message: errMsgUnknownTag, Got: string(tag), Offset: tagStruct.start}
+		return r.err
+	}
+	handler(r)
+	return r.err
+}
+
+// ReadComposed reads a JSON object whose properties are divided among several independent readers,
+// such as the fields of a struct that embeds other structs, each of which knows how to read its own
+// fields. For each property, the functions are called in order, and each one can get the property
+// name with PropertyName; the first function that reads the property value handles the property,
+// and the remaining functions are not called for it. A function that does not recognize the name
+// should simply return nil without reading anything. If no function reads the value, it is skipped.
+//
+//	type Base struct{ ID string }
+//	type Item struct {
+//	    Base
+//	    Count int64
+//	}
+//
+//	func (b *Base) readField(r *jreader.Reader) error {
+//	    if string(r.PropertyName()) == "id" {
+//	        b.ID = string(r.String())
+//	    }
+//	    return nil
+//	}
+//
+//	func (item *Item) readField(r *jreader.Reader) error {
+//	    if string(r.PropertyName()) == "count" {
+//	        item.Count = r.Int64()
+//	    }
+//	    return nil
+//	}
+//
+//	err := r.ReadComposed(item.Base.readField, item.readField)
+//
+// If a function returns an error, the Reader enters a failed state with that error, and no more
+// properties are read. The return value is the Reader's error, if any, once the object has been
+// read; if there is a parsing error, or the next value is not an object, that is the error.
+func (r *Reader) ReadComposed(readers ...func(*Reader) error) error {
+	outerName := r.composedName
+	defer func() { r.composedName = outerName }()
+	for obj := r.Object(); obj.Next(); {
+		for _, read := range readers {
+			r.composedName = obj.Name()
+			if err := read(r); err != nil {
+				r.AddError(err)
+				break
+			}
+			if !r.awaitingReadValue {
+				break
+			}
+		}
+	}
+	return r.err
+}
+
+// DecodePartial reads a JSON object, passing the value of each property whose name is in binders
+// to the corresponding function, and returns the text of all other property values, keyed by
+// property name. This allows a type to decode the properties it knows about while preserving any
+// others verbatim, so that they can be written back out unchanged:
+//
+//	extra, err := r.DecodePartial(map[string]func(*jreader.Reader){
+//	    "name":  func(r *jreader.Reader) { item.Name = string(r.String()) },
+//	    "count": func(r *jreader.Reader) { item.Count = r.Int64() },
+//	})
+//
+// A binder should read the property value; if it does not, the value is skipped. Property names are
+// matched after any escape sequences in them are decoded. Each RawMessage in the result is a copy
+// of the value's text, so it remains valid after the Reader has moved on. If the same unknown
+// property appears more than once, the last value wins. If there are no unknown properties, the
+// result is an empty non-nil map.
+//
+// If there is a parsing error, or the next value is not an object, or a binder causes an error, the
+// return value is nil and the Reader enters a failed state; the error is also returned.
+func (r *Reader) DecodePartial(binders map[string]func(*Reader)) (map[string]RawMessage, error) {
+	extra := make(map[string]RawMessage)
+	for obj := r.Object(); obj.Next(); {
+		key := r.mapKey(obj.Name())
+		if bind, ok := binders[key]; ok {
+			bind(r)
+			continue
+		}
+		raw := r.readRawValue()
+		if r.err != nil {
+			break
+		}
+		extra[key] = append(RawMessage(nil), raw...)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return extra, nil
+}
+
+// PropertyName returns the name of the object property whose value is about to be read, while the
+// Reader is calling one of the functions that were passed to ReadComposed; otherwise, it returns
+// nil. As with ObjectState.Name, the name may refer directly to the source data.
+func (r *Reader) PropertyName() []byte {
+	return r.composedName
+}
+
+// typeErrorAtRawPosition returns an error for a value that was not of the expected kind, based on
+// looking ahead at the raw input without consuming it.
+func (r *Reader) typeErrorAtRawPosition(expected ValueKind, pos int) error {
+	if pos >= len(r.tr.data) {
+		return io.EOF
+	}
+	actual, ok := valueKindFromFirstByte(r.tr.data[pos])
+	if !ok {
+		return SyntaxError{Message: errMsgUnexpectedChar, Value: string(r.tr.data[pos]), Offset: pos}
+	}
+	return TypeError{Expected: expected, Actual: actual, Offset: pos}
+}
+
 // IsDefined returns true if the ObjectState represents an actual object, or false if it was
 // parsed from a null value or was the result of an error. If IsDefined is false, Next will
 // always return false. The zero value ObjectState{} returns false for IsDefined.
@@ -69,6 +357,12 @@ func (obj *ObjectState) IsDefined() bool {
 	return obj.r != nil
 }
 
+// Reader returns the Reader that the ObjectState was created by, which is the one to use for
+// reading the property values, or nil if the ObjectState is not defined (see IsDefined).
+func (obj *ObjectState) Reader() *Reader {
+	return obj.r
+}
+
 // Next checks whether an object property is available and returns true if so. It returns false
 // if the Reader has reached the end of the object, or if any previous Reader operation failed,
 // or if the object was empty or null.
@@ -76,18 +370,23 @@ func (obj *ObjectState) IsDefined() bool {
 // If Next returns true, you can then get the property name with Name, and use Reader methods
 // such as Bool or String to read the property value. If you do not care about the value, simply
 // calling Next again without calling a Reader method will discard the value, just as if you had
-// called SkipValue on the reader.
+// called SkipValue on the reader. This is the same in lazy mode, where Next moves past the value's
+// subtree in the pre-processed tree.
 //
 // See ObjectState for example code.
 func (obj *ObjectState) Next() bool {
-	if obj.r == nil {
+	if obj.r == nil || obj.r.err != nil {
 		return false
 	}
 	if obj.r.tr.options.lazyRead {
 		reader := &obj.r.tr
 		tape := &reader.structBuffer
-		currPos := tape.Pos
 		initPos := obj.objectIndex
+		if tape.pos != initPos && obj.r.awaitingReadValue {
+			obj.r.awaitingReadValue = false
+			tape.SkipSubTree()
+		}
+		currPos := tape.pos
 
 		if !tape.HasNext() {
 			return false
@@ -101,28 +400,29 @@ func (obj *ObjectState) Next() bool {
 
 		if initPos == currPos {
 			tape.Next()
-			if currStruct.SubTreeSize != 1 {
+			if currStruct.subTreeSize != 1 {
 				currStruct, err = tape.CurrentStruct()
-				obj.name = currStruct.AssocValue
+				obj.setName(currStruct.key)
+				obj.r.awaitingReadValue = true
 				return true
 			} else {
 				obj.name = nil
+				obj.r.exitContainer()
 				return false
 			}
 		}
 
-		if (*tape.Values)[initPos].SubTreeSize+initPos != currPos {
+		if (*tape.values)[initPos].subTreeSize+initPos != currPos {
 			currStruct, err = tape.CurrentStruct()
-			obj.name = currStruct.AssocValue
+			obj.setName(currStruct.key)
+			obj.r.awaitingReadValue = true
 			return true
 		} else {
 			obj.name = nil
+			obj.r.exitContainer()
 			return false
 		}
 	} else {
-		if obj.r == nil || obj.r.err != nil {
-			return false
-		}
 		var isEnd bool
 		var err error
 
@@ -145,6 +445,7 @@ func (obj *ObjectState) Next() bool {
 		}
 		if isEnd {
 			obj.name = nil
+			obj.r.exitContainer()
 			return false
 		}
 		name, err := obj.r.tr.PropertyName()
@@ -152,7 +453,7 @@ func (obj *ObjectState) Next() bool {
 			obj.r.AddError(err)
 			return false
 		}
-		obj.name = name
+		obj.setName(name)
 		obj.r.awaitingReadValue = true
 		return true
 	}
@@ -169,6 +470,37 @@ func (obj *ObjectState) Name() []byte {
 	return obj.name
 }
 
+func (obj *ObjectState) setName(name []byte) {
+	// While pre-processing, the name is only stored in the tree, and is normalized when it is read
+	// from there; doing it here as well would apply the function twice.
+	if normalize := obj.r.normalizeKey; normalize != nil && !obj.r.tr.options.lazyParse {
+		obj.nameBuf = append(obj.nameBuf[:0], name...)
+		name = normalize(obj.nameBuf[:len(obj.nameBuf):len(obj.nameBuf)])
+	}
+	obj.name = name
+	if obj.presence != nil {
+		obj.presence.mark(name)
+	}
+}
+
+// SetNormalizeKeys sets a function that is applied to every object property name before it is
+// returned by ObjectState.Name, such as bytes.ToLower for case-insensitive property names:
+//
+//	r.SetNormalizeKeys(bytes.ToLower)
+//
+// The function receives a copy of the name, as it appears in the input (that is, without decoding
+// any escape sequences), in a buffer that the ObjectState reuses for each property, so it can
+// modify the name in place instead of allocating a new slice; it must not retain the slice. For the
+// same reason, a normalized name is only valid until the next call to Next. Since the result is
+// what Name returns, it is also what methods such as StringMap, ReadComposed, and
+// ObjectState.TrackPresence see, but methods that look up properties in the pre-processed tree,
+// such as DecodeTagged and FindAll, use the original names.
+//
+// A nil function, which is the default, leaves names unchanged.
+func (r *Reader) SetNormalizeKeys(fn func(name []byte) []byte) {
+	r.normalizeKey = fn
+}
+
 // This technique of using either a preallocated fixed-length array or a slice (where we have
 // only set the slice to a non-nil value if we determined that the array wasn't big enough) is a
 // way to avoid unnecessary heap allocations: if the ObjectState is on the stack, the fixed-length
@@ -95,3 +95,585 @@ func TestSyntaxErrorStopsObjectParsing(t *testing.T) {
 
 	require.False(t, obj.Next())
 }
+
+func TestDetectMisuseReportsValueReadWithoutNext(t *testing.T) {
+	t.Run("before first property", func(t *testing.T) {
+		r := NewReader([]byte(`{"a":"x"}`))
+		r.SetDetectMisuse(true)
+		obj := r.Object()
+		_ = r.String() // without detection, this would silently read the property name
+		require.IsType(t, UsageError{}, r.Error())
+		require.False(t, obj.Next())
+	})
+
+	t.Run("after a property value", func(t *testing.T) {
+		r := NewReader([]byte(`{"a":1, "b":2}`))
+		r.SetDetectMisuse(true)
+		obj := r.Object()
+		require.True(t, obj.Next())
+		require.Equal(t, int64(1), r.Int64())
+		_ = r.Int64()
+		require.IsType(t, UsageError{}, r.Error())
+		require.False(t, obj.Next())
+	})
+}
+
+func TestDetectMisuseAllowsCorrectUsage(t *testing.T) {
+	r := NewReader([]byte(`{"a":[1, {"b":2}], "c":{"d":[]}, "e":{"skipped":[1]}, "f":3}`))
+	r.SetDetectMisuse(true)
+	for obj := r.Object(); obj.Next(); {
+		switch string(obj.Name()) {
+		case "a":
+			for arr := r.Array(); arr.Next(); {
+				if v := r.Any(); v.Kind == ObjectValue {
+					for inner := v.Object; inner.Next(); {
+						require.Equal(t, int64(2), r.Int64())
+					}
+				}
+			}
+		case "c":
+			for inner := r.Object(); inner.Next(); {
+				require.NoError(t, r.SkipValue())
+			}
+		case "f":
+			require.Equal(t, int64(3), r.Int64())
+		}
+	}
+	require.NoError(t, r.Error())
+}
+
+func TestReadObjectCount(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		count int
+	}{
+		{`{}`, 0},
+		{` { } `, 0},
+		{`{"a":1}`, 1},
+		{`{"a":1, "b":[1,2,3], "c":{"d":4, "e":5}}`, 3},
+		{`{"a,b":"}", "c":"\",{"}`, 2},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			r := NewReader([]byte(tc.input))
+			count, err := r.ReadObjectCount()
+			require.NoError(t, err)
+			require.Equal(t, tc.count, count)
+
+			// the object was not consumed
+			actual := 0
+			for obj := r.Object(); obj.Next(); {
+				actual++
+			}
+			require.NoError(t, r.Error())
+			require.Equal(t, tc.count, actual)
+			require.NoError(t, r.RequireEOF())
+		})
+	}
+}
+
+func TestReadObjectCountInLazyMode(t *testing.T) {
+	r := newLazyReaderForTest(t, `{"a":{}, "b":{"x":[1,2], "y":{"z":3}, "w":null}}`)
+
+	count, err := r.ReadObjectCount()
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	obj := r.Object()
+	require.True(t, obj.Next())
+	count, err = r.ReadObjectCount()
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+	require.NoError(t, r.SkipValue())
+
+	require.True(t, obj.Next())
+	count, err = r.ReadObjectCount()
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+}
+
+func TestReadObjectCountErrors(t *testing.T) {
+	r := NewReader([]byte(`[1,2]`))
+	_, err := r.ReadObjectCount()
+	require.Equal(t, TypeError{Expected: ObjectValue, Actual: ArrayValue, Offset: 0}, err)
+	require.Equal(t, err, r.Error())
+
+	r = NewReader([]byte(`{"a":"unterminated`))
+	_, err = r.ReadObjectCount()
+	require.IsType(t, SyntaxError{}, err)
+}
+
+func TestReadObjectKeys(t *testing.T) {
+	data := `{"a":1, "b\u0063":[1,{"x":2}], "":{"d":4}, "a":null}`
+	expected := [][]byte{[]byte("a"), []byte("bc"), {}, []byte("a")}
+
+	t.Run("direct mode", func(t *testing.T) {
+		r := NewReader([]byte(data + ` 5`))
+		keys, err := r.ReadObjectKeys()
+		require.NoError(t, err)
+		require.Equal(t, expected, keys)
+		require.Equal(t, int64(5), r.Int64())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[`+data+`, 5]`)
+		arr := r.Array()
+		require.True(t, arr.Next())
+		keys, err := r.ReadObjectKeys()
+		require.NoError(t, err)
+		require.Equal(t, expected, keys)
+		require.True(t, arr.Next())
+		require.Equal(t, int64(5), r.Int64())
+	})
+
+	t.Run("empty object", func(t *testing.T) {
+		r := NewReader([]byte(`{ }`))
+		keys, err := r.ReadObjectKeys()
+		require.NoError(t, err)
+		require.Empty(t, keys)
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		r := NewReader([]byte(`["a"]`))
+		keys, err := r.ReadObjectKeys()
+		require.IsType(t, TypeError{}, err)
+		require.Nil(t, keys)
+		require.Equal(t, err, r.Error())
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		r := NewReader([]byte(`{"a":1, "b":]`))
+		keys, err := r.ReadObjectKeys()
+		require.Error(t, err)
+		require.Nil(t, keys)
+	})
+}
+
+func TestReadObjectEmpty(t *testing.T) {
+	data := `{"a":{}, "b":{ "c":1 }, "d":{ }, "e":[], "f":null}`
+
+	check := func(t *testing.T, r *Reader) {
+		obj := r.Object()
+		var empty []string
+		for obj.Next() {
+			isEmpty, err := r.ReadObjectEmpty()
+			require.NoError(t, err)
+			if isEmpty {
+				empty = append(empty, string(obj.Name()))
+			}
+		}
+		require.NoError(t, r.Error())
+		require.Equal(t, []string{"a", "d"}, empty)
+	}
+
+	t.Run("direct mode", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		check(t, &r)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		check(t, &r)
+	})
+
+	t.Run("value is not consumed", func(t *testing.T) {
+		r := NewReader([]byte(`{"a":1}`))
+		isEmpty, err := r.ReadObjectEmpty()
+		require.NoError(t, err)
+		require.False(t, isEmpty)
+		keys, err := r.ReadObjectKeys()
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{[]byte("a")}, keys)
+	})
+}
+
+type taggedShape struct {
+	kind   string
+	radius float64
+	side   float64
+}
+
+func decodeShape(r *Reader) (taggedShape, error) {
+	var shape taggedShape
+	err := r.DecodeTagged("type", map[string]func(*Reader){
+		"circle": func(r *Reader) {
+			shape.kind = "circle"
+			for obj := r.Object(); obj.Next(); {
+				if string(obj.Name()) == "radius" {
+					shape.radius = r.Float64()
+				}
+			}
+		},
+		"square": func(r *Reader) {
+			shape.kind = "square"
+			for obj := r.Object(); obj.Next(); {
+				if string(obj.Name()) == "side" {
+					shape.side = r.Float64()
+				}
+			}
+		},
+	})
+	return shape, err
+}
+
+func TestDecodeTagged(t *testing.T) {
+	t.Run("tag last", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"radius": 2.5, "extra": [1, {"type": "square"}], "type": "circle"}`)
+		shape, err := decodeShape(&r)
+		require.NoError(t, err)
+		assert.Equal(t, taggedShape{kind: "circle", radius: 2.5}, shape)
+	})
+
+	t.Run("tag first", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"type": "square", "side": 3}`)
+		shape, err := decodeShape(&r)
+		require.NoError(t, err)
+		assert.Equal(t, taggedShape{kind: "square", side: 3}, shape)
+	})
+
+	t.Run("escaped tag", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"side": 3, "t\u0079pe": "squ\u0061re"}`)
+		shape, err := decodeShape(&r)
+		require.NoError(t, err)
+		assert.Equal(t, taggedShape{kind: "square", side: 3}, shape)
+	})
+
+	t.Run("nested in array", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[{"radius": 1, "type": "circle"}, {"side": 2, "type": "square"}]`)
+		var shapes []taggedShape
+		for arr := r.Array(); arr.Next(); {
+			shape, err := decodeShape(&r)
+			require.NoError(t, err)
+			shapes = append(shapes, shape)
+		}
+		require.NoError(t, r.Error())
+		assert.Equal(t, []taggedShape{{kind: "circle", radius: 1}, {kind: "square", side: 2}}, shapes)
+	})
+
+	t.Run("missing tag", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"radius": 2.5}`)
+		_, err := decodeShape(&r)
+		require.Equal(t, RequiredPropertyError{Name: "type", Offset: len(`{"radius": 2.5}`)}, err)
+		assert.Equal(t, err, r.Error())
+	})
+
+	t.Run("unknown tag", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"type": "triangle"}`)
+		_, err := decodeShape(&r)
+		require.IsType(t, ValueError{}, err)
+		assert.Equal(t, "triangle", err.(ValueError).Got)
+	})
+
+	t.Run("tag is not a string", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"type": 3}`)
+		_, err := decodeShape(&r)
+		require.IsType(t, TypeError{}, err)
+		assert.Equal(t, "type", err.(TypeError).Property)
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `["circle"]`)
+		_, err := decodeShape(&r)
+		require.IsType(t, TypeError{}, err)
+	})
+
+	t.Run("not in lazy mode", func(t *testing.T) {
+		r := NewReader([]byte(`{"type": "circle"}`))
+		_, err := decodeShape(&r)
+		require.IsType(t, StateError{}, err)
+	})
+}
+
+type composedBase struct{ ID string }
+
+func (b *composedBase) readField(r *Reader) error {
+	if string(r.PropertyName()) == "id" {
+		b.ID = string(r.String())
+	}
+	return nil
+}
+
+type composedItem struct {
+	composedBase
+	Count int64
+	Tags  []string
+}
+
+func (item *composedItem) readField(r *Reader) error {
+	switch string(r.PropertyName()) {
+	case "count":
+		item.Count = r.Int64()
+	case "tags":
+		item.Tags = readStrings(r)
+	}
+	return nil
+}
+
+func TestReadComposed(t *testing.T) {
+	t.Run("fields are divided among readers", func(t *testing.T) {
+		r := NewReader([]byte(`{"id": "a", "unknown": {"x": [1]}, "count": 2, "tags": ["p", "q"]}`))
+		var item composedItem
+		require.NoError(t, r.ReadComposed(item.composedBase.readField, item.readField))
+		require.NoError(t, r.RequireEOF())
+		require.Equal(t, composedItem{composedBase: composedBase{ID: "a"}, Count: 2, Tags: []string{"p", "q"}}, item)
+		require.Nil(t, r.PropertyName())
+	})
+
+	t.Run("first reader that reads the value wins", func(t *testing.T) {
+		r := NewReader([]byte(`{"id": "a", "n": 1}`))
+		var calls []string
+		first := func(r *Reader) error {
+			calls = append(calls, "first:"+string(r.PropertyName()))
+			if string(r.PropertyName()) == "id" {
+				_ = r.String()
+			}
+			return nil
+		}
+		second := func(r *Reader) error {
+			calls = append(calls, "second:"+string(r.PropertyName()))
+			return nil
+		}
+		require.NoError(t, r.ReadComposed(first, second))
+		require.Equal(t, []string{"first:id", "first:n", "second:n"}, calls)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		r := NewReader([]byte(`{"outer": {"id": "x"}, "after": 1}`))
+		var names []string
+		var inner composedBase
+		outer := func(r *Reader) error {
+			names = append(names, string(r.PropertyName()))
+			if string(r.PropertyName()) == "outer" {
+				err := r.ReadComposed(inner.readField)
+				names = append(names, string(r.PropertyName()))
+				return err
+			}
+			return nil
+		}
+		require.NoError(t, r.ReadComposed(outer))
+		require.Equal(t, []string{"outer", "outer", "after"}, names)
+		require.Equal(t, "x", inner.ID)
+	})
+
+	t.Run("reader error", func(t *testing.T) {
+		r := NewReader([]byte(`{"a": 1, "b": 2}`))
+		failure := errors.New("sorry")
+		calls := 0
+		err := r.ReadComposed(func(r *Reader) error {
+			calls++
+			return failure
+		})
+		require.Equal(t, failure, err)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("value of wrong type", func(t *testing.T) {
+		r := NewReader([]byte(`{"count": "x"}`))
+		var item composedItem
+		require.IsType(t, TypeError{}, r.ReadComposed(item.readField))
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		r := NewReader([]byte(`[]`))
+		require.IsType(t, TypeError{}, r.ReadComposed())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"count": 3, "skip": [1, 2], "id": "z"}`)
+		var item composedItem
+		require.NoError(t, r.ReadComposed(item.composedBase.readField, item.readField))
+		require.Equal(t, composedItem{composedBase: composedBase{ID: "z"}, Count: 3}, item)
+	})
+}
+
+func TestDecodePartial(t *testing.T) {
+	var name string
+	var count int64
+	binders := map[string]func(*Reader){
+		"name":   func(r *Reader) { name = string(r.String()) },
+		"count":  func(r *Reader) { count = r.Int64() },
+		"unread": func(r *Reader) {},
+	}
+
+	t.Run("known and unknown properties", func(t *testing.T) {
+		data := []byte(`{"name": "a", "extra": {"x": [1, "]"]}, "count": 2, "unread": [3], "new" : true }`)
+		r := NewReader(data)
+		extra, err := r.DecodePartial(binders)
+		require.NoError(t, err)
+		require.NoError(t, r.RequireEOF())
+		assert.Equal(t, "a", name)
+		assert.Equal(t, int64(2), count)
+		assert.Equal(t, map[string]RawMessage{
+			"extra": RawMessage(`{"x": [1, "]"]}`),
+			"new":   RawMessage(`true`),
+		}, extra)
+
+		copy(data, bytes.Repeat([]byte(" "), len(data)))
+		assert.Equal(t, RawMessage(`{"x": [1, "]"]}`), extra["extra"])
+	})
+
+	t.Run("no unknown properties", func(t *testing.T) {
+		r := NewReader([]byte(`{"count": 5}`))
+		extra, err := r.DecodePartial(binders)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]RawMessage{}, extra)
+		assert.Equal(t, int64(5), count)
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"other": [1, {"y": null}], "name": "b"}`)
+		extra, err := r.DecodePartial(binders)
+		require.NoError(t, err)
+		assert.Equal(t, "b", name)
+		assert.Equal(t, map[string]RawMessage{"other": RawMessage(`[1, {"y": null}]`)}, extra)
+	})
+
+	t.Run("binder error", func(t *testing.T) {
+		r := NewReader([]byte(`{"count": "x", "other": 1}`))
+		extra, err := r.DecodePartial(binders)
+		require.IsType(t, TypeError{}, err)
+		assert.Nil(t, extra)
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		r := NewReader([]byte(`[]`))
+		extra, err := r.DecodePartial(binders)
+		require.IsType(t, TypeError{}, err)
+		assert.Nil(t, extra)
+	})
+}
+
+func TestSetNormalizeKeys(t *testing.T) {
+	const data = `{"Name": "a", "COUNT": 2, "nested": {"InnerKey": true}, "Tags": {"X": "y"}}`
+	lowerInPlace := func(name []byte) []byte {
+		for i, ch := range name {
+			if ch >= 'A' && ch <= 'Z' {
+				name[i] = ch + ('a' - 'A')
+			}
+		}
+		return name
+	}
+	modes := []struct {
+		name      string
+		newReader func(data []byte) Reader
+	}{
+		{"direct", func(data []byte) Reader { return NewReader(data) }},
+		{"lazy", func(data []byte) Reader { return newLazyReaderForTest(t, string(data)) }},
+	}
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			input := []byte(data)
+			r := mode.newReader(input)
+			r.SetNormalizeKeys(lowerInPlace)
+			var names []string
+			var tags map[string]string
+			obj := r.Object()
+			present := NewFieldPresence(NewFieldMatcher([]string{"count", "COUNT"}))
+			obj.TrackPresence(present)
+			for obj.Next() {
+				names = append(names, string(obj.Name()))
+				switch string(obj.Name()) {
+				case "nested":
+					for inner := r.Object(); inner.Next(); {
+						names = append(names, string(inner.Name()))
+					}
+				case "tags":
+					tags, _ = r.StringMap()
+				}
+			}
+			require.NoError(t, r.Error())
+			assert.Equal(t, []string{"name", "count", "nested", "innerkey", "tags"}, names)
+			assert.Equal(t, map[string]string{"x": "y"}, tags)
+			assert.True(t, present.HasName("count"))
+			assert.False(t, present.HasName("COUNT"))
+			assert.Equal(t, data, string(input))
+		})
+	}
+
+	t.Run("nil function", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		r.SetNormalizeKeys(bytes.ToUpper)
+		r.SetNormalizeKeys(nil)
+		obj := r.Object()
+		require.True(t, obj.Next())
+		assert.Equal(t, "Name", string(obj.Name()))
+	})
+
+	t.Run("character buffer does not grow", func(t *testing.T) {
+		buffer := make([]JsonTreeStruct, 0, 10)
+		charBuffer := make([]byte, 0, 10)
+		r := NewReaderWithBuffers([]byte(`{"First": {"A": 1, "LongerName": 2}, "Second": {"X": null}}`),
+			BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer})
+		r.SetNormalizeKeys(lowerInPlace)
+		var names []string
+		for obj := r.Object(); obj.Next(); {
+			for inner := r.Object(); inner.Next(); {
+				names = append(names, string(inner.Name()))
+				require.NoError(t, r.SkipValue())
+			}
+			names = append(names, string(obj.Name()))
+		}
+		require.NoError(t, r.Error())
+		assert.Equal(t, []string{"a", "longername", "first", "x", "second"}, names)
+		assert.Empty(t, charBuffer)
+	})
+
+	t.Run("function that is not idempotent is applied once", func(t *testing.T) {
+		buffer := make([]JsonTreeStruct, 0, 10)
+		charBuffer := make([]byte, 0, 10)
+		r := NewReaderWithBuffers([]byte(`{"a": 1}`), BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer})
+		r.SetNormalizeKeys(func(name []byte) []byte { return append([]byte("x-"), name...) })
+		r.PreProcess()
+		obj := r.Object()
+		require.True(t, obj.Next())
+		assert.Equal(t, "x-a", string(obj.Name()))
+	})
+}
+
+func TestReadObjectFieldCount(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		count int
+	}{
+		{`{}`, 0},
+		{` { } `, 0},
+		{`{"a":1}`, 1},
+		{`{"a":1, "b":[1,{"x":2}], "c":{"d":4, "e":5}, "a":2}`, 4},
+		{`{"a:b":"}", "c":"\":{"}`, 2},
+		{`[{"a":1}]`, -1},
+		{`null`, -1},
+		{`"{"`, -1},
+		{`{"a":1`, -1},
+		{``, -1},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			r := NewReader([]byte(tc.input))
+			count, err := r.ReadObjectFieldCount()
+			require.NoError(t, err)
+			require.Equal(t, tc.count, count)
+			require.NoError(t, r.Error())
+
+			if tc.count >= 0 {
+				lazy := newLazyReaderForTest(t, tc.input)
+				count, err = lazy.ReadObjectFieldCount()
+				require.NoError(t, err)
+				require.Equal(t, tc.count, count)
+
+				actual := 0
+				for obj := r.Object(); obj.Next(); {
+					actual++
+				}
+				require.NoError(t, r.Error())
+				require.Equal(t, tc.count, actual)
+			}
+		})
+	}
+
+	t.Run("after error", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		r.AddError(errors.New("sorry"))
+		count, err := r.ReadObjectFieldCount()
+		require.Equal(t, -1, count)
+		require.EqualError(t, err, "sorry")
+	})
+}
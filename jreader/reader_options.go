@@ -0,0 +1,112 @@
+package jreader
+
+import "fmt"
+
+// ReaderOptions is a snapshot of the settings that determine how a Reader parses its input, as
+// returned by Reader.Options. It is meant for debugging and logging; changing it has no effect on
+// the Reader.
+type ReaderOptions struct {
+	// LazyRead is true if the Reader is reading from a pre-processed tree (see Reader.PreProcess).
+	LazyRead bool
+
+	// ComputeStrings is true if string values are decoded while pre-processing, because the
+	// Reader was given a buffer for them in BufferConfig.ComputedValuesBuffer and this was not
+	// overridden by Reader.PreProcessWith.
+	ComputeStrings bool
+
+	// DeferStrings is true if the last call to Reader.PreProcessWith left the decoding of string
+	// values that ComputeStrings applies to until they are read (see PreProcessOptions.DeferStrings).
+	DeferStrings bool
+
+	// ComputeNumbers is true if numbers are parsed while pre-processing, because the Reader was
+	// given a buffer for them in BufferConfig.ComputedValuesBuffer and this was not overridden by
+	// Reader.PreProcessWith.
+	ComputeNumbers bool
+
+	// DeferNumbers is true if the last call to Reader.PreProcessWith left the parsing of numbers
+	// that ComputeNumbers applies to until they are read (see PreProcessOptions.DeferNumbers).
+	DeferNumbers bool
+
+	// MutationCheck is true if the first lazy read after Reader.PreProcessWith checks that the input
+	// has not changed since it was pre-processed (see PreProcessOptions.DisableMutationCheck).
+	MutationCheck bool
+
+	// RawNumbers is true if numbers that are read in streaming mode are only scanned, and parsed
+	// when they are converted to a Go type, rather than being parsed as they are read. It is
+	// controlled by Reader.SetNumberRawRead, and is true by default.
+	RawNumbers bool
+
+	// DetectMisuse is true if extra checks for incorrect use of the API are enabled (see
+	// Reader.SetDetectMisuse).
+	DetectMisuse bool
+
+	// InternKeys is true if map keys are interned (see Reader.SetInternKeys).
+	InternKeys bool
+
+	// NormalizeKeys is true if property names are normalized (see Reader.SetNormalizeKeys).
+	NormalizeKeys bool
+
+	// DefaultToNull is true if a missing value is read as a null (see Reader.SetDefaultToNull).
+	DefaultToNull bool
+}
+
+// Options returns the Reader's current settings. It is built from the Reader's state at the time
+// of the call, so it reflects any changes made after the Reader was created, such as those made by
+// the Set methods and PreProcessWith, and those made by Reset, which restores the defaults for the
+// settings that are part of the parsing state (LazyRead, DeferStrings, DeferNumbers, MutationCheck
+// and RawNumbers, as well as ComputeStrings and ComputeNumbers if PreProcessWith overrode them).
+func (r *Reader) Options() ReaderOptions {
+	return ReaderOptions{
+		LazyRead:       r.tr.options.lazyRead,
+		ComputeStrings: r.tr.options.computeString,
+		DeferStrings:   r.deferred.strings,
+		ComputeNumbers: r.tr.options.computeNumber,
+		DeferNumbers:   r.deferred.numbers,
+		MutationCheck:  r.inputCheck.enabled,
+		RawNumbers:     r.tr.options.readRawNumbers,
+		DetectMisuse:   r.detectMisuse,
+		InternKeys:     r.internedKeys != nil,
+		NormalizeKeys:  r.normalizeKey != nil,
+		DefaultToNull:  r.defaultToNull,
+	}
+}
+
+// readerSettings are the settings of a Reader that are kept outside of its tokenReader: those that
+// the Set methods change, and which values the last PreProcessWith call deferred. NewSubReader and
+// ReaderView give them to the Readers that they create.
+type readerSettings struct {
+	detectMisuse  bool
+	internKeys    bool
+	normalizeKey  func([]byte) []byte
+	defaultToNull bool
+	deferred      deferredValues
+}
+
+func (r *Reader) settings() readerSettings {
+	return readerSettings{
+		detectMisuse:  r.detectMisuse,
+		internKeys:    r.internedKeys != nil,
+		normalizeKey:  r.normalizeKey,
+		defaultToNull: r.defaultToNull,
+		deferred:      r.deferred,
+	}
+}
+
+// apply gives the settings to r. If keys are interned, r gets a table of its own, since the table
+// is modified as keys are read, and the Readers that the settings are given to can be used
+// concurrently.
+func (s readerSettings) apply(r *Reader) {
+	r.detectMisuse = s.detectMisuse
+	r.SetInternKeys(s.internKeys)
+	r.normalizeKey = s.normalizeKey
+	r.defaultToNull = s.defaultToNull
+	r.deferred = s.deferred
+}
+
+// String returns a description of the options that is suitable for logging.
+func (o ReaderOptions) String() string {
+	return fmt.Sprintf("LazyRead=%t ComputeStrings=%t DeferStrings=%t ComputeNumbers=%t DeferNumbers=%t "+
+		"MutationCheck=%t RawNumbers=%t DetectMisuse=%t InternKeys=%t NormalizeKeys=%t DefaultToNull=%t",
+		o.LazyRead, o.ComputeStrings, o.DeferStrings, o.ComputeNumbers, o.DeferNumbers,
+		o.MutationCheck, o.RawNumbers, o.DetectMisuse, o.InternKeys, o.NormalizeKeys, o.DefaultToNull)
+}
@@ -0,0 +1,371 @@
+package jreader
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderOptions(t *testing.T) {
+	data := []byte(`{"a":1}`)
+
+	t.Run("NewReader", func(t *testing.T) {
+		r := NewReader(data)
+		require.Equal(t, ReaderOptions{RawNumbers: true}, r.Options())
+	})
+
+	t.Run("NewReaderWithBuffers", func(t *testing.T) {
+		buffer := make([]JsonTreeStruct, 0, 10)
+		charBuffer := make([]byte, 0, 10)
+		strings := make([][]byte, 0, 10)
+		numbers := make([]NumberProps, 0, 10)
+		r := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer,
+			ComputedValuesBuffer: JsonComputedValues{StringValues: &strings, NumberValues: &numbers}})
+		require.Equal(t, ReaderOptions{ComputeStrings: true, ComputeNumbers: true, RawNumbers: true}, r.Options())
+
+		r.PreProcess()
+		require.Equal(t, ReaderOptions{LazyRead: true, ComputeStrings: true, ComputeNumbers: true, MutationCheck: true, RawNumbers: true},
+			r.Options())
+
+		r.Reset(data)
+		require.Equal(t, ReaderOptions{ComputeStrings: true, ComputeNumbers: true, RawNumbers: true}, r.Options())
+	})
+
+	t.Run("setters", func(t *testing.T) {
+		r := NewReader(data)
+		r.SetNumberRawRead(false)
+		r.SetDetectMisuse(true)
+		r.SetInternKeys(true)
+		require.Equal(t, ReaderOptions{DetectMisuse: true, InternKeys: true}, r.Options())
+
+		r.Reset(data)
+		require.Equal(t, ReaderOptions{RawNumbers: true, DetectMisuse: true, InternKeys: true}, r.Options())
+
+		r.SetNormalizeKeys(bytes.ToLower)
+		r.SetDefaultToNull(true)
+		require.Equal(t, ReaderOptions{RawNumbers: true, DetectMisuse: true, InternKeys: true, NormalizeKeys: true,
+			DefaultToNull: true}, r.Options())
+
+		r.SetNormalizeKeys(nil)
+		r.SetDefaultToNull(false)
+		require.Equal(t, ReaderOptions{RawNumbers: true, DetectMisuse: true, InternKeys: true}, r.Options())
+	})
+
+	t.Run("PreProcessWith", func(t *testing.T) {
+		buffer := make([]JsonTreeStruct, 0, 10)
+		charBuffer := make([]byte, 0, 10)
+		strings := make([][]byte, 0, 10)
+		numbers := make([]NumberProps, 0, 10)
+		r := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer,
+			ComputedValuesBuffer: JsonComputedValues{StringValues: &strings, NumberValues: &numbers}})
+		r.PreProcessWith(PreProcessOptions{ComputeStrings: true, DeferStrings: true, ComputeNumbers: true,
+			DeferNumbers: true, DisableMutationCheck: true, BuildIndex: true})
+		require.Equal(t, ReaderOptions{LazyRead: true, ComputeStrings: true, DeferStrings: true, ComputeNumbers: true,
+			DeferNumbers: true, RawNumbers: true}, r.Options())
+
+		r.Reset(data)
+		r.PreProcessWith(PreProcessOptions{ComputeNumbers: true, DeferNumbers: true, BuildIndex: true})
+		require.Equal(t, ReaderOptions{LazyRead: true, ComputeNumbers: true, DeferNumbers: true, MutationCheck: true,
+			RawNumbers: true}, r.Options())
+
+		r.Reset(data)
+		require.Equal(t, ReaderOptions{ComputeStrings: true, ComputeNumbers: true, RawNumbers: true}, r.Options())
+	})
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t,
+			"LazyRead=true ComputeStrings=false DeferStrings=false ComputeNumbers=true DeferNumbers=true "+
+				"MutationCheck=false RawNumbers=false DetectMisuse=false InternKeys=true NormalizeKeys=false DefaultToNull=true",
+			ReaderOptions{LazyRead: true, ComputeNumbers: true, DeferNumbers: true, InternKeys: true,
+				DefaultToNull: true}.String())
+	})
+}
+
+func TestPreProcessWith(t *testing.T) {
+	data := []byte(`{"a":"x\ny","b":[1.5,2]}`)
+	newReader := func() Reader {
+		buffer := make([]JsonTreeStruct, 0, 10)
+		charBuffer := make([]byte, 0, 10)
+		strings := make([][]byte, 0, 10)
+		numbers := make([]NumberProps, 0, 10)
+		return NewReaderWithBuffers(data, BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer,
+			ComputedValuesBuffer: JsonComputedValues{StringValues: &strings, NumberValues: &numbers}})
+	}
+	readAll := func(t *testing.T, r *Reader) (string, []float64) {
+		var s string
+		var numbers []float64
+		for obj := r.Object(); obj.Next(); {
+			switch string(obj.Name()) {
+			case "a":
+				s = string(r.decodedString(nil, r.String()))
+			case "b":
+				for arr := r.Array(); arr.Next(); {
+					numbers = append(numbers, r.Float64())
+				}
+			}
+		}
+		require.NoError(t, r.Error())
+		return s, numbers
+	}
+
+	t.Run("index only", func(t *testing.T) {
+		r := newReader()
+		r.PreProcessWith(PreProcessOptions{BuildIndex: true})
+		require.NoError(t, r.Error())
+		require.Equal(t, ReaderOptions{LazyRead: true, MutationCheck: true, RawNumbers: true}, r.Options())
+		require.True(t, r.IsNumbersRaw())
+
+		s, numbers := readAll(t, &r)
+		assert.Equal(t, "x\ny", s)
+		assert.Equal(t, []float64{1.5, 2}, numbers)
+	})
+
+	t.Run("compute strings only", func(t *testing.T) {
+		r := newReader()
+		r.PreProcessWith(PreProcessOptions{ComputeStrings: true, BuildIndex: true})
+		require.NoError(t, r.Error())
+		require.Equal(t, ReaderOptions{LazyRead: true, ComputeStrings: true, MutationCheck: true, RawNumbers: true}, r.Options())
+
+		s, numbers := readAll(t, &r)
+		assert.Equal(t, "x\ny", s)
+		assert.Equal(t, []float64{1.5, 2}, numbers)
+	})
+
+	t.Run("deferred strings", func(t *testing.T) {
+		doc := []byte(`{"items":[` + strings.Repeat(`"a\tb","plain",`, 50) + `"c\u0041"], "name":"n\"m"}`)
+		structs := make([]JsonTreeStruct, 0, 10)
+		chars := make([]byte, 0, 10)
+		computed := make([][]byte, 0, 10)
+		r := NewReaderWithBuffers(doc, BufferConfig{StructBuffer: &structs, CharsBuffer: &chars,
+			ComputedValuesBuffer: JsonComputedValues{StringValues: &computed}})
+		r.PreProcessWith(PreProcessOptions{ComputeStrings: true, DeferStrings: true, BuildIndex: true})
+		require.NoError(t, r.Error())
+		require.Len(t, computed, 0)
+		require.True(t, r.Options().ComputeStrings)
+
+		var name string
+		obj := r.Object()
+		for obj.Next() {
+			switch string(obj.Name()) {
+			case "items":
+				arr := r.Array()
+				require.True(t, arr.At(1))
+				assert.Equal(t, "plain", string(r.String()))
+				require.True(t, arr.At(100))
+				assert.Equal(t, "cA", string(r.String()))
+				require.True(t, arr.At(2))
+				assert.Equal(t, "a\tb", string(r.String()))
+				require.Len(t, computed, 2)
+
+				require.True(t, arr.At(100))
+				assert.Equal(t, "cA", string(r.String()))
+				require.Len(t, computed, 2)
+				for arr.Next() {
+					_ = r.String()
+				}
+			case "name":
+				name = string(r.String())
+			}
+		}
+		require.NoError(t, r.Error())
+		assert.Equal(t, `n"m`, name)
+		assert.Equal(t, [][]byte{[]byte("cA"), []byte("a\tb"), []byte(`n"m`)}, computed)
+	})
+
+	t.Run("invalid escapes are rejected while pre-processing", func(t *testing.T) {
+		for _, deferStrings := range []bool{false, true} {
+			for _, input := range []string{`["\x"]`, `["\u12"]`, `["a\u12G4"]`, `["\"]`} {
+				t.Run(fmt.Sprintf("%s, deferred: %t", input, deferStrings), func(t *testing.T) {
+					structs := make([]JsonTreeStruct, 0, 10)
+					chars := make([]byte, 0, 10)
+					computed := make([][]byte, 0, 10)
+					r := NewReaderWithBuffers([]byte(input), BufferConfig{StructBuffer: &structs, CharsBuffer: &chars,
+						ComputedValuesBuffer: JsonComputedValues{StringValues: &computed}})
+					r.PreProcessWith(PreProcessOptions{ComputeStrings: true, DeferStrings: deferStrings, BuildIndex: true})
+					assert.Equal(t, SyntaxError{Message: errMsgInvalidString, Offset: 1}, r.Error())
+				})
+			}
+		}
+	})
+
+	t.Run("deferred numbers", func(t *testing.T) {
+		doc := []byte(`[` + strings.Repeat(`1.5,`, 100) + `9007199254740993,-2e3]`)
+		structs := make([]JsonTreeStruct, 0, 10)
+		chars := make([]byte, 0, 10)
+		computed := make([]NumberProps, 0, 10)
+		r := NewReaderWithBuffers(doc, BufferConfig{StructBuffer: &structs, CharsBuffer: &chars,
+			ComputedValuesBuffer: JsonComputedValues{NumberValues: &computed}})
+		r.PreProcessWith(PreProcessOptions{ComputeNumbers: true, DeferNumbers: true, BuildIndex: true})
+		require.NoError(t, r.Error())
+		require.Len(t, computed, 0)
+		require.False(t, r.IsNumbersRaw())
+
+		arr := r.Array()
+		require.True(t, arr.At(100))
+		assert.Equal(t, int64(9007199254740993), r.Int64())
+		require.True(t, arr.At(3))
+		assert.Equal(t, 1.5, r.Float64())
+		require.Len(t, computed, 2)
+
+		require.True(t, arr.At(100))
+		assert.Equal(t, int64(9007199254740993), r.Int64())
+		require.Len(t, computed, 2)
+
+		require.True(t, arr.Next())
+		n := r.NumberProps()
+		require.NotNil(t, n)
+		f, err := n.Float64()
+		require.NoError(t, err)
+		assert.Equal(t, -2000.0, f)
+		require.False(t, arr.Next())
+		require.NoError(t, r.Error())
+		require.Len(t, computed, 3)
+	})
+
+	t.Run("malformed numbers are rejected while pre-processing", func(t *testing.T) {
+		for _, deferNumbers := range []bool{false, true} {
+			for _, input := range []string{`[1.2.3]`, `[01]`, `[1.]`, `[-]`, `[1e]`, `[1-2]`} {
+				t.Run(fmt.Sprintf("%s, deferred: %t", input, deferNumbers), func(t *testing.T) {
+					structs := make([]JsonTreeStruct, 0, 10)
+					chars := make([]byte, 0, 10)
+					computed := make([]NumberProps, 0, 10)
+					r := NewReaderWithBuffers([]byte(input), BufferConfig{StructBuffer: &structs, CharsBuffer: &chars,
+						ComputedValuesBuffer: JsonComputedValues{NumberValues: &computed}})
+					if !deferNumbers {
+						r.SetNumberRawRead(false)
+					}
+					r.PreProcessWith(PreProcessOptions{ComputeNumbers: true, DeferNumbers: deferNumbers, BuildIndex: true})
+					assert.Equal(t, SyntaxError{Message: errMsgInvalidNumber, Offset: 1}, r.Error())
+				})
+			}
+		}
+	})
+
+	t.Run("PreProcess restores the buffer settings", func(t *testing.T) {
+		r := newReader()
+		r.PreProcessWith(PreProcessOptions{BuildIndex: true})
+		r.Reset(data)
+		r.PreProcess()
+		require.Equal(t, ReaderOptions{LazyRead: true, ComputeStrings: true, ComputeNumbers: true, MutationCheck: true, RawNumbers: true},
+			r.Options())
+
+		s, numbers := readAll(t, &r)
+		assert.Equal(t, "x\ny", s)
+		assert.Equal(t, []float64{1.5, 2}, numbers)
+	})
+
+	t.Run("computing without a buffer has no effect", func(t *testing.T) {
+		buffer := make([]JsonTreeStruct, 0, 10)
+		charBuffer := make([]byte, 0, 10)
+		r := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer})
+		r.PreProcessWith(PreProcessOptions{ComputeStrings: true, ComputeNumbers: true, BuildIndex: true})
+		require.Equal(t, ReaderOptions{LazyRead: true, MutationCheck: true, RawNumbers: true}, r.Options())
+
+		s, numbers := readAll(t, &r)
+		assert.Equal(t, "x\ny", s)
+		assert.Equal(t, []float64{1.5, 2}, numbers)
+	})
+
+	t.Run("without an index is a usage error", func(t *testing.T) {
+		r := newReader()
+		r.PreProcessWith(PreProcessOptions{ComputeStrings: true, ComputeNumbers: true})
+		require.Equal(t, UsageError{Message: errMsgPreProcessNoIndex}, r.Error())
+		require.False(t, r.Options().LazyRead)
+		require.Equal(t, 0, r.Consumed())
+	})
+}
+
+func TestPreProcessMutationCheck(t *testing.T) {
+	newReader := func(data []byte, opts PreProcessOptions) Reader {
+		buffer := make([]JsonTreeStruct, 0, 10)
+		charBuffer := make([]byte, 0, 10)
+		r := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer})
+		r.PreProcessWith(opts)
+		require.NoError(t, r.Error())
+		return r
+	}
+	long := `{"a":"` + strings.Repeat("x", 200) + `","b":[1,2,3]}`
+
+	t.Run("unchanged input", func(t *testing.T) {
+		r := newReader([]byte(long), PreProcessOptions{BuildIndex: true})
+		require.NoError(t, r.SkipValue())
+		require.NoError(t, r.Error())
+	})
+
+	for _, doc := range []string{`{"a":"xyz","b":[1,2,3]}`, long} {
+		t.Run("input changed at end", func(t *testing.T) {
+			data := []byte(doc)
+			r := newReader(data, PreProcessOptions{BuildIndex: true})
+			copy(data[len(data)-8:], `[9,8,7]`)
+
+			r.Object()
+			var se StateError
+			require.ErrorAs(t, r.Error(), &se)
+			assert.Equal(t, errMsgInputChanged, se.Message)
+			assert.Equal(t, 0, se.Offset)
+		})
+	}
+
+	t.Run("input changed at start", func(t *testing.T) {
+		data := []byte(long)
+		r := newReader(data, PreProcessOptions{BuildIndex: true})
+		copy(data, `[     `)
+
+		r.Array()
+		require.ErrorAs(t, r.Error(), &StateError{})
+	})
+
+	t.Run("input length changed", func(t *testing.T) {
+		data := []byte(`{"a":"xyz","b":[1,2,3]} `)
+		r := newReader(data, PreProcessOptions{BuildIndex: true})
+		r.tr.data = data[:len(data)-1]
+		r.tr.len = len(r.tr.data)
+
+		r.Object()
+		assert.Equal(t, StateError{Message: errMsgInputChanged, Offset: 0}, r.Error())
+	})
+
+	t.Run("only checked on the first read", func(t *testing.T) {
+		data := []byte(`{"a":"xyz","b":[1,2,3]}`)
+		r := newReader(data, PreProcessOptions{BuildIndex: true})
+		obj := r.Object()
+		require.NoError(t, r.Error())
+		copy(data[len(data)-8:], `[9,8,7]`)
+
+		var values []int64
+		for obj.Next() {
+			if string(obj.Name()) == "b" {
+				for arr := r.Array(); arr.Next(); {
+					values = append(values, r.Int64())
+				}
+			} else {
+				require.NoError(t, r.SkipValue())
+			}
+		}
+		require.NoError(t, r.Error())
+		assert.Equal(t, []int64{9, 8, 7}, values)
+	})
+
+	t.Run("check disabled", func(t *testing.T) {
+		data := []byte(`{"a":"xyz","b":[1,2,3]}`)
+		r := newReader(data, PreProcessOptions{BuildIndex: true, DisableMutationCheck: true})
+		copy(data[len(data)-8:], `[9,8,7]`)
+
+		var values []int64
+		for obj := r.Object(); obj.Next(); {
+			if string(obj.Name()) == "b" {
+				for arr := r.Array(); arr.Next(); {
+					values = append(values, r.Int64())
+				}
+			} else {
+				require.NoError(t, r.SkipValue())
+			}
+		}
+		require.NoError(t, r.Error())
+		assert.Equal(t, []int64{9, 8, 7}, values)
+	})
+}
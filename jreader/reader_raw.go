@@ -0,0 +1,136 @@
+package jreader
+
+import "io"
+
+// RawMessage is the undecoded text of a single JSON value, exactly as it appeared in the input,
+// without any leading or trailing whitespace. It is equivalent to json.RawMessage.
+type RawMessage []byte
+
+// readRawValue consumes the next JSON value and returns its text. The returned slice refers to the
+// input data, so it must be copied if it is to be retained. If there is an error, the return value
+// is nil and the Reader enters a failed state.
+func (r *Reader) readRawValue() []byte {
+	if r.err != nil {
+		return nil
+	}
+	if r.tr.options.lazyRead {
+		node, err := r.tr.currentNode()
+		if err != nil {
+			r.err = err
+			return nil
+		}
+		if err := r.SkipValue(); err != nil {
+			r.AddError(err)
+			return nil
+		}
+		return r.tr.data[node.start:node.end]
+	}
+	start := r.tr.pos
+	if r.tr.hasUnread {
+		start = r.tr.lastPos
+	}
+	start = skipRawWhitespace(r.tr.data, start)
+	if err := r.SkipValue(); err != nil {
+		r.AddError(err)
+		return nil
+	}
+	return r.tr.data[start:r.tr.pos]
+}
+
+// ParsePrefix parses one complete JSON value at the start of data, after any whitespace, and ignores
+// whatever follows it. It returns the text of the value, and the number of bytes of data up to the
+// end of the value, so that data[consumed:] is the rest of the input. This is useful for extracting
+// a value that is embedded in other text, such as the JSON payload in a log line:
+//
+//	// line is `{"a":1} duration=3ms`, after the text before the payload
+//	value, consumed, err := jreader.ParsePrefix(line)
+//	// value is `{"a":1}`, and line[consumed:] is ` duration=3ms`
+//
+// Unlike reading a value and then calling RequireEOF, this does not treat data after the value as an
+// error. However, since a number or a literal such as true has no closing delimiter, one that is
+// immediately followed by a letter or digit, as in 3ms, is a SyntaxError, because the text cannot be
+// split into a value and what follows it unambiguously.
+//
+// The returned value refers to data without copying it. If the value is not well-formed, including
+// if a string in it has an invalid escape sequence, the return values are nil, 0, and a SyntaxError.
+// If data ends in the middle of the value, the error is io.ErrUnexpectedEOF: this applies to an
+// unfinished array or object, and also to an unterminated string such as "abc, a literal that is
+// cut short such as tru, and a number that cannot end where it does such as 12. or -. A number
+// that could end where data ends, such as 12, is a complete value. If data has nothing but
+// whitespace, the error is io.EOF.
+func ParsePrefix(data []byte) (value RawMessage, consumed int, err error) {
+	r := NewReader(data)
+	r.SetNumberRawRead(false) // parse numbers strictly, since there is no delimiter to check them against
+	start := skipRawWhitespace(data, 0)
+	raw := r.readRawValue()
+	if r.err != nil {
+		if r.err == io.EOF && start < len(data) {
+			return nil, 0, io.ErrUnexpectedEOF
+		}
+		if se, ok := r.err.(SyntaxError); ok && se.Offset < len(data) && isTruncatedToken(data[se.Offset:]) {
+			return nil, 0, io.ErrUnexpectedEOF
+		}
+		return nil, 0, r.err
+	}
+	// Strings are scanned without decoding them, so their escape sequences have not been checked.
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '"' {
+			end := skipRawString(raw, i)
+			if !validEscapes(raw[i+1 : end-1]) {
+				return nil, 0, SyntaxError{Message: errMsgInvalidString, Offset: start + i}
+			}
+			i = end - 1
+		}
+	}
+	return raw, r.Consumed(), nil
+}
+
+// isTruncatedToken returns true if text, which runs to the end of the input, is the beginning of a
+// string, number, or literal that the input ends in the middle of.
+func isTruncatedToken(text []byte) bool {
+	switch {
+	case len(text) == 0:
+		return false
+	case text[0] == '"':
+		return isTruncatedString(text[1:])
+	case text[0] == '-' || (text[0] >= '0' && text[0] <= '9'):
+		// the number is cut short if one more digit would complete it
+		end, ok := scanNumber(append(text[:len(text):len(text)], '0'), 0)
+		return ok && end == len(text)+1
+	}
+	for _, literal := range []string{"true", "false", "null"} {
+		if len(text) < len(literal) && literal[:len(text)] == string(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTruncatedString returns true if content, the input after the opening quote of a string, has no
+// closing quote and no invalid escape sequence, except for one that is cut short by the end.
+func isTruncatedString(content []byte) bool {
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '"':
+			return false
+		case '\\':
+			if i+1 == len(content) {
+				return true
+			}
+			i++
+			switch content[i] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			case 'u':
+				for j := i + 1; j < len(content) && j <= i+4; j++ {
+					if !isHexDigit(content[j]) {
+						return false
+					}
+				}
+				i += 4
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package jreader
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collectStreamArray(r *Reader) ([]string, error) {
+	out := make(chan RawMessage)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(out)
+		errCh <- r.StreamArray(out)
+	}()
+	var elements []string
+	for element := range out {
+		elements = append(elements, string(element))
+	}
+	return elements, <-errCh
+}
+
+func TestStreamArray(t *testing.T) {
+	data := `[ 1, "a\"b" ,{"x": [true, null]},[ ],  -2.5e3 ]`
+	expected := []string{`1`, `"a\"b"`, `{"x": [true, null]}`, `[ ]`, `-2.5e3`}
+
+	t.Run("direct mode", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		elements, err := collectStreamArray(&r)
+		require.NoError(t, err)
+		assert.Equal(t, expected, elements)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		elements, err := collectStreamArray(&r)
+		require.NoError(t, err)
+		assert.Equal(t, expected, elements)
+	})
+
+	t.Run("elements are copied", func(t *testing.T) {
+		input := []byte(`["abc"]`)
+		r := NewReader(input)
+		elements, err := collectStreamArray(&r)
+		require.NoError(t, err)
+		input[2] = 'x'
+		assert.Equal(t, []string{`"abc"`}, elements)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		r := NewReader([]byte(`[1, 2 3]`))
+		elements, err := collectStreamArray(&r)
+		require.IsType(t, SyntaxError{}, err)
+		assert.Equal(t, []string{`1`, `2`}, elements)
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		elements, err := collectStreamArray(&r)
+		require.IsType(t, TypeError{}, err)
+		assert.Nil(t, elements)
+	})
+}
+
+func TestParsePrefix(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		value    string
+		consumed int
+	}{
+		{`{"a":1} duration=3ms`, `{"a":1}`, 7},
+		{`[1, "x"]garbage`, `[1, "x"]`, 8},
+		{"  \"s\"  \t trailing", `"s"`, 5},
+		{"-12.5e3, more", "-12.5e3", 7},
+		{"true)", "true", 4},
+		{"null", "null", 4},
+	} {
+		t.Run(test.input, func(t *testing.T) {
+			value, consumed, err := ParsePrefix([]byte(test.input))
+			require.NoError(t, err)
+			assert.Equal(t, test.value, string(value))
+			assert.Equal(t, test.consumed, consumed)
+		})
+	}
+
+	for _, input := range []string{`{"a":`, `[1, 2`, `"abc`} {
+		t.Run("truncated "+input, func(t *testing.T) {
+			value, consumed, err := ParsePrefix([]byte(input))
+			require.Error(t, err)
+			assert.Nil(t, value)
+			assert.Equal(t, 0, consumed)
+		})
+	}
+	for _, input := range []string{`{"a":[1`, `"abc`, `"ab\`, `"\u12`, `["a\"`, `tru`, `[fa`, `{"a":n`,
+		`12.`, `-`, `1e`, `[1.5e+`} {
+		t.Run("ends inside "+input, func(t *testing.T) {
+			_, _, err := ParsePrefix([]byte(input))
+			assert.Equal(t, io.ErrUnexpectedEOF, err)
+		})
+	}
+
+	for _, input := range []string{"3ms", "truex", "tx", "nul1", "1.2.", "01", `"\x`, `{"a" 1}`} {
+		t.Run("invalid "+input, func(t *testing.T) {
+			_, _, err := ParsePrefix([]byte(input))
+			require.IsType(t, SyntaxError{}, err)
+		})
+	}
+
+	for _, input := range []string{`"\x" rest`, `["a", "\u12G4"]`} {
+		t.Run("invalid escape in "+input, func(t *testing.T) {
+			value, consumed, err := ParsePrefix([]byte(input))
+			require.IsType(t, SyntaxError{}, err)
+			assert.Equal(t, errMsgInvalidString, err.(SyntaxError).Message)
+			assert.Nil(t, value)
+			assert.Equal(t, 0, consumed)
+		})
+	}
+
+	_, _, err := ParsePrefix([]byte("  "))
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReaderTail(t *testing.T) {
+	r := NewReader([]byte(`[1, 2] rest`))
+	for arr := r.Array(); arr.Next(); {
+		r.Int64()
+	}
+	require.NoError(t, r.Error())
+	assert.Equal(t, " rest", string(r.Tail()))
+
+	lazy := newLazyReaderForTest(t, `{"a":[1]}`)
+	assert.Equal(t, `{"a":[1]}`, string(lazy.Tail()))
+	for obj := lazy.Object(); obj.Next(); {
+		assert.Equal(t, "[1]}", string(lazy.Tail()))
+		require.NoError(t, lazy.SkipValue())
+	}
+	assert.Equal(t, "", string(lazy.Tail()))
+}
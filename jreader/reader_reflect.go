@@ -0,0 +1,345 @@
+package jreader
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var readableType = reflect.TypeOf((*Readable)(nil)).Elem() //nolint:gochecknoglobals
+
+var structFieldsCache sync.Map //nolint:gochecknoglobals
+
+type structFields struct {
+	matcher *FieldMatcher
+	indexes [][]int
+}
+
+// DecodeWithDefaults reads a JSON object into the struct that target points to, using defaults as
+// the starting point: target is first set to a copy of defaults (which may be either a struct of the
+// same type or a pointer to one), and then each property that is present in the JSON object
+// overrides the corresponding field. Fields whose properties are absent keep their default values.
+//
+//	defaultConfig := Config{Port: 8080, Host: "localhost"}
+//	var config Config
+//	err := r.DecodeWithDefaults(&config, defaultConfig)
+//
+// Unlike the Reader's other methods, this uses reflection, so it is much slower than reading the
+// properties one at a time; it is meant for things like configuration data where convenience
+// matters more than speed.
+//
+// Property names are mapped to struct fields as in encoding/json: the name is taken from the
+// field's "json" tag if it has one, fields tagged "-" and unexported fields are ignored, and the
+// fields of untagged embedded structs are treated as fields of the outer struct. Unlike
+// encoding/json, names must match exactly. Nested structs are also decoded over their default
+// values, while maps and slices that are present in the JSON replace the default ones entirely; the
+// defaults are never modified. A JSON null sets a pointer, map, slice, or interface field to nil,
+// and leaves any other field unchanged. A field whose type implements Readable is read by calling
+// its ReadFromJSONReader method.
+//
+// If there is a parsing error, or a JSON value does not match the type of its field, the target is
+// not modified and the Reader enters a failed state; the error is also returned. If target is not a
+// non-nil pointer to a struct, or defaults is not of the same struct type, the error is a
+// UsageError.
+func (r *Reader) DecodeWithDefaults(target any, defaults any) error {
+	if r.err != nil {
+		return r.err
+	}
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Pointer || targetValue.IsNil() || targetValue.Elem().Kind() != reflect.Struct {
+		r.err = UsageError{Message: errMsgBadDecodeTarget, Offset: r.tr.getPos()}
+		return r.err
+	}
+	defaultsValue := reflect.Indirect(reflect.ValueOf(defaults))
+	if !defaultsValue.IsValid() || defaultsValue.Type() != targetValue.Elem().Type() {
+		r.err = UsageError{Message: errMsgBadDecodeDefaults, Offset: r.tr.getPos()}
+		return r.err
+	}
+	result := reflect.New(defaultsValue.Type()).Elem()
+	result.Set(defaultsValue)
+	r.decodeReflect(result)
+	if r.err != nil {
+		return r.err
+	}
+	targetValue.Elem().Set(result)
+	return nil
+}
+
+func (r *Reader) decodeReflect(v reflect.Value) {
+	if r.decoder != nil && r.decoder.decodeCustom(r, v) {
+		return
+	}
+	if v.Kind() == reflect.Pointer && v.Type().Implements(readableType) {
+		// A null sets the pointer to nil, as it would for a pointer to any other type, rather than
+		// being passed to ReadFromJSONReader.
+		if kind, _, ok := r.peekKind(); ok && kind == NullValue && r.err == nil {
+			_ = r.Null()
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		p := newPointerCopy(v)
+		p.Interface().(Readable).ReadFromJSONReader(r)
+		v.Set(p)
+		return
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(readableType) {
+		v.Addr().Interface().(Readable).ReadFromJSONReader(r)
+		return
+	}
+	anyVal := r.Any()
+	if r.err != nil {
+		return
+	}
+	// The AnyValue is a buffer that the next call to Any will overwrite, so it must be copied before
+	// we recurse into an array or object.
+	val := *anyVal
+	r.decodeAnyValue(&val, v)
+}
+
+func (r *Reader) decodeAnyValue(val *AnyValue, v reflect.Value) {
+	if val.Kind == NullValue {
+		switch v.Kind() { //nolint:exhaustive
+		case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return
+	}
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.Pointer:
+		p := newPointerCopy(v)
+		r.decodeAnyValue(val, p.Elem())
+		v.Set(p)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			r.err = UsageError{Message: errMsgBadDecodeType + v.Type().String(), Offset: r.tr.getPos()}
+			return
+		}
+		if result := r.decodeInterface(val); r.err == nil {
+			v.Set(reflect.ValueOf(result))
+		}
+	case reflect.Bool:
+		if r.requireKind(val, BoolValue) {
+			v.SetBool(val.Bool)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if r.requireKind(val, NumberValue) {
+			n, err := r.numberToInt64(&val.Number)
+			if err != nil || v.OverflowInt(n) {
+				r.err = r.numberRangeError(&val.Number, v.Type())
+				return
+			}
+			v.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if r.requireKind(val, NumberValue) {
+			n, err := r.numberToUInt64(&val.Number)
+			if err != nil || v.OverflowUint(n) {
+				r.err = r.numberRangeError(&val.Number, v.Type())
+				return
+			}
+			v.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		if r.requireKind(val, NumberValue) {
+			n, err := r.numberToFloat64(&val.Number)
+			if err != nil || v.OverflowFloat(n) {
+				r.err = r.numberRangeError(&val.Number, v.Type())
+				return
+			}
+			v.SetFloat(n)
+		}
+	case reflect.String:
+		if r.requireKind(val, StringValue) {
+			v.SetString(string(r.decodedString(nil, val.String)))
+		}
+	case reflect.Struct:
+		if r.requireKind(val, ObjectValue) {
+			r.decodeStruct(&val.Object, v)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			r.err = UsageError{Message: errMsgBadDecodeType + v.Type().String(), Offset: r.tr.getPos()}
+			return
+		}
+		if r.requireKind(val, ObjectValue) {
+			m := reflect.MakeMap(v.Type())
+			for val.Object.Next() {
+				key := reflect.ValueOf(string(r.decodedString(nil, val.Object.Name()))).Convert(v.Type().Key())
+				elem := reflect.New(v.Type().Elem()).Elem()
+				mark := r.pushDecodePath(val.Object.Name(), -1)
+				r.decodeReflect(elem)
+				r.decodePath = r.decodePath[:mark]
+				m.SetMapIndex(key, elem)
+			}
+			if r.err == nil {
+				v.Set(m)
+			}
+		}
+	case reflect.Slice:
+		if r.requireKind(val, ArrayValue) {
+			s := reflect.MakeSlice(v.Type(), 0, 0)
+			for i := 0; val.Array.Next(); i++ {
+				elem := reflect.New(v.Type().Elem()).Elem()
+				mark := r.pushDecodePath(nil, i)
+				r.decodeReflect(elem)
+				r.decodePath = r.decodePath[:mark]
+				s = reflect.Append(s, elem)
+			}
+			if r.err == nil {
+				v.Set(s)
+			}
+		}
+	case reflect.Array:
+		if r.requireKind(val, ArrayValue) {
+			i := 0
+			for val.Array.Next() {
+				if i < v.Len() {
+					mark := r.pushDecodePath(nil, i)
+					r.decodeReflect(v.Index(i))
+					r.decodePath = r.decodePath[:mark]
+				}
+				i++
+			}
+			for ; i < v.Len(); i++ {
+				v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+			}
+		}
+	default:
+		r.err = UsageError{Message: errMsgBadDecodeType + v.Type().String(), Offset: r.tr.getPos()}
+	}
+}
+
+func (r *Reader) decodeStruct(obj *ObjectState, v reflect.Value) {
+	fields := cachedStructFields(v.Type())
+	for obj.Next() {
+		mark := r.pushDecodePath(obj.Name(), -1)
+		if i := fields.matcher.Match(obj.Name()); i >= 0 {
+			r.decodeReflect(v.FieldByIndex(fields.indexes[i]))
+		} else if r.decoder != nil {
+			r.decoder.unknownField(r, obj.Name())
+		}
+		r.decodePath = r.decodePath[:mark]
+	}
+}
+
+// pushDecodePath adds a property name, or an array index if name is nil, to the JSON Pointer of the
+// value that is being decoded, and returns the previous length of the pointer. The pointer is only
+// maintained if a Decoder needs it for an UnknownFieldError.
+func (r *Reader) pushDecodePath(name []byte, index int) int {
+	mark := len(r.decodePath)
+	if r.decoder == nil || !r.decoder.unknownFields.disallow {
+		return mark
+	}
+	r.decodePath = append(r.decodePath, '/')
+	if name != nil {
+		r.decodePath = appendPointerToken(r.decodePath, name)
+	} else {
+		r.decodePath = strconv.AppendInt(r.decodePath, int64(index), 10)
+	}
+	return mark
+}
+
+// decodeInterface converts a JSON value to the same Go types that encoding/json uses for an
+// interface{} target.
+func (r *Reader) decodeInterface(val *AnyValue) any {
+	switch val.Kind {
+	case BoolValue:
+		return val.Bool
+	case NumberValue:
+		n, err := r.numberToFloat64(&val.Number)
+		if err != nil {
+			r.err = err
+		}
+		return n
+	case StringValue:
+		return string(r.decodedString(nil, val.String))
+	case ArrayValue:
+		result := []any{}
+		for val.Array.Next() {
+			var elem any
+			r.decodeReflect(reflect.ValueOf(&elem).Elem())
+			result = append(result, elem)
+		}
+		return result
+	case ObjectValue:
+		result := map[string]any{}
+		for val.Object.Next() {
+			name := string(r.decodedString(nil, val.Object.Name()))
+			var elem any
+			r.decodeReflect(reflect.ValueOf(&elem).Elem())
+			result[name] = elem
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+func (r *Reader) requireKind(val *AnyValue, expected ValueKind) bool {
+	if val.Kind == expected {
+		return true
+	}
+	r.err = TypeError{Expected: expected, Actual: val.Kind, Offset: r.tr.getPos()}
+	return false
+}
+
+func (r *Reader) numberRangeError(n *NumberProps, t reflect.Type) error {
+	return ValueError{Message: "number cannot be stored in a value of type " + t.String(), Got: string(n.raw),
+		Offset: r.tr.getPos()}
+}
+
+// newPointerCopy returns a pointer to a new value that is a copy of the value that v points to, or
+// the zero value if v is nil. This lets us decode over a default value without modifying it.
+func newPointerCopy(v reflect.Value) reflect.Value {
+	p := reflect.New(v.Type().Elem())
+	if !v.IsNil() {
+		p.Elem().Set(v.Elem())
+	}
+	return p
+}
+
+func cachedStructFields(t reflect.Type) *structFields {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		return cached.(*structFields)
+	}
+	var names []string
+	var indexes [][]int
+	seen := make(map[string]bool)
+	addStructFields(t, nil, seen, &names, &indexes)
+	fields := &structFields{matcher: NewFieldMatcher(names), indexes: indexes}
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+func addStructFields(t reflect.Type, parentIndex []int, seen map[string]bool, names *[]string, indexes *[][]int) {
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, f)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		*names = append(*names, name)
+		*indexes = append(*indexes, append(append([]int(nil), parentIndex...), i))
+	}
+	// Fields of the outer struct take precedence over promoted fields with the same name.
+	for _, f := range embedded {
+		addStructFields(f.Type, append(append([]int(nil), parentIndex...), f.Index...), seen, names, indexes)
+	}
+}
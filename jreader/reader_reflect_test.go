@@ -0,0 +1,149 @@
+package jreader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type defaultsTestLimits struct {
+	Max  int     `json:"max"`
+	Rate float64 `json:"rate"`
+}
+
+type defaultsTestBase struct {
+	Name string `json:"name"`
+}
+
+type defaultsTestConfig struct {
+	defaultsTestBase
+	Host    string            `json:"host"`
+	Port    uint16            `json:"port"`
+	Debug   bool              `json:"debug"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels"`
+	Limits  defaultsTestLimits
+	Timeout *int           `json:"timeout,omitempty"`
+	Extra   interface{}    `json:"extra"`
+	Ignored string         `json:"-"`
+	Custom  readableValue  `json:"custom"`
+	Pointer *readableValue `json:"pointer"`
+	hidden  string
+}
+
+type readableValue struct {
+	value string
+}
+
+func (v *readableValue) ReadFromJSONReader(r *Reader) {
+	v.value = "custom:" + string(r.String())
+}
+
+func TestDecodeWithDefaults(t *testing.T) {
+	timeout := 30
+	defaults := defaultsTestConfig{
+		defaultsTestBase: defaultsTestBase{Name: "default"},
+		Host:             "localhost",
+		Port:             8080,
+		Tags:             []string{"a"},
+		Labels:           map[string]string{"env": "dev"},
+		Limits:           defaultsTestLimits{Max: 10, Rate: 0.5},
+		Timeout:          &timeout,
+		Ignored:          "keep",
+		Pointer:          &readableValue{value: "default"},
+		hidden:           "keep",
+	}
+
+	t.Run("some fields present", func(t *testing.T) {
+		r := NewReader([]byte(`{"port": 9090, "debug": true, "Limits": {"max": 20}, "timeout": 5,
+			"unknown": [1, {"x": null}], "name": "svc", "Ignored": "no", "custom": "x", "pointer": "y"}`))
+		var config defaultsTestConfig
+		require.NoError(t, r.DecodeWithDefaults(&config, defaults))
+		require.NoError(t, r.RequireEOF())
+
+		assert.Equal(t, "svc", config.Name)
+		assert.Equal(t, "localhost", config.Host)
+		assert.Equal(t, uint16(9090), config.Port)
+		assert.True(t, config.Debug)
+		assert.Equal(t, []string{"a"}, config.Tags)
+		assert.Equal(t, map[string]string{"env": "dev"}, config.Labels)
+		assert.Equal(t, defaultsTestLimits{Max: 20, Rate: 0.5}, config.Limits)
+		require.NotNil(t, config.Timeout)
+		assert.Equal(t, 5, *config.Timeout)
+		assert.Equal(t, "keep", config.Ignored)
+		assert.Equal(t, "keep", config.hidden)
+		assert.Equal(t, "custom:x", config.Custom.value)
+		assert.Equal(t, &readableValue{value: "custom:y"}, config.Pointer)
+		assert.Equal(t, "default", defaults.Pointer.value, "default pointer target must not be modified")
+
+		assert.Equal(t, 30, timeout, "default pointer target must not be modified")
+	})
+
+	t.Run("no fields present", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		var config defaultsTestConfig
+		require.NoError(t, r.DecodeWithDefaults(&config, &defaults))
+		assert.Equal(t, defaults, config)
+	})
+
+	t.Run("maps and slices are replaced", func(t *testing.T) {
+		r := NewReader([]byte(`{"tags": ["b", "c"], "labels": {"region": "eu"}}`))
+		var config defaultsTestConfig
+		require.NoError(t, r.DecodeWithDefaults(&config, defaults))
+		assert.Equal(t, []string{"b", "c"}, config.Tags)
+		assert.Equal(t, map[string]string{"region": "eu"}, config.Labels)
+		assert.Equal(t, map[string]string{"env": "dev"}, defaults.Labels)
+	})
+
+	t.Run("nulls", func(t *testing.T) {
+		r := NewReader([]byte(`{"host": null, "tags": null, "timeout": null, "pointer": null}`))
+		var config defaultsTestConfig
+		require.NoError(t, r.DecodeWithDefaults(&config, defaults))
+		assert.Equal(t, "localhost", config.Host)
+		assert.Nil(t, config.Tags)
+		assert.Nil(t, config.Timeout)
+		assert.Nil(t, config.Pointer)
+	})
+
+	t.Run("interface field", func(t *testing.T) {
+		r := NewReader([]byte(`{"extra": {"a": [1, "x", true, null]}}`))
+		var config defaultsTestConfig
+		require.NoError(t, r.DecodeWithDefaults(&config, defaults))
+		assert.Equal(t, map[string]interface{}{"a": []interface{}{1.0, "x", true, nil}}, config.Extra)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		r := NewReader([]byte(`{"host": "example", "port": "80"}`))
+		config := defaultsTestConfig{Host: "unchanged"}
+		err := r.DecodeWithDefaults(&config, defaults)
+		require.IsType(t, TypeError{}, err)
+		assert.Equal(t, StringValue, err.(TypeError).Actual)
+		assert.Equal(t, "unchanged", config.Host)
+		assert.Equal(t, err, r.Error())
+	})
+
+	t.Run("number out of range", func(t *testing.T) {
+		r := NewReader([]byte(`{"port": 70000}`))
+		var config defaultsTestConfig
+		require.IsType(t, ValueError{}, r.DecodeWithDefaults(&config, defaults))
+	})
+
+	t.Run("bad arguments", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		var config defaultsTestConfig
+		require.IsType(t, UsageError{}, r.DecodeWithDefaults(config, defaults))
+
+		r = NewReader([]byte(`{}`))
+		require.IsType(t, UsageError{}, r.DecodeWithDefaults(&config, defaultsTestLimits{}))
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"host": "example", "Limits": {"rate": 1.5}, "tags": []}`)
+		var config defaultsTestConfig
+		require.NoError(t, r.DecodeWithDefaults(&config, defaults))
+		assert.Equal(t, "example", config.Host)
+		assert.Equal(t, defaultsTestLimits{Max: 10, Rate: 1.5}, config.Limits)
+		assert.Equal(t, []string{}, config.Tags)
+	})
+}
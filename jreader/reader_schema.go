@@ -0,0 +1,273 @@
+package jreader
+
+// ObjectSchema describes the properties that Reader.ReadObjectWithSchema expects in a JSON object.
+type ObjectSchema struct {
+	// RequiredFields is the names of the properties that must be present.
+	RequiredFields []string
+
+	// AllowedFields, if not nil, is the names of the properties that may be present; any other
+	// property is a violation. Properties that are named in RequiredFields or FieldTypes are
+	// always allowed. If AllowedFields is nil, any property is allowed.
+	AllowedFields []string
+
+	// FieldTypes maps property names to the kind of value that the property must have, if it is
+	// present. A null value only matches NullValue.
+	FieldTypes map[string]ValueKind
+}
+
+// ReadObjectWithSchema reads a JSON object and checks it against a schema: all of the required
+// properties must be present, no properties may appear that the schema does not allow, and each
+// property with a declared type must have a value of that type. The property values are not
+// decoded; this is meant for validating a document before, or instead of, reading it in detail, for
+// instance with a Reader created for the same data.
+//
+// Unlike most Reader methods, this does not stop at the first problem: if the object does not match
+// the schema, the error is a ValidationError listing every violation, and the Reader enters a
+// failed state. If there is a parsing error, or the next value is not an object, the error is that
+// error instead.
+//
+// Property names are compared after any escape sequences in them are decoded.
+func (r *Reader) ReadObjectWithSchema(schema ObjectSchema) error {
+	obj := r.Object()
+	if !obj.IsDefined() {
+		return r.err
+	}
+	start := r.tr.LastPos()
+	present := NewFieldPresence(NewFieldMatcher(schema.RequiredFields))
+	obj.TrackPresence(present)
+	var violations []SchemaViolation
+	var nameBuf []byte
+	for obj.Next() {
+		var name []byte
+		name, nameBuf = decodeName(nameBuf, obj.Name())
+		offset := r.tr.LastPos()
+		kind, err := r.SkipValueKind()
+		if err != nil {
+			return err
+		}
+		if !schema.allows(name) {
+			violations = append(violations, SchemaViolation{Property: string(name), Reason: UnknownProperty,
+				Offset: offset})
+			continue
+		}
+		if expected, ok := schema.FieldTypes[string(name)]; ok && kind != expected {
+			violations = append(violations, SchemaViolation{Property: string(name), Reason: WrongPropertyType,
+				Expected: expected, Actual: kind, Offset: offset})
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	for _, name := range present.MissingNames() {
+		violations = append(violations, SchemaViolation{Property: name, Reason: MissingProperty,
+			Offset: r.tr.LastPos()})
+	}
+	if len(violations) != 0 {
+		r.err = ValidationError{Violations: violations, Offset: start}
+	}
+	return r.err
+}
+
+// Field describes one property of a JSON object for Reader.DecodeFields.
+type Field struct {
+	// Name is the property name.
+	Name string
+
+	// Required is true if the property must be present.
+	Required bool
+
+	// Kind is the kind of value that the property must have, if CheckKind is true. A null value
+	// only matches NullValue.
+	Kind ValueKind
+
+	// CheckKind enables the check of Kind. This is a separate field because the zero value of
+	// ValueKind, NullValue, is itself a valid kind.
+	CheckKind bool
+
+	// Bind is called to read the property value, if it is present and of the right kind. It should
+	// read the value with the Reader that is passed to it; if it does not, the value is skipped. If
+	// Bind is nil, the value is always skipped.
+	Bind func(r *Reader)
+}
+
+// DecodeFields reads a JSON object whose properties are described by fields, calling the Bind
+// function of each field whose property is present. This combines the checks of
+// ReadObjectWithSchema with decoding, in a single pass over the object:
+//
+//	err := r.DecodeFields([]jreader.Field{
+//	    {Name: "id", Required: true, Kind: jreader.NumberValue, CheckKind: true,
+//	        Bind: func(r *jreader.Reader) { item.ID = r.Int64() }},
+//	    {Name: "name", Bind: func(r *jreader.Reader) { item.Name = string(r.String()) }},
+//	})
+//
+// As with ReadObjectWithSchema, problems with the object do not stop it from being read: a property
+// that is not in fields, or whose value is not of the declared kind, is skipped, and once the whole
+// object has been consumed, the error is a ValidationError listing every such property and every
+// required property that was missing, and the Reader enters a failed state. Property names are
+// compared after any escape sequences in them are decoded. If a property appears more than once,
+// Bind is called for each occurrence.
+//
+// If there is a parsing error, or the next value is not an object, or a Bind function causes an
+// error, the Reader enters a failed state and the error is that error instead; in that case, the
+// rest of the object is not read.
+func (r *Reader) DecodeFields(fields []Field) error {
+	_, start, _ := r.peekKind()
+	obj := r.Object()
+	if !obj.IsDefined() {
+		return r.err
+	}
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+	matcher := NewFieldMatcher(names)
+	present := make([]bool, len(fields))
+	var violations []SchemaViolation
+	for obj.Next() {
+		i := matcher.Match(obj.Name())
+		kind, offset, _ := r.peekKind()
+		switch {
+		case i < 0:
+			name, _ := decodeName(nil, obj.Name())
+			violations = append(violations, SchemaViolation{Property: string(name), Reason: UnknownProperty,
+				Offset: offset})
+		case fields[i].CheckKind && kind != fields[i].Kind:
+			present[i] = true
+			violations = append(violations, SchemaViolation{Property: fields[i].Name, Reason: WrongPropertyType,
+				Expected: fields[i].Kind, Actual: kind, Offset: offset})
+		default:
+			present[i] = true
+			if fields[i].Bind != nil {
+				fields[i].Bind(r)
+			}
+		}
+		if r.err != nil {
+			return r.err
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	for i, f := range fields {
+		if f.Required && !present[i] {
+			violations = append(violations, SchemaViolation{Property: f.Name, Reason: MissingProperty,
+				Offset: r.tr.LastPos()})
+		}
+	}
+	if len(violations) != 0 {
+		r.err = ValidationError{Violations: violations, Offset: start}
+	}
+	return r.err
+}
+
+func (s ObjectSchema) allows(name []byte) bool {
+	if s.AllowedFields == nil {
+		return true
+	}
+	if _, ok := s.FieldTypes[string(name)]; ok {
+		return true
+	}
+	for _, allowed := range s.AllowedFields {
+		if string(name) == allowed {
+			return true
+		}
+	}
+	for _, required := range s.RequiredFields {
+		if string(name) == required {
+			return true
+		}
+	}
+	return false
+}
+
+// ArraySchema describes the constraints that Reader.ReadArrayWithSchema applies to a JSON array.
+type ArraySchema struct {
+	// MinLen is the minimum number of elements.
+	MinLen int
+
+	// MaxLen, if greater than zero, is the maximum number of elements.
+	MaxLen int
+
+	// ElementKind is the kind of value that every element must have, if CheckElementKind is true.
+	// A null element only matches NullValue.
+	ElementKind ValueKind
+
+	// CheckElementKind enables the check of ElementKind. This is a separate field because the zero
+	// value of ValueKind, NullValue, is itself a valid kind.
+	CheckElementKind bool
+}
+
+// ReadArrayWithSchema reads a JSON array and checks it against a schema, calling fn to read each
+// element that is valid; fn must read the element with the Reader that is passed to it, or it can
+// ignore it, in which case the element is skipped. Elements that are of the wrong kind, or that are
+// beyond the maximum length, are skipped without calling fn. If fn is nil, all elements are
+// skipped.
+//
+// As with ReadObjectWithSchema, problems with the array are not reported until the whole array has
+// been consumed: the error is then a ValidationError listing every violation, and the Reader enters
+// a failed state. If there is a parsing error, or the next value is not an array, or fn returns an
+// error, the Reader stops and the error is that error instead.
+func (r *Reader) ReadArrayWithSchema(schema ArraySchema, fn func(*Reader) error) error {
+	arr := r.Array()
+	if !arr.IsDefined() {
+		return r.err
+	}
+	start := r.tr.LastPos()
+	var violations []SchemaViolation
+	count := 0
+	for arr.Next() {
+		index := count
+		count++
+		kind, offset, ok := r.peekKind()
+		if !ok {
+			continue // the syntax error will be reported when the element is skipped
+		}
+		if schema.CheckElementKind && kind != schema.ElementKind {
+			violations = append(violations, SchemaViolation{Reason: WrongElementType, Index: index,
+				Expected: schema.ElementKind, Actual: kind, Offset: offset})
+			continue
+		}
+		if fn == nil || (schema.MaxLen > 0 && count > schema.MaxLen) {
+			continue
+		}
+		if err := fn(r); err != nil {
+			r.AddError(err)
+			return r.err
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if count < schema.MinLen {
+		violations = append(violations, SchemaViolation{Reason: ArrayTooShort, Length: count,
+			Offset: r.tr.LastPos()})
+	}
+	if schema.MaxLen > 0 && count > schema.MaxLen {
+		violations = append(violations, SchemaViolation{Reason: ArrayTooLong, Length: count,
+			Offset: r.tr.LastPos()})
+	}
+	if len(violations) != 0 {
+		r.err = ValidationError{Violations: violations, Offset: start}
+	}
+	return r.err
+}
+
+// peekKind returns the kind of the next value, and its position, without consuming it. It returns
+// false if the next value does not start with a character that can begin a JSON value.
+func (r *Reader) peekKind() (ValueKind, int, bool) {
+	var pos int
+	if r.tr.options.lazyRead {
+		node, err := r.tr.structBuffer.CurrentStruct()
+		if err != nil {
+			return NullValue, 0, false
+		}
+		pos = node.start
+	} else {
+		pos = skipRawWhitespace(r.tr.data, r.tr.getPos())
+	}
+	if pos >= len(r.tr.data) {
+		return NullValue, pos, false
+	}
+	kind, ok := valueKindFromFirstByte(r.tr.data[pos])
+	return kind, pos, ok
+}
@@ -0,0 +1,280 @@
+package jreader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadObjectWithSchema(t *testing.T) {
+	schema := ObjectSchema{
+		RequiredFields: []string{"id", "name"},
+		AllowedFields:  []string{"tags"},
+		FieldTypes:     map[string]ValueKind{"id": NumberValue, "name": StringValue, "tags": ArrayValue, "score": NumberValue},
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		r := NewReader([]byte(`{"id": 1, "name": "a", "tags": ["x"], "score": 2.5}`))
+		require.NoError(t, r.ReadObjectWithSchema(schema))
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("all violations are reported", func(t *testing.T) {
+		data := `{"id": "1", "extra": {"a": 1}, "tags": null}`
+		r := NewReader([]byte(data))
+		err := r.ReadObjectWithSchema(schema)
+		require.Equal(t, err, r.Error())
+		var ve ValidationError
+		require.True(t, errors.As(err, &ve))
+		require.Len(t, ve.Violations, 4)
+
+		assert.Equal(t, "id", ve.Violations[0].Property)
+		assert.Equal(t, WrongPropertyType, ve.Violations[0].Reason)
+		assert.Equal(t, NumberValue, ve.Violations[0].Expected)
+		assert.Equal(t, StringValue, ve.Violations[0].Actual)
+
+		assert.Equal(t, "extra", ve.Violations[1].Property)
+		assert.Equal(t, UnknownProperty, ve.Violations[1].Reason)
+
+		assert.Equal(t, "tags", ve.Violations[2].Property)
+		assert.Equal(t, WrongPropertyType, ve.Violations[2].Reason)
+		assert.Equal(t, NullValue, ve.Violations[2].Actual)
+
+		assert.Equal(t, SchemaViolation{Property: "name", Reason: MissingProperty, Offset: len(data) - 1},
+			ve.Violations[3])
+
+		assert.Contains(t, err.Error(), `expected number, got string for property "id"`)
+		assert.Contains(t, err.Error(), `property "extra" is not allowed`)
+		assert.Contains(t, err.Error(), `required property "name" is missing`)
+	})
+
+	t.Run("any property allowed without AllowedFields", func(t *testing.T) {
+		r := NewReader([]byte(`{"whatever": [1, {"b": 2}], "id": 3}`))
+		require.NoError(t, r.ReadObjectWithSchema(ObjectSchema{FieldTypes: map[string]ValueKind{"id": NumberValue}}))
+	})
+
+	t.Run("escaped names", func(t *testing.T) {
+		r := NewReader([]byte(`{"\u0069d": 1, "n\u0061me": "a"}`))
+		require.NoError(t, r.ReadObjectWithSchema(schema))
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		r := NewReader([]byte(`{"id": 1, "name": [}`))
+		err := r.ReadObjectWithSchema(schema)
+		require.IsType(t, SyntaxError{}, err)
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		r := NewReader([]byte(`[]`))
+		require.IsType(t, TypeError{}, r.ReadObjectWithSchema(schema))
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[{"id": 1, "name": "a"}, {"id": 2, "tags": {}}]`)
+		var errs []error
+		for arr := r.Array(); arr.Next(); {
+			errs = append(errs, r.ReadObjectWithSchema(schema))
+		}
+		require.NoError(t, errs[0])
+		var ve ValidationError
+		require.True(t, errors.As(errs[1], &ve))
+		require.Len(t, ve.Violations, 2)
+		assert.Equal(t, WrongPropertyType, ve.Violations[0].Reason)
+		assert.Equal(t, MissingProperty, ve.Violations[1].Reason)
+	})
+}
+
+func TestDecodeFields(t *testing.T) {
+	type item struct {
+		ID   int64
+		Name string
+		Tags []string
+	}
+	fieldsFor := func(it *item) []Field {
+		return []Field{
+			{Name: "id", Required: true, Kind: NumberValue, CheckKind: true,
+				Bind: func(r *Reader) { it.ID = r.Int64() }},
+			{Name: "name", Required: true, Kind: StringValue, CheckKind: true,
+				Bind: func(r *Reader) { it.Name = string(r.String()) }},
+			{Name: "tags", Bind: func(r *Reader) {
+				for arr := r.Array(); arr.Next(); {
+					it.Tags = append(it.Tags, string(r.String()))
+				}
+			}},
+			{Name: "ignored"},
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		for _, lazy := range []bool{false, true} {
+			data := `{"n\u0061me": "a", "ignored": {"x": [1]}, "id": 7, "tags": ["x", "y"]}`
+			r := NewReader([]byte(data))
+			if lazy {
+				r = newLazyReaderForTest(t, data)
+			}
+			var it item
+			require.NoError(t, r.DecodeFields(fieldsFor(&it)))
+			require.NoError(t, r.RequireEOF())
+			assert.Equal(t, item{ID: 7, Name: "a", Tags: []string{"x", "y"}}, it)
+		}
+	})
+
+	t.Run("all problems are reported at once", func(t *testing.T) {
+		for _, lazy := range []bool{false, true} {
+			data := `[{"extra": [1, 2], "id": "7", "tags": ["x"], "more": null}, 5]`
+			r := NewReader([]byte(data))
+			if lazy {
+				r = newLazyReaderForTest(t, data)
+			}
+			var it item
+			arr := r.Array()
+			require.True(t, arr.Next())
+			err := r.DecodeFields(fieldsFor(&it))
+			require.Equal(t, err, r.Error())
+			var ve ValidationError
+			require.True(t, errors.As(err, &ve))
+			assert.Equal(t, []SchemaViolation{
+				{Property: "extra", Reason: UnknownProperty, Offset: 11},
+				{Property: "id", Reason: WrongPropertyType, Expected: NumberValue, Actual: StringValue, Offset: 25},
+				{Property: "more", Reason: UnknownProperty, Offset: 53},
+				{Property: "name", Reason: MissingProperty, Offset: ve.Violations[3].Offset},
+			}, ve.Violations)
+			assert.Equal(t, 1, ve.Offset)
+			assert.Equal(t, []string{"x"}, it.Tags)
+			assert.Equal(t, int64(0), it.ID)
+
+			assert.Contains(t, err.Error(), `property "extra" is not allowed at position 11`)
+			assert.Contains(t, err.Error(), `expected number, got string for property "id" at position 25`)
+			assert.Contains(t, err.Error(), `property "more" is not allowed`)
+			assert.Contains(t, err.Error(), `required property "name" is missing`)
+		}
+	})
+
+	t.Run("escaped name is matched only after decoding", func(t *testing.T) {
+		r := NewReader([]byte(`{"a\\b": 1}`))
+		var got int64
+		err := r.DecodeFields([]Field{{Name: `a\\b`, Bind: func(r *Reader) { got = r.Int64() }}})
+		var ve ValidationError
+		require.True(t, errors.As(err, &ve))
+		assert.Equal(t, `a\b`, ve.Violations[0].Property)
+		assert.Equal(t, int64(0), got)
+	})
+
+	t.Run("error from Bind stops decoding", func(t *testing.T) {
+		r := NewReader([]byte(`{"id": 1.5, "extra": 1}`))
+		var it item
+		err := r.DecodeFields(fieldsFor(&it))
+		require.IsType(t, NumberFormatError{}, err)
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		r := NewReader([]byte(`[]`))
+		require.IsType(t, TypeError{}, r.DecodeFields(nil))
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		r := NewReader([]byte(`{"extra": [}`))
+		require.IsType(t, SyntaxError{}, r.DecodeFields(nil))
+	})
+}
+
+func TestReadArrayWithSchema(t *testing.T) {
+	numbers := ArraySchema{MinLen: 2, MaxLen: 4, ElementKind: NumberValue, CheckElementKind: true}
+	readSum := func(r *Reader, schema ArraySchema) (int64, error) {
+		var sum int64
+		err := r.ReadArrayWithSchema(schema, func(r *Reader) error {
+			sum += r.Int64()
+			return r.Error()
+		})
+		return sum, err
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		r := NewReader([]byte(`[1, 2, 3]`))
+		sum, err := readSum(&r, numbers)
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), sum)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("wrong element kinds", func(t *testing.T) {
+		r := NewReader([]byte(`[1, "x", null, 4]`))
+		sum, err := readSum(&r, numbers)
+		assert.Equal(t, int64(5), sum)
+		require.Equal(t, err, r.Error())
+		require.Equal(t, ValidationError{Violations: []SchemaViolation{
+			{Reason: WrongElementType, Index: 1, Expected: NumberValue, Actual: StringValue, Offset: 4},
+			{Reason: WrongElementType, Index: 2, Expected: NumberValue, Actual: NullValue, Offset: 9},
+		}}, err)
+		assert.Contains(t, err.Error(), "expected number, got string for element 1 at position 4")
+	})
+
+	t.Run("too short", func(t *testing.T) {
+		r := NewReader([]byte(`[{}]`))
+		err := r.ReadArrayWithSchema(ArraySchema{MinLen: 2}, nil)
+		require.Equal(t, ValidationError{Violations: []SchemaViolation{
+			{Reason: ArrayTooShort, Length: 1, Offset: 3},
+		}}, err)
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		r := NewReader([]byte(`[1, 2, 3, 4, 5, "x"]`))
+		sum, err := readSum(&r, numbers)
+		assert.Equal(t, int64(10), sum)
+		var ve ValidationError
+		require.True(t, errors.As(err, &ve))
+		require.Len(t, ve.Violations, 2)
+		assert.Equal(t, WrongElementType, ve.Violations[0].Reason)
+		assert.Equal(t, SchemaViolation{Reason: ArrayTooLong, Length: 6, Offset: 19}, ve.Violations[1])
+	})
+
+	t.Run("any element kind", func(t *testing.T) {
+		r := NewReader([]byte(`[1, "x", [null], {"a": 1}]`))
+		var kinds []ValueKind
+		require.NoError(t, r.ReadArrayWithSchema(ArraySchema{}, func(r *Reader) error {
+			kind, err := r.SkipValueKind()
+			kinds = append(kinds, kind)
+			return err
+		}))
+		assert.Equal(t, []ValueKind{NumberValue, StringValue, ArrayValue, ObjectValue}, kinds)
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		r := NewReader([]byte(`[1, 2]`))
+		failure := errors.New("sorry")
+		err := r.ReadArrayWithSchema(numbers, func(r *Reader) error { return failure })
+		require.Equal(t, failure, err)
+		require.Equal(t, failure, r.Error())
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		r := NewReader([]byte(`[1, ?]`))
+		_, err := readSum(&r, numbers)
+		require.IsType(t, SyntaxError{}, err)
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		require.IsType(t, TypeError{}, r.ReadArrayWithSchema(numbers, nil))
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[[1, 2], [3, true], [4]]`)
+		var errs []error
+		var total int64
+		for arr := r.Array(); arr.Next(); {
+			sum, err := readSum(&r, numbers)
+			total += sum
+			errs = append(errs, err)
+		}
+		assert.Equal(t, int64(6), total)
+		require.Len(t, errs, 2) // iteration stops once the Reader has failed
+		require.NoError(t, errs[0])
+		var ve ValidationError
+		require.True(t, errors.As(errs[1], &ve))
+		assert.Equal(t, SchemaViolation{Reason: WrongElementType, Index: 1, Expected: NumberValue,
+			Actual: BoolValue, Offset: 13}, ve.Violations[0])
+	})
+}
@@ -0,0 +1,133 @@
+package jreader
+
+import (
+	"math/big"
+	"math/bits"
+	"strconv"
+)
+
+// ReadBoolArray reads a JSON array whose elements are all booleans, such as an indicator or mask
+// array, returning it as a slice. An empty JSON array produces an empty non-nil slice. In lazy
+// mode, the slice is preallocated with the number of elements in the array.
+//
+// If there is a parsing error, or the next value is not an array, or one of the elements is not a
+// boolean (including a null element), the return value is nil and the Reader enters a failed
+// state, which you can detect with Error(). To allow null elements, use ReadNullableBoolArray.
+func (r *Reader) ReadBoolArray() []bool {
+	arr := r.Array()
+	if !arr.IsDefined() {
+		return nil
+	}
+	values := make([]bool, 0, r.arraySizeHint(&arr))
+	for arr.Next() {
+		val := r.Bool()
+		if r.err != nil {
+			return nil
+		}
+		values = append(values, val)
+	}
+	if r.err != nil {
+		return nil
+	}
+	return values
+}
+
+// ReadNullableBoolArray is the same as ReadBoolArray, except that the array may contain null
+// elements, which are represented by nil pointers in the result.
+func (r *Reader) ReadNullableBoolArray() []*bool {
+	arr := r.Array()
+	if !arr.IsDefined() {
+		return nil
+	}
+	values := make([]*bool, 0, r.arraySizeHint(&arr))
+	for arr.Next() {
+		val, nonNull := r.BoolOrNull()
+		if r.err != nil {
+			return nil
+		}
+		if nonNull {
+			values = append(values, &val)
+		} else {
+			values = append(values, nil)
+		}
+	}
+	if r.err != nil {
+		return nil
+	}
+	return values
+}
+
+// ReadBitset reads a JSON array whose elements are all booleans, like ReadBoolArray, but returns it
+// as a bitset rather than a slice, which takes an eighth of the memory: bit i of the result is set
+// if element i is true. Since the bitset does not record trailing false elements, the number of
+// elements is also returned. An empty JSON array produces a zero bitset and a count of zero.
+//
+// If there is a parsing error, or the next value is not an array, or one of the elements is not a
+// boolean (including a null element), the return values are nil, 0, and the error, and the Reader
+// enters a failed state.
+func (r *Reader) ReadBitset() (*big.Int, int, error) {
+	arr := r.Array()
+	if !arr.IsDefined() {
+		return nil, 0, r.err
+	}
+	words := make([]big.Word, 0, (r.arraySizeHint(&arr)+bits.UintSize-1)/bits.UintSize)
+	count := 0
+	for arr.Next() {
+		val := r.Bool()
+		if r.err != nil {
+			return nil, 0, r.err
+		}
+		if count%bits.UintSize == 0 {
+			words = append(words, 0)
+		}
+		if val {
+			words[len(words)-1] |= 1 << uint(count%bits.UintSize)
+		}
+		count++
+	}
+	if r.err != nil {
+		return nil, 0, r.err
+	}
+	return new(big.Int).SetBits(words), count, nil
+}
+
+// SumInt64 reads a JSON array whose elements are all integers, and returns their sum. The sum is
+// exact however large it is, since it is accumulated in a big.Int; the elements themselves can
+// also be of any size. This is useful for totals that may not fit in an int64, and that would lose
+// precision if accumulated as float64 values. An empty JSON array produces a zero sum.
+//
+// If there is a parsing error, or the next value is not an array, or one of the elements is not a
+// number (including a null element), the return value is nil and the Reader enters a failed state;
+// the error is also returned. If an element is a number with a fraction or an exponent, even one
+// whose value is a whole number such as 1.0, the error is a NumberFormatError whose Reason is
+// NotAnInteger.
+func (r *Reader) SumInt64() (*big.Int, error) {
+	arr := r.Array()
+	if !arr.IsDefined() {
+		return nil, r.err
+	}
+	sum := new(big.Int)
+	var element big.Int
+	for arr.Next() {
+		raw := r.Number()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if _, ok := element.SetString(string(raw), 10); !ok {
+			r.err = r.withNumberOffset(numberFormatError(raw, strconv.ErrSyntax))
+			return nil, r.err
+		}
+		sum.Add(sum, &element)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return sum, nil
+}
+
+func (r *Reader) arraySizeHint(arr *ArrayState) int {
+	if !r.tr.options.lazyRead {
+		return 0
+	}
+	return r.tr.structBuffer.childCount(arr.arrayIndex)
+}
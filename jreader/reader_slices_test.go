@@ -0,0 +1,179 @@
+package jreader
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestReadBoolArray(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		r := NewReader([]byte(`[true, false, true]`))
+		values := r.ReadBoolArray()
+		require.NoError(t, r.Error())
+		assert.Equal(t, []bool{true, false, true}, values)
+	})
+
+	t.Run("empty array", func(t *testing.T) {
+		r := NewReader([]byte(`[]`))
+		values := r.ReadBoolArray()
+		require.NoError(t, r.Error())
+		assert.Equal(t, []bool{}, values)
+	})
+
+	t.Run("null element", func(t *testing.T) {
+		r := NewReader([]byte(`[true, null]`))
+		values := r.ReadBoolArray()
+		assert.Nil(t, values)
+		require.IsType(t, TypeError{}, r.Error())
+	})
+
+	t.Run("wrong element type", func(t *testing.T) {
+		r := NewReader([]byte(`[true, 1]`))
+		assert.Nil(t, r.ReadBoolArray())
+		require.IsType(t, TypeError{}, r.Error())
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		r := NewReader([]byte(`true`))
+		assert.Nil(t, r.ReadBoolArray())
+		require.IsType(t, TypeError{}, r.Error())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[[true, false, true], [false]]`)
+		var all [][]bool
+		for arr := r.Array(); arr.Next(); {
+			values := r.ReadBoolArray()
+			assert.Equal(t, len(values), cap(values))
+			all = append(all, values)
+		}
+		require.NoError(t, r.Error())
+		assert.Equal(t, [][]bool{{true, false, true}, {false}}, all)
+	})
+}
+
+func TestReadNullableBoolArray(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		r := NewReader([]byte(`[true, null, false]`))
+		values := r.ReadNullableBoolArray()
+		require.NoError(t, r.Error())
+		assert.Equal(t, []*bool{boolPtr(true), nil, boolPtr(false)}, values)
+	})
+
+	t.Run("wrong element type", func(t *testing.T) {
+		r := NewReader([]byte(`[true, "x"]`))
+		assert.Nil(t, r.ReadNullableBoolArray())
+		require.IsType(t, TypeError{}, r.Error())
+	})
+}
+
+func TestReadBitset(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		var input []bool
+		for i := 0; i < 150; i++ {
+			input = append(input, i%3 == 0 || i == 64 || i == 127)
+		}
+		input = append(input, false, false) // trailing false elements are counted
+		data, err := json.Marshal(input)
+		require.NoError(t, err)
+
+		r := NewReader(data)
+		bitset, count, err := r.ReadBitset()
+		require.NoError(t, err)
+		assert.Equal(t, len(input), count)
+		for i, expected := range input {
+			assert.Equal(t, expected, bitset.Bit(i) == 1, "bit %d", i)
+		}
+		assert.Equal(t, 0, int(bitset.Bit(len(input)+10)))
+	})
+
+	t.Run("empty array", func(t *testing.T) {
+		r := NewReader([]byte(`[]`))
+		bitset, count, err := r.ReadBitset()
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+		assert.Equal(t, 0, bitset.Sign())
+	})
+
+	t.Run("non-boolean element", func(t *testing.T) {
+		for _, input := range []string{`[true, null]`, `[false, 0]`, `[true, "true"]`} {
+			r := NewReader([]byte(input))
+			bitset, count, err := r.ReadBitset()
+			assert.Nil(t, bitset)
+			assert.Equal(t, 0, count)
+			require.IsType(t, TypeError{}, err)
+			require.Equal(t, err, r.Error())
+		}
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		r := NewReader([]byte(`true`))
+		_, _, err := r.ReadBitset()
+		require.IsType(t, TypeError{}, err)
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[false, true, true]`)
+		bitset, count, err := r.ReadBitset()
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, int64(6), bitset.Int64())
+	})
+}
+
+func TestSumInt64(t *testing.T) {
+	data := `[9223372036854775807, 9223372036854775807, 2, -1, 123456789012345678901234567890]`
+	expected, _ := new(big.Int).SetString("123456789030792422974944119505", 10)
+
+	t.Run("sum overflows int64", func(t *testing.T) {
+		for _, rawNumbers := range []bool{true, false} {
+			r := NewReader([]byte(data))
+			r.SetNumberRawRead(rawNumbers)
+			sum, err := r.SumInt64()
+			require.NoError(t, err)
+			assert.Equal(t, expected.String(), sum.String())
+		}
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		sum, err := r.SumInt64()
+		require.NoError(t, err)
+		assert.Equal(t, expected.String(), sum.String())
+	})
+
+	t.Run("empty array", func(t *testing.T) {
+		r := NewReader([]byte(`[]`))
+		sum, err := r.SumInt64()
+		require.NoError(t, err)
+		assert.Equal(t, 0, sum.Sign())
+	})
+
+	t.Run("non-integer element", func(t *testing.T) {
+		for _, input := range []string{`[1, 2.5]`, `[1, 1.0]`, `[1, 1e3]`} {
+			r := NewReader([]byte(input))
+			sum, err := r.SumInt64()
+			assert.Nil(t, sum)
+			var nfe NumberFormatError
+			require.ErrorAs(t, err, &nfe, input)
+			assert.Equal(t, NotAnInteger, nfe.Reason)
+			assert.Equal(t, 4, nfe.Offset)
+			require.Equal(t, err, r.Error())
+		}
+	})
+
+	t.Run("non-number element", func(t *testing.T) {
+		for _, input := range []string{`[1, null]`, `[1, "2"]`, `{}`} {
+			r := NewReader([]byte(input))
+			sum, err := r.SumInt64()
+			assert.Nil(t, sum)
+			require.IsType(t, TypeError{}, err, input)
+		}
+	})
+}
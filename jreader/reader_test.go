@@ -1,9 +1,14 @@
 package jreader
 
 import (
+	"bytes"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/Brat-vseznamus/go-jsonstream/v3/internal/commontest"
@@ -31,6 +36,9 @@ type readerErrorTestFactory struct{}
 // - Any other non-null value could be read as its own type, or as a nullable value of that type.
 // - Any value could be read with a nonspecific type using the Any() method.
 // - Any value could be skipped instead of read.
+// - The last two could also be done after switching the Reader to lazy mode with PreProcess, if
+// nothing has been read yet; otherwise, as for a nested value, they are done in whichever mode the
+// Reader is already in, so the values within a lazily read array or object are read in every way.
 const (
 	defaultVariant       commontest.ValueVariant = ""
 	nullableValue        commontest.ValueVariant = "nullable"
@@ -42,17 +50,19 @@ const (
 	nullableStringIsNull commontest.ValueVariant = "nullable string is"
 	nullableArrayIsNull  commontest.ValueVariant = "nullable array is"
 	nullableObjectIsNull commontest.ValueVariant = "nullable object is"
+	lazyUntypedVariant   commontest.ValueVariant = "lazy " + commontest.UntypedVariant
+	lazySkipValueVariant commontest.ValueVariant = "lazy " + commontest.SkipValueVariant
 )
 
 var variantsForNullValues = []commontest.ValueVariant{defaultVariant, nullableBoolIsNull, nullableIntIsNull,
 	nullableFloatIsNull, nullableStringIsNull, nullableArrayIsNull, nullableObjectIsNull,
-	commontest.UntypedVariant, commontest.SkipValueVariant}
+	commontest.UntypedVariant, commontest.SkipValueVariant, lazyUntypedVariant, lazySkipValueVariant}
 var variantsForInts = []commontest.ValueVariant{defaultVariant, numberAsInt, nullableValue, nullableNumberAsInt,
-	commontest.UntypedVariant, commontest.SkipValueVariant}
+	commontest.UntypedVariant, commontest.SkipValueVariant, lazyUntypedVariant, lazySkipValueVariant}
 var variantsForFloats = []commontest.ValueVariant{defaultVariant, nullableValue,
-	commontest.UntypedVariant, commontest.SkipValueVariant}
+	commontest.UntypedVariant, commontest.SkipValueVariant, lazyUntypedVariant, lazySkipValueVariant}
 var variantsForNonNullValues = []commontest.ValueVariant{defaultVariant, nullableValue,
-	commontest.UntypedVariant, commontest.SkipValueVariant}
+	commontest.UntypedVariant, commontest.SkipValueVariant, lazyUntypedVariant, lazySkipValueVariant}
 var shouldHaveBeenNullError = errors.New("should have been null")
 var shouldNotHaveBeenNullError = errors.New("should not have been null")
 
@@ -80,6 +90,16 @@ func TestReader(t *testing.T) {
 
 func (c readerTestContext) JSONData() []byte { return c.input }
 
+// preProcessIfUnread switches the Reader to lazy mode, unless it is already in lazy mode or has
+// already read something.
+func (c *readerTestContext) preProcessIfUnread() error {
+	if c.r.tr.options.lazyRead || c.r.tr.getPos() > 0 {
+		return nil
+	}
+	c.r.PreProcess()
+	return c.r.Error()
+}
+
 func (f readerValueTestFactory) EOF() commontest.Action {
 	return func(c commontest.TestContext) error {
 		return c.(*readerTestContext).r.RequireEOF()
@@ -105,10 +125,15 @@ func (f readerValueTestFactory) Value(value commontest.AnyValue, variant commont
 		ctx := c.(*readerTestContext)
 		r := ctx.r
 
-		if variant == commontest.SkipValueVariant {
+		if variant == lazySkipValueVariant || variant == lazyUntypedVariant {
+			if err := ctx.preProcessIfUnread(); err != nil {
+				return err
+			}
+		}
+		if variant == commontest.SkipValueVariant || variant == lazySkipValueVariant {
 			return r.SkipValue()
 		}
-		if variant == commontest.UntypedVariant {
+		if variant == commontest.UntypedVariant || variant == lazyUntypedVariant {
 			return assertReadAnyValue(ctx, r, value)
 		}
 
@@ -429,3 +454,1331 @@ func TestReaderSkipValue(t *testing.T) {
 		require.False(t, obj.Next())
 	})
 }
+
+func TestNextSkipsUnreadValue(t *testing.T) {
+	modes := []struct {
+		name      string
+		newReader func(t *testing.T, data string) Reader
+	}{
+		{"direct mode", func(t *testing.T, data string) Reader { return NewReader([]byte(data)) }},
+		{"lazy mode", newLazyReaderForTest},
+	}
+	for _, mode := range modes {
+		t.Run(mode.name+": array", func(t *testing.T) {
+			r := mode.newReader(t, `["a", ["b1", ["b2"]], {"c": [1]}, "d", [5]]`)
+			arr := r.Array()
+			require.True(t, arr.Next())
+			require.Equal(t, "a", string(r.String()))
+			require.True(t, arr.Next())
+			require.True(t, arr.Next())
+			require.True(t, arr.Next())
+			require.Equal(t, "d", string(r.String()))
+			require.True(t, arr.Next())
+			require.False(t, arr.Next())
+			require.NoError(t, r.Error())
+			require.NoError(t, r.RequireEOF())
+		})
+
+		t.Run(mode.name+": object", func(t *testing.T) {
+			r := mode.newReader(t, `{"a": 1, "b": {"b1": [2], "b2": {}}, "c": [], "d": 4, "e": {"f": 5}}`)
+			var names []string
+			obj := r.Object()
+			for obj.Next() {
+				names = append(names, string(obj.Name()))
+				if string(obj.Name()) == "d" {
+					require.Equal(t, int64(4), r.Int64())
+				}
+			}
+			require.NoError(t, r.Error())
+			require.Equal(t, []string{"a", "b", "c", "d", "e"}, names)
+			require.NoError(t, r.RequireEOF())
+		})
+
+		t.Run(mode.name+": partly read container", func(t *testing.T) {
+			r := mode.newReader(t, `[[1, 2, 3], 4]`)
+			arr := r.Array()
+			require.True(t, arr.Next())
+			inner := r.Array()
+			require.True(t, inner.Next())
+			require.Equal(t, int64(1), r.Int64())
+			require.True(t, inner.Next())
+			require.True(t, inner.Next())
+			require.False(t, inner.Next())
+			require.True(t, arr.Next())
+			require.Equal(t, int64(4), r.Int64())
+			require.False(t, arr.Next())
+			require.NoError(t, r.Error())
+		})
+	}
+}
+
+func TestReaderSkipValueAfterError(t *testing.T) {
+	myErr := errors.New("sorry")
+	r := NewReader([]byte(`[1, 2]`))
+	r.AddError(myErr)
+	require.Equal(t, myErr, r.SkipValue())
+	require.Equal(t, myErr, r.Error())
+
+	r = newLazyReaderForTest(t, `[1, 2]`)
+	r.AddError(myErr)
+	require.Equal(t, myErr, r.SkipValue())
+}
+
+func TestReaderSkipValueWithSyntaxError(t *testing.T) {
+	r := NewReader([]byte(`]`))
+	require.IsType(t, SyntaxError{}, r.SkipValue())
+}
+
+func TestReaderSkipValueDeeplyNested(t *testing.T) {
+	const depth = 100000
+	data := strings.Repeat(`[`, depth) + strings.Repeat(`]`, depth)
+	r := NewReader([]byte(`[` + data + `, {"a":` + data + `, "b":[{}]}, 1]`))
+	arr := r.Array()
+	require.True(t, arr.Next())
+	require.NoError(t, r.SkipValue())
+	require.True(t, arr.Next())
+	require.NoError(t, r.SkipValue())
+	require.True(t, arr.Next())
+	require.Equal(t, int64(1), r.Int64())
+	require.False(t, arr.Next())
+	require.NoError(t, r.Error())
+
+	r = NewReader([]byte(strings.Repeat(`[`, depth) + strings.Repeat(`]`, depth-1) + `}`))
+	require.IsType(t, SyntaxError{}, r.SkipValue())
+}
+
+func TestReaderSkipValueNestedSyntaxErrors(t *testing.T) {
+	for _, input := range []string{
+		`[[1 2]]`, `{"a":{"b" 1}}`, `{"a":{1:2}}`, `[{"a":1,}]`, `[[1],`, `{"a":[}`,
+	} {
+		t.Run(input, func(t *testing.T) {
+			r := NewReader([]byte(input))
+			require.Error(t, r.SkipValue())
+			require.Error(t, r.Error())
+		})
+	}
+}
+
+func TestReaderSkipValueKind(t *testing.T) {
+	data := `[null, true, false, 1.5, "x", [1, [2]], {"a": {"b": 3}}]`
+	expected := []ValueKind{NullValue, BoolValue, BoolValue, NumberValue, StringValue, ArrayValue, ObjectValue}
+
+	readKinds := func(t *testing.T, r *Reader) {
+		var kinds []ValueKind
+		for arr := r.Array(); arr.Next(); {
+			kind, err := r.SkipValueKind()
+			require.NoError(t, err)
+			kinds = append(kinds, kind)
+		}
+		require.NoError(t, r.Error())
+		require.Equal(t, expected, kinds)
+	}
+
+	t.Run("direct", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		readKinds(t, &r)
+	})
+
+	t.Run("lazy", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		readKinds(t, &r)
+	})
+
+	t.Run("reader already failed", func(t *testing.T) {
+		r := NewReader([]byte(`true`))
+		failure := errors.New("sorry")
+		r.AddError(failure)
+		kind, err := r.SkipValueKind()
+		require.Equal(t, failure, err)
+		require.Equal(t, NullValue, kind)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		r := NewReader([]byte(`[1 2]`))
+		kind, err := r.SkipValueKind()
+		require.Error(t, err)
+		require.Equal(t, NullValue, kind)
+	})
+}
+
+func TestReaderWithBuffersWithoutPreProcess(t *testing.T) {
+	data := `{"a":[1, true, null, "x"], "b":{"c":2.5}, "d":[], "e":{"f":[3]}}`
+	newReader := func() Reader {
+		buffer := make([]JsonTreeStruct, 0, 100)
+		charBuffer := make([]byte, 0, 100)
+		return NewReaderWithBuffers([]byte(data), BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer})
+	}
+
+	t.Run("reads in streaming mode", func(t *testing.T) {
+		r := newReader()
+		require.False(t, r.IsPreProcessed())
+		obj := r.Object()
+		require.True(t, obj.Next())
+		arr := r.Array()
+		require.True(t, arr.Next())
+		require.Equal(t, int64(1), r.Int64())
+		require.True(t, arr.Next())
+		require.True(t, r.Bool())
+		require.True(t, arr.Next())
+		require.NoError(t, r.Null())
+		require.True(t, arr.Next())
+		require.Equal(t, "x", string(r.String()))
+		require.False(t, arr.Next())
+		require.True(t, obj.Next())
+		m, err := r.Float64Map()
+		require.NoError(t, err)
+		require.Equal(t, map[string]float64{"c": 2.5}, m)
+		require.True(t, obj.Next())
+		empty, err := r.ReadArrayEmpty()
+		require.NoError(t, err)
+		require.True(t, empty)
+		require.True(t, obj.Next())
+		require.NoError(t, r.SkipValue())
+		require.False(t, obj.Next())
+		require.NoError(t, r.Error())
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("reads whole values", func(t *testing.T) {
+		r := newReader()
+		keys, err := r.ReadObjectKeys()
+		require.NoError(t, err)
+		require.Len(t, keys, 4)
+
+		r = newReader()
+		v, err := r.ReadAllAny()
+		require.NoError(t, err)
+		require.Len(t, v.Properties, 4)
+
+		r = newReader()
+		count, err := r.ReadObjectCount()
+		require.NoError(t, err)
+		require.Equal(t, 4, count)
+	})
+
+	t.Run("lazy-only methods fail with StateError", func(t *testing.T) {
+		r := newReader()
+		err := r.DecodeTagged("a", map[string]func(*Reader){})
+		require.IsType(t, StateError{}, err)
+		require.Contains(t, err.Error(), "PreProcess")
+	})
+}
+
+func TestReaderLazyReadAfterEndOfTree(t *testing.T) {
+	t.Run("end of input", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[1] `)
+		require.NoError(t, r.SkipValue())
+		require.Equal(t, io.EOF, r.SkipValue())
+
+		r = newLazyReaderForTest(t, `[1] `)
+		require.NoError(t, r.SkipValue())
+		_ = r.Int64()
+		require.Equal(t, io.EOF, r.Error())
+	})
+
+	t.Run("more input", func(t *testing.T) {
+		for name, read := range map[string]func(r *Reader){
+			"SkipValue": func(r *Reader) { _ = r.SkipValue() },
+			"Int64":     func(r *Reader) { _ = r.Int64() },
+			"Any":       func(r *Reader) { _ = r.Any() },
+			"Array":     func(r *Reader) { _ = r.Array() },
+			"Object":    func(r *Reader) { _ = r.Object() },
+		} {
+			t.Run(name, func(t *testing.T) {
+				r := newLazyReaderForTest(t, `[1] [2]`)
+				require.NoError(t, r.SkipValue())
+				read(&r)
+				require.Equal(t, StateError{Message: errMsgBeyondPreProcessed, Offset: 3}, r.Error())
+			})
+		}
+	})
+
+	t.Run("pre-process the next value", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[1] [2]`)
+		require.NoError(t, r.SkipValue())
+		r.SyncWithPreProcess()
+		r.PreProcess()
+		arr := r.Array()
+		require.True(t, arr.Next())
+		require.Equal(t, int64(2), r.Int64())
+		require.False(t, arr.Next())
+		require.NoError(t, r.Error())
+	})
+}
+
+func TestAnyValueType(t *testing.T) {
+	for input, expected := range map[string]string{
+		`null`: "null", `true`: "bool", `false`: "bool", `-1.5e3`: "number",
+		`"x"`: "string", `[1]`: "array", `{"a":1}`: "object",
+	} {
+		t.Run(input, func(t *testing.T) {
+			r := NewReader([]byte(input))
+			v := r.Any()
+			require.NoError(t, r.Error())
+			require.Equal(t, expected, v.Type())
+		})
+	}
+}
+
+func TestAnyValueFormat(t *testing.T) {
+	for _, tc := range []struct {
+		input, expected string
+	}{
+		{`null`, `null`},
+		{`true`, `true`},
+		{`-1.5e3`, `-1.5e3`},
+		{`"a\"b"`, `"a\"b"`},
+		{`[]`, `[]`},
+		{`[1, [2, 3], {"x": null}]`, `[1, [2, 3], {"x": null}]`},
+		{`[1, 2, 3, 4, 5]`, `[1, 2, 3, …]`},
+		{`{}`, `{}`},
+		{`{"a":1, "b":"c", "d":[], "e":4}`, `{"a": 1, "b": "c", "d": [], …}`},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			r := NewReader([]byte(tc.input))
+			v, err := r.ReadAllAny()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, fmt.Sprint(v))
+			require.Equal(t, tc.expected, fmt.Sprintf("%s", *v))
+		})
+	}
+
+	r := NewReader([]byte(`[1]`))
+	require.Equal(t, `[…]`, fmt.Sprintf("%v", r.Any()))
+
+	require.Contains(t, fmt.Sprintf("%#v", AnyValue{Kind: BoolValue}), "Kind:1")
+}
+
+func TestReaderAnyAfterError(t *testing.T) {
+	myErr := errors.New("sorry")
+	r := NewReader([]byte(`[1, 2]`))
+	r.AddError(myErr)
+	v := r.Any()
+	require.NotNil(t, v)
+	require.Equal(t, NullValue, v.Kind)
+	require.False(t, v.Array.IsDefined())
+	require.Equal(t, myErr, r.Error())
+
+	r = NewReader([]byte(`[]]`))
+	require.Equal(t, ArrayValue, r.Any().Kind)
+	v = r.Any()
+	require.Equal(t, NullValue, v.Kind)
+	require.False(t, v.Array.IsDefined())
+	require.IsType(t, SyntaxError{}, r.Error())
+}
+
+func TestReaderReadOptionalBool(t *testing.T) {
+	r := NewReader([]byte(`{"name":"x", "active":true, "hidden":false}`))
+	var active, hidden bool
+	var name string
+	for obj := r.Object(); obj.Next(); {
+		if r.ReadOptionalBool("active", &obj) {
+			active = true
+			continue
+		}
+		if r.ReadOptionalBool("hidden", &obj) {
+			hidden = true
+			continue
+		}
+		if string(obj.Name()) == "name" {
+			name = string(r.String())
+		}
+	}
+	require.NoError(t, r.Error())
+	require.True(t, active)
+	require.False(t, hidden)
+	require.Equal(t, "x", name)
+}
+
+func TestReaderReadOptionalBoolWrongType(t *testing.T) {
+	r := NewReader([]byte(`{"active":"yes"}`))
+	obj := r.Object()
+	require.True(t, obj.Next())
+	require.False(t, r.ReadOptionalBool("active", &obj))
+	require.IsType(t, TypeError{}, r.Error())
+}
+
+func TestReaderReadNullOrError(t *testing.T) {
+	data := `[null, 1, "a", [2], {"b":null}, true]`
+	check := func(t *testing.T, r *Reader) {
+		var values []string
+		arr := r.Array()
+		for arr.Next() {
+			isNull, err := r.ReadNullOrError()
+			require.NoError(t, err)
+			if isNull {
+				values = append(values, "null")
+				continue
+			}
+			raw := r.readRawValue()
+			require.NoError(t, r.Error())
+			values = append(values, string(raw))
+		}
+		require.NoError(t, r.Error())
+		require.Equal(t, []string{"null", "1", `"a"`, "[2]", `{"b":null}`, "true"}, values)
+	}
+
+	t.Run("direct mode", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		check(t, &r)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		check(t, &r)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, input := range []string{``, `nul`, `]`} {
+			r := NewReader([]byte(input))
+			isNull, err := r.ReadNullOrError()
+			require.False(t, isNull, input)
+			require.Error(t, err, input)
+			require.Equal(t, err, r.Error(), input)
+		}
+	})
+
+	t.Run("after error", func(t *testing.T) {
+		r := NewReader([]byte(`null`))
+		r.AddError(errors.New("sorry"))
+		isNull, err := r.ReadNullOrError()
+		require.False(t, isNull)
+		require.EqualError(t, err, "sorry")
+	})
+}
+
+func TestReaderStringUsing(t *testing.T) {
+	data := `["abc", "a\"b\\c\u00e9\n", "", 1]`
+	expected := []string{"abc", "a\"b\\c\u00e9\n", ""}
+
+	newReaders := map[string]func() Reader{
+		"raw strings": func() Reader { return NewReader([]byte(data)) },
+		"computed strings": func() Reader {
+			charBuffer := make([]byte, 0, 100)
+			stringsBuffer := make([][]byte, 0, 10)
+			return NewReaderWithBuffers([]byte(data), BufferConfig{CharsBuffer: &charBuffer,
+				StructBuffer: &[]JsonTreeStruct{}, ComputedValuesBuffer: JsonComputedValues{StringValues: &stringsBuffer}})
+		},
+		"lazy": func() Reader { return newLazyReaderForTest(t, data) },
+	}
+	for name, newReader := range newReaders {
+		t.Run(name, func(t *testing.T) {
+			r := newReader()
+			buf := make([]byte, 0, 64)
+			var got []string
+			arr := r.Array()
+			for i := 0; i < len(expected); i++ {
+				require.True(t, arr.Next())
+				var ok bool
+				buf, ok = r.StringUsing(buf)
+				require.True(t, ok)
+				require.Equal(t, 64, cap(buf))
+				got = append(got, string(buf))
+			}
+			require.Equal(t, expected, got)
+
+			require.True(t, arr.Next())
+			buf, ok := r.StringUsing(buf)
+			require.False(t, ok)
+			require.Len(t, buf, 0)
+			require.IsType(t, TypeError{}, r.Error())
+		})
+	}
+
+	t.Run("buffer grows", func(t *testing.T) {
+		r := NewReader([]byte(`"0123456789"`))
+		buf, ok := r.StringUsing(make([]byte, 3))
+		require.True(t, ok)
+		require.Equal(t, "0123456789", string(buf))
+	})
+
+	t.Run("result does not share memory with the input", func(t *testing.T) {
+		input := []byte(`"abc"`)
+		r := NewReader(input)
+		buf, ok := r.StringUsing(nil)
+		require.True(t, ok)
+		input[1] = 'x'
+		require.Equal(t, "abc", string(buf))
+	})
+}
+
+func TestReaderReadEnumString(t *testing.T) {
+	colors := MakeEnumTable("red", "green", "blue")
+
+	r := NewReader([]byte(`["blue", "red", "gr\u0065en"]`))
+	var got []int
+	for arr := r.Array(); arr.Next(); {
+		i, err := r.ReadEnumString(colors)
+		require.NoError(t, err)
+		got = append(got, i)
+	}
+	require.NoError(t, r.Error())
+	require.Equal(t, []int{2, 0, 1}, got)
+
+	r = NewReader([]byte(`"purple"`))
+	i, err := r.ReadEnumString(colors)
+	require.Equal(t, -1, i)
+	require.IsType(t, ValueError{}, err)
+	require.Equal(t, "purple", err.(ValueError).Got)
+	require.Equal(t, err, r.Error())
+
+	r = NewReader([]byte(`3`))
+	i, err = r.ReadEnumString(colors)
+	require.Equal(t, -1, i)
+	require.IsType(t, TypeError{}, err)
+}
+
+func TestReaderFloat64OrNaN(t *testing.T) {
+	r := NewReader([]byte(`[1.5, null, -2]`))
+	var values []float64
+	for arr := r.Array(); arr.Next(); {
+		values = append(values, r.Float64OrNaN())
+	}
+	require.NoError(t, r.Error())
+	require.Len(t, values, 3)
+	require.Equal(t, 1.5, values[0])
+	require.True(t, math.IsNaN(values[1]))
+	require.Equal(t, -2.0, values[2])
+
+	r = NewReader([]byte(`"1.5"`))
+	require.Equal(t, 0.0, r.Float64OrNaN())
+	require.IsType(t, TypeError{}, r.Error())
+}
+
+func TestReaderReadExactString(t *testing.T) {
+	r := NewReader([]byte(`{"version": "2.0", "name": "a\"b"}`))
+	for obj := r.Object(); obj.Next(); {
+		switch string(obj.Name()) {
+		case "version":
+			require.NoError(t, r.ReadExactString([]byte("2.0")))
+		case "name":
+			require.NoError(t, r.ReadExactString([]byte(`a"b`)))
+		}
+	}
+	require.NoError(t, r.Error())
+
+	r = NewReader([]byte(`"1.0"`))
+	err := r.ReadExactString([]byte("2.0"))
+	require.IsType(t, ValueError{}, err)
+	require.Equal(t, "2.0", err.(ValueError).Expected)
+	require.Equal(t, "1.0", err.(ValueError).Got)
+	require.Equal(t, err, r.Error())
+
+	r = NewReader([]byte(`2.0`))
+	require.IsType(t, TypeError{}, r.ReadExactString([]byte("2.0")))
+}
+
+func TestReaderReadExactInt64(t *testing.T) {
+	r := NewReader([]byte(`{"version": 2}`))
+	for obj := r.Object(); obj.Next(); {
+		require.NoError(t, r.ReadExactInt64(2))
+	}
+	require.NoError(t, r.Error())
+
+	r = NewReader([]byte(`[3, 2]`))
+	arr := r.Array()
+	require.True(t, arr.Next())
+	err := r.ReadExactInt64(2)
+	require.IsType(t, ValueError{}, err)
+	require.Equal(t, "2", err.(ValueError).Expected)
+	require.Equal(t, "3", err.(ValueError).Got)
+	require.Equal(t, err, r.Error())
+	require.False(t, arr.Next())
+
+	r = NewReader([]byte(`"2"`))
+	require.IsType(t, TypeError{}, r.ReadExactInt64(2))
+}
+
+func TestReaderReadHexString(t *testing.T) {
+	r := NewReader([]byte(`["0123456789abcdefABCDEF", "", "\u0061f"]`))
+	var got []string
+	for arr := r.Array(); arr.Next(); {
+		got = append(got, string(r.ReadHexString()))
+	}
+	require.NoError(t, r.Error())
+	require.Equal(t, []string{"0123456789abcdefABCDEF", "", "af"}, got)
+
+	r = NewReader([]byte(`"12g4"`))
+	require.Nil(t, r.ReadHexString())
+	require.IsType(t, ValueError{}, r.Error())
+	require.Equal(t, "12g4", r.Error().(ValueError).Got)
+
+	r = NewReader([]byte(`1234`))
+	require.Nil(t, r.ReadHexString())
+	require.IsType(t, TypeError{}, r.Error())
+}
+
+func TestReaderHexBytes(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		r := NewReader([]byte(`["deadBEEF", "", "\u0030\u0031", null]`))
+		buf := make([]byte, 0, 2)
+		var got [][]byte
+		arr := r.Array()
+		for i := 0; arr.Next(); i++ {
+			if i < 2 {
+				buf = r.HexBytes(buf[:0])
+				got = append(got, append([]byte{}, buf...))
+				continue
+			}
+			value, nonNull := r.HexBytesOrNull(nil)
+			got = append(got, value)
+			require.Equal(t, i == 2, nonNull)
+		}
+		require.NoError(t, r.Error())
+		require.Equal(t, [][]byte{{0xde, 0xad, 0xbe, 0xef}, {}, {0x01}, nil}, got)
+	})
+
+	t.Run("appends to dst", func(t *testing.T) {
+		r := NewReader([]byte(`"0a0b"`))
+		require.Equal(t, []byte{9, 10, 11}, r.HexBytes([]byte{9}))
+	})
+
+	for _, test := range []struct {
+		input   string
+		message string
+	}{
+		{`"abc"`, errMsgOddHexString},
+		{`"abcg"`, errMsgNotHexString},
+		{`"ab c"`, errMsgNotHexString},
+	} {
+		t.Run("invalid "+test.input, func(t *testing.T) {
+			r := NewReader([]byte(`[1, ` + test.input + `]`))
+			arr := r.Array()
+			require.True(t, arr.Next())
+			require.Equal(t, int64(1), r.Int64())
+			require.True(t, arr.Next())
+			require.Equal(t, []byte{7}, r.HexBytes([]byte{7}))
+			require.Equal(t, ValueError{Message: test.message, Got: test.input[1 : len(test.input)-1], Offset: 4},
+				r.Error())
+
+			r = NewReader([]byte(test.input))
+			var b bytes.Buffer
+			n, err := r.CopyHexTo(&b)
+			require.IsType(t, ValueError{}, err)
+			require.Equal(t, int64(0), n)
+			require.Equal(t, 0, b.Len())
+		})
+	}
+
+	t.Run("CopyHexTo", func(t *testing.T) {
+		data := make([]byte, 3000)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		r := NewReader([]byte(`"` + hex.EncodeToString(data) + `"`))
+		var b bytes.Buffer
+		n, err := r.CopyHexTo(&b)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(data)), n)
+		require.Equal(t, data, b.Bytes())
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("not a string", func(t *testing.T) {
+		r := NewReader([]byte(`1234`))
+		require.Nil(t, r.HexBytes(nil))
+		require.IsType(t, TypeError{}, r.Error())
+	})
+}
+
+func TestReaderProgressFunc(t *testing.T) {
+	data := commontest.MakeStructsJSON(commontest.MakeStructs())
+	var reported []int
+	r := NewReader(data)
+	r.SetProgressFunc(func(consumed int) { reported = append(reported, consumed) }, 500)
+
+	for arr := r.Array(); arr.Next(); {
+		var val ExampleStructWrapper
+		val.ReadFromJSONReader(&r)
+	}
+	require.NoError(t, r.Error())
+	require.NoError(t, r.RequireEOF())
+
+	require.Greater(t, len(reported), len(data)/1000)
+	for i := 1; i < len(reported); i++ {
+		require.Greater(t, reported[i], reported[i-1])
+		if i < len(reported)-1 {
+			require.GreaterOrEqual(t, reported[i]-reported[i-1], 500)
+		}
+	}
+	require.Equal(t, r.Consumed(), reported[len(reported)-1])
+	require.Equal(t, len(data), r.Consumed())
+}
+
+func TestReaderProgressFuncDuringPreProcess(t *testing.T) {
+	data := commontest.MakeStructsJSON(commontest.MakeStructs())
+	buffer := make([]JsonTreeStruct, 0, 100)
+	charBuffer := make([]byte, 0, 100)
+	r := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer})
+	var last int
+	calls := 0
+	r.SetProgressFunc(func(consumed int) {
+		require.Greater(t, consumed, last)
+		last = consumed
+		calls++
+	}, 1000)
+	r.PreProcess()
+	require.Greater(t, calls, len(data)/2000)
+	require.GreaterOrEqual(t, last, len(data)-1000)
+}
+
+func TestReaderProgressFuncInLazyMode(t *testing.T) {
+	data := commontest.MakeStructsJSON(commontest.MakeStructs())
+	var reported []int
+	r := newLazyReaderForTest(t, string(data))
+	r.SetProgressFunc(func(consumed int) { reported = append(reported, consumed) }, 500)
+
+	for arr := r.Array(); arr.Next(); {
+		var val ExampleStructWrapper
+		val.ReadFromJSONReader(&r)
+	}
+	require.NoError(t, r.Error())
+	require.NoError(t, r.RequireEOF())
+
+	require.Greater(t, len(reported), len(data)/1000)
+	for i := 1; i < len(reported); i++ {
+		require.Greater(t, reported[i], reported[i-1])
+	}
+	require.Equal(t, r.Consumed(), reported[len(reported)-1])
+	require.Equal(t, len(data), r.Consumed())
+}
+
+func TestReaderProgressFuncDuringFlatten(t *testing.T) {
+	data := `{"a": {"b": [` + strings.Repeat(`"0123456789",`, 100) + `1], "c": {"d": true}}, "e": null}`
+	for _, lazy := range []bool{false, true} {
+		t.Run(fmt.Sprintf("lazy=%t", lazy), func(t *testing.T) {
+			r := NewReader([]byte(data))
+			if lazy {
+				r = newLazyReaderForTest(t, data)
+			}
+			var reported []int
+			r.SetProgressFunc(func(consumed int) { reported = append(reported, consumed) }, 1)
+			_, err := r.ReadObjectFlattened(3, ".")
+			require.NoError(t, err)
+			require.NotEmpty(t, reported)
+			for i := 1; i < len(reported); i++ {
+				require.Greater(t, reported[i], reported[i-1])
+			}
+			require.GreaterOrEqual(t, reported[len(reported)-1], strings.Index(data, "null"))
+		})
+	}
+}
+
+func TestReaderReadAllAny(t *testing.T) {
+	data := `{"a": [1, "x\"y", true, null], "bc": {"d": -2.5}, "e": []}`
+
+	verify := func(t *testing.T, v *AnyValue) {
+		require.Equal(t, ObjectValue, v.Kind)
+		require.Len(t, v.Properties, 3)
+
+		require.Equal(t, "a", string(v.Properties[0].Name))
+		a := v.Properties[0].Value
+		require.Equal(t, ArrayValue, a.Kind)
+		require.Len(t, a.Elements, 4)
+		require.Equal(t, NumberValue, a.Elements[0].Kind)
+		n, err := a.Elements[0].Number.Float64()
+		require.NoError(t, err)
+		require.Equal(t, 1.0, n)
+		require.Equal(t, StringValue, a.Elements[1].Kind)
+		require.Equal(t, `x"y`, string(a.Elements[1].String))
+		require.Equal(t, BoolValue, a.Elements[2].Kind)
+		require.True(t, a.Elements[2].Bool)
+		require.Equal(t, NullValue, a.Elements[3].Kind)
+
+		require.Equal(t, "bc", string(v.Properties[1].Name))
+		bc := v.Properties[1].Value
+		require.Equal(t, ObjectValue, bc.Kind)
+		require.Len(t, bc.Properties, 1)
+		require.Equal(t, "d", string(bc.Properties[0].Name))
+		require.Equal(t, NumberValue, bc.Properties[0].Value.Kind)
+
+		require.Equal(t, "e", string(v.Properties[2].Name))
+		require.Equal(t, []*AnyValue{}, v.Properties[2].Value.Elements)
+	}
+
+	t.Run("direct mode", func(t *testing.T) {
+		input := []byte(data)
+		r := NewReader(input)
+		v, err := r.ReadAllAny()
+		require.NoError(t, err)
+		for i := range input {
+			input[i] = ' '
+		}
+		r.Reset([]byte(`[2]`))
+		_ = r.Any()
+		verify(t, v)
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		v, err := r.ReadAllAny()
+		require.NoError(t, err)
+		verify(t, v)
+	})
+
+	t.Run("data after value", func(t *testing.T) {
+		r := NewReader([]byte(`[1] 2`))
+		v, err := r.ReadAllAny()
+		require.IsType(t, SyntaxError{}, err)
+		require.Nil(t, v)
+		require.Equal(t, err, r.Error())
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		r := NewReader([]byte(`{"a": [1, }`))
+		v, err := r.ReadAllAny()
+		require.IsType(t, SyntaxError{}, err)
+		require.Nil(t, v)
+	})
+}
+
+func TestReaderClone(t *testing.T) {
+	r := NewReader([]byte(`[1, "x", true]`))
+	arr := r.Array()
+	require.True(t, arr.Next())
+	c := r.Clone()
+	require.Equal(t, int64(1), r.Int64())
+	_ = c.Bool()
+	require.Error(t, c.Error())
+	require.NoError(t, r.Error())
+	require.True(t, arr.Next())
+	require.Equal(t, "x", string(r.String()))
+}
+
+func TestReaderReadFirstOf(t *testing.T) {
+	present := NewFieldPresence(NewFieldMatcher([]string{"x", "y"}))
+	asNumber := func(r *Reader) (*AnyValue, error) {
+		n := r.Float64()
+		return &AnyValue{Kind: NumberValue, Number: NumberProps{raw: []byte(strconv.FormatFloat(n, 'g', -1, 64))}},
+			r.Error()
+	}
+	asPoint := func(r *Reader) (*AnyValue, error) {
+		var x, y float64
+		obj := r.Object()
+		obj.TrackPresence(present)
+		for obj.Next() {
+			switch string(obj.Name()) {
+			case "x":
+				x = r.Float64()
+			case "y":
+				y = r.Float64()
+			}
+		}
+		if missing := present.MissingNames(); missing != nil {
+			return nil, RequiredPropertyError{Name: missing[0]}
+		}
+		return &AnyValue{Kind: ArrayValue, Elements: []*AnyValue{
+			{Kind: NumberValue, Number: NumberProps{raw: []byte(strconv.FormatFloat(x, 'g', -1, 64))}},
+			{Kind: NumberValue, Number: NumberProps{raw: []byte(strconv.FormatFloat(y, 'g', -1, 64))}},
+		}}, nil
+	}
+	anything := func(r *Reader) (*AnyValue, error) { return r.readAnyTree(), nil }
+
+	t.Run("first strategy wins", func(t *testing.T) {
+		r := NewReader([]byte(`[2.5, {"x": 1, "y": 2}, {"x": 1}, "s"]`))
+		var results []string
+		for arr := r.Array(); arr.Next(); {
+			v, err := r.ReadFirstOf(asNumber, asPoint, anything)
+			require.NoError(t, err)
+			results = append(results, fmt.Sprint(*v))
+		}
+		require.NoError(t, r.Error())
+		require.Equal(t, []string{`2.5`, `[1, 2]`, `{"x": 1}`, `"s"`}, results)
+	})
+
+	t.Run("all strategies fail", func(t *testing.T) {
+		r := NewReader([]byte(`"s"`))
+		v, err := r.ReadFirstOf(asPoint, asNumber)
+		require.Nil(t, v)
+		require.Equal(t, TypeError{Expected: NumberValue, Actual: StringValue}, err)
+		require.Equal(t, err, r.Error())
+	})
+
+	t.Run("strategy returns an error", func(t *testing.T) {
+		failure := errors.New("sorry")
+		r := NewReader([]byte(`1`))
+		v, err := r.ReadFirstOf(func(r *Reader) (*AnyValue, error) {
+			_ = r.Int64()
+			return nil, failure
+		}, asNumber)
+		require.NoError(t, err)
+		require.Equal(t, "1", fmt.Sprint(*v))
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[{"x": 3, "y": 4}, 5]`)
+		var results []string
+		for arr := r.Array(); arr.Next(); {
+			v, err := r.ReadFirstOf(asPoint, asNumber)
+			require.NoError(t, err)
+			results = append(results, fmt.Sprint(*v))
+		}
+		require.Equal(t, []string{`[3, 4]`, `5`}, results)
+	})
+
+	t.Run("no strategies", func(t *testing.T) {
+		r := NewReader([]byte(`1`))
+		v, err := r.ReadFirstOf()
+		require.Nil(t, v)
+		require.NoError(t, err)
+	})
+}
+
+func TestReaderReadAll(t *testing.T) {
+	summarize := func(values []*AnyValue) []string {
+		var out []string
+		for _, v := range values {
+			out = append(out, fmt.Sprint(*v))
+		}
+		return out
+	}
+
+	t.Run("several values", func(t *testing.T) {
+		r := NewReader([]byte(`1 2 3 "hello"` + "\n" + `[true, {"a": null}]{"b":[]}  `))
+		values, err := r.ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, []string{`1`, `2`, `3`, `"hello"`, `[true, {"a": null}]`, `{"b": []}`}, summarize(values))
+	})
+
+	t.Run("no values", func(t *testing.T) {
+		for _, input := range []string{"", "  \n "} {
+			r := NewReader([]byte(input))
+			values, err := r.ReadAll()
+			require.NoError(t, err)
+			require.NotNil(t, values)
+			require.Len(t, values, 0)
+		}
+	})
+
+	t.Run("remaining values", func(t *testing.T) {
+		r := NewReader([]byte(`"skip" 1 2`))
+		_ = r.String()
+		values, err := r.ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, []string{`1`, `2`}, summarize(values))
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		r := NewReader([]byte(`1 [2, ] 3`))
+		values, err := r.ReadAll()
+		require.Nil(t, values)
+		require.IsType(t, SyntaxError{}, err)
+		require.Equal(t, err, r.Error())
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"a": [1]} "x" 2`)
+		values, err := r.ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, []string{`{"a": [1]}`, `"x"`, `2`}, summarize(values))
+		require.False(t, r.IsPreProcessed())
+	})
+}
+
+func TestReaderReadJSONLines(t *testing.T) {
+	type event struct {
+		line int
+		name string
+	}
+
+	t.Run("lines", func(t *testing.T) {
+		r := NewReader([]byte("{\"name\": \"a\"}\n\n  \r\n{\"name\": \"b\", \"n\": 1}\r\n{\"name\": \"c\"}"))
+		var events []event
+		err := r.ReadJSONLines(func(lineNum int, lr *Reader) error {
+			e := event{line: lineNum}
+			for obj := lr.Object(); obj.Next(); {
+				if string(obj.Name()) == "name" {
+					e.name = string(lr.String())
+				}
+			}
+			events = append(events, e)
+			return lr.RequireEOF()
+		})
+		require.NoError(t, err)
+		require.Equal(t, []event{{1, "a"}, {4, "b"}, {5, "c"}}, events)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("pre-processing a line", func(t *testing.T) {
+		r := newLazyReaderForTest(t, "[1, 2]\n[3]")
+		var sums []int64
+		err := r.ReadJSONLines(func(lineNum int, lr *Reader) error {
+			lr.PreProcess()
+			var sum int64
+			for arr := lr.Array(); arr.Next(); {
+				sum += lr.Int64()
+			}
+			sums = append(sums, sum)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int64{3, 3}, sums)
+		require.False(t, r.IsPreProcessed())
+	})
+
+	t.Run("number options are kept", func(t *testing.T) {
+		r := NewReader([]byte("1.5\n2.5"))
+		r.SetNumberRawRead(false)
+		err := r.ReadJSONLines(func(lineNum int, lr *Reader) error {
+			require.False(t, lr.IsNumbersRaw())
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("error returned by function", func(t *testing.T) {
+		r := NewReader([]byte("1\n2\n3"))
+		failure := errors.New("sorry")
+		var lines []int
+		err := r.ReadJSONLines(func(lineNum int, lr *Reader) error {
+			lines = append(lines, lineNum)
+			if lineNum == 2 {
+				return failure
+			}
+			return nil
+		})
+		require.Equal(t, LineError{Line: 2, Err: failure}, err)
+		require.True(t, errors.Is(err, failure))
+		require.Equal(t, err, r.Error())
+		require.Equal(t, []int{1, 2}, lines)
+	})
+
+	t.Run("error in line reader", func(t *testing.T) {
+		r := NewReader([]byte("[1]\n[2, ]"))
+		err := r.ReadJSONLines(func(lineNum int, lr *Reader) error {
+			for arr := lr.Array(); arr.Next(); {
+				_ = lr.Int64()
+			}
+			return nil
+		})
+		var lineErr LineError
+		require.True(t, errors.As(err, &lineErr))
+		require.Equal(t, 2, lineErr.Line)
+		require.IsType(t, SyntaxError{}, lineErr.Err)
+		offset, ok := ErrorOffset(err)
+		require.True(t, ok)
+		require.Equal(t, 4, offset)
+		require.Equal(t, "line 2: "+lineErr.Err.Error(), err.Error())
+	})
+}
+
+func TestReaderSetDefaultToNull(t *testing.T) {
+	t.Run("after end of object", func(t *testing.T) {
+		r := NewReader([]byte(`{"a": 1}`))
+		r.SetDefaultToNull(true)
+		obj := r.Object()
+		require.True(t, obj.Next())
+		require.Equal(t, int64(1), r.Int64())
+		require.False(t, obj.Next())
+
+		require.Equal(t, int64(0), r.Int64())
+		require.Empty(t, r.String())
+		require.False(t, r.Bool())
+		require.Nil(t, r.NumberProps())
+		require.NoError(t, r.Null())
+		f, nonNull := r.Float64OrNull()
+		require.Equal(t, float64(0), f)
+		require.False(t, nonNull)
+		s, nonNull := r.StringOrNull()
+		require.Empty(t, s)
+		require.False(t, nonNull)
+		n, ok := r.ScaledInt(2)
+		require.Equal(t, int64(0), n)
+		require.False(t, ok)
+		require.NoError(t, r.Error())
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("inside unfinished array", func(t *testing.T) {
+		r := NewReader([]byte(`[ ]`))
+		r.SetDefaultToNull(true)
+		arr := r.Array()
+		require.Equal(t, int64(0), r.Int64())
+		require.False(t, arr.Next())
+		require.NoError(t, r.Error())
+	})
+
+	t.Run("present values are unaffected", func(t *testing.T) {
+		r := NewReader([]byte(`[1, "x"]`))
+		r.SetDefaultToNull(true)
+		arr := r.Array()
+		require.True(t, arr.Next())
+		require.Equal(t, int64(1), r.Int64())
+		require.True(t, arr.Next())
+		r.Bool()
+		require.Error(t, r.Error())
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		r := NewReader([]byte(``))
+		r.Int64()
+		require.Error(t, r.Error())
+	})
+
+	for _, mode := range []struct {
+		name      string
+		newReader func(t *testing.T, data string) Reader
+	}{
+		{"direct mode", func(t *testing.T, data string) Reader { return NewReader([]byte(data)) }},
+		{"lazy mode", newLazyReaderForTest},
+	} {
+		t.Run(mode.name+": after end of nested object", func(t *testing.T) {
+			r := mode.newReader(t, `[{"a": 1}, 2]`)
+			r.SetDefaultToNull(true)
+			arr := r.Array()
+			require.True(t, arr.Next())
+			obj := r.Object()
+			require.True(t, obj.Next())
+			require.Equal(t, int64(1), r.Int64())
+			require.False(t, obj.Next())
+
+			require.Equal(t, int64(0), r.Int64())
+			require.True(t, arr.Next())
+			require.Equal(t, int64(2), r.Int64())
+			require.False(t, arr.Next())
+			require.NoError(t, r.Error())
+		})
+
+		t.Run(mode.name+": at end of nested array", func(t *testing.T) {
+			r := mode.newReader(t, `[[1], 2]`)
+			r.SetDefaultToNull(true)
+			arr := r.Array()
+			require.True(t, arr.Next())
+			inner := r.Array()
+			require.True(t, inner.Next())
+			require.Equal(t, int64(1), r.Int64())
+
+			intVal, floatVal, isInt, ok := r.Numberish()
+			require.Equal(t, []interface{}{int64(0), float64(0), true, true}, []interface{}{intVal, floatVal, isInt, ok})
+			s, ok := r.StringUsing(make([]byte, 0, 8))
+			require.Empty(t, s)
+			require.True(t, ok)
+			require.Equal(t, NullValue, r.Any().Kind)
+			missingArr := r.Array()
+			require.False(t, missingArr.Next())
+			missingObj := r.Object()
+			require.False(t, missingObj.Next())
+			require.NoError(t, r.Error())
+
+			require.False(t, inner.Next())
+			require.True(t, arr.Next())
+			require.Equal(t, int64(2), r.Int64())
+			require.False(t, arr.Next())
+			require.NoError(t, r.Error())
+		})
+
+		t.Run(mode.name+": inside empty array", func(t *testing.T) {
+			r := mode.newReader(t, `[[], 1]`)
+			r.SetDefaultToNull(true)
+			arr := r.Array()
+			require.True(t, arr.Next())
+			inner := r.Array()
+			require.Equal(t, int64(0), r.Int64())
+			require.False(t, inner.Next())
+			require.True(t, arr.Next())
+			require.Equal(t, int64(1), r.Int64())
+			require.NoError(t, r.Error())
+		})
+	}
+
+	t.Run("lazy mode at end of tree", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[1]`)
+		r.SetDefaultToNull(true)
+		arr := r.Array()
+		require.True(t, arr.Next())
+		require.Equal(t, int64(1), r.Int64())
+		require.False(t, arr.Next())
+		v, nonNull := r.Int64OrNull()
+		require.Equal(t, int64(0), v)
+		require.False(t, nonNull)
+		require.NoError(t, r.Error())
+	})
+}
+
+func TestReaderClearErrorAndResync(t *testing.T) {
+	modes := []struct {
+		name      string
+		newReader func(t *testing.T, data string) Reader
+	}{
+		{"direct mode", func(t *testing.T, data string) Reader { return NewReader([]byte(data)) }},
+		{"lazy mode", newLazyReaderForTest},
+	}
+	for _, mode := range modes {
+		t.Run(mode.name+": mid-array", func(t *testing.T) {
+			r := mode.newReader(t, `[1, "bad", [2, [3]], {"a": 4}, 1.5, 5, true, 6]`)
+			var values []int64
+			var errs []error
+			for arr := r.Array(); arr.Next(); {
+				n := r.Int64()
+				if err := r.Error(); err != nil {
+					errs = append(errs, err)
+					require.True(t, r.ClearErrorAndResync())
+					continue
+				}
+				values = append(values, n)
+			}
+			require.NoError(t, r.Error())
+			require.Equal(t, []int64{1, 5, 6}, values)
+			require.Len(t, errs, 5)
+			require.IsType(t, TypeError{}, errs[0])
+			require.IsType(t, NumberFormatError{}, errs[3])
+			require.NoError(t, r.RequireEOF())
+		})
+
+		t.Run(mode.name+": mid-object", func(t *testing.T) {
+			r := mode.newReader(t, `{"a": 1, "b": "x", "c": {"d": [1, "}"]}, "e": 2}`)
+			values := map[string]int64{}
+			var bad []string
+			for obj := r.Object(); obj.Next(); {
+				name := string(obj.Name())
+				n := r.Int64()
+				if r.Error() != nil {
+					bad = append(bad, name)
+					require.True(t, r.ClearErrorAndResync())
+					continue
+				}
+				values[name] = n
+			}
+			require.NoError(t, r.Error())
+			require.Equal(t, map[string]int64{"a": 1, "e": 2}, values)
+			require.Equal(t, []string{"b", "c"}, bad)
+		})
+
+		t.Run(mode.name+": inside a started container", func(t *testing.T) {
+			r := mode.newReader(t, `[{"id": 1}, {"tags": ["a", 2, "c"], "id": 2}, {"id": 3}]`)
+			var ids []int64
+			var tags []string
+			for arr := r.Array(); arr.Next(); {
+				for obj := r.Object(); obj.Next(); {
+					switch string(obj.Name()) {
+					case "id":
+						ids = append(ids, r.Int64())
+					case "tags":
+						for tagsArr := r.Array(); tagsArr.Next(); {
+							s := r.String()
+							if r.Error() != nil {
+								require.True(t, r.ClearErrorAndResync())
+								continue
+							}
+							tags = append(tags, string(s))
+						}
+					}
+				}
+			}
+			require.NoError(t, r.Error())
+			require.Equal(t, []int64{1, 2, 3}, ids)
+			require.Equal(t, []string{"a", "c"}, tags)
+		})
+
+		t.Run(mode.name+": wrong container type", func(t *testing.T) {
+			r := mode.newReader(t, `[{"a": [1]}, [2], 3]`)
+			var got []int64
+			for arr := r.Array(); arr.Next(); {
+				inner := r.Array()
+				if r.Error() != nil {
+					require.True(t, r.ClearErrorAndResync())
+					continue
+				}
+				for inner.Next() {
+					got = append(got, r.Int64())
+				}
+			}
+			require.NoError(t, r.Error())
+			require.Equal(t, []int64{2}, got)
+		})
+	}
+
+	t.Run("syntax error is not cleared", func(t *testing.T) {
+		r := NewReader([]byte(`[1, x, 2]`))
+		for arr := r.Array(); arr.Next(); {
+			r.Int64()
+		}
+		err := r.Error()
+		require.IsType(t, SyntaxError{}, err)
+		require.False(t, r.ClearErrorAndResync())
+		require.Equal(t, err, r.Error())
+	})
+
+	t.Run("other errors are not cleared", func(t *testing.T) {
+		r := NewReader([]byte(`[1]`))
+		require.False(t, r.ClearErrorAndResync())
+		r.AddError(errors.New("sorry"))
+		require.False(t, r.ClearErrorAndResync())
+		require.EqualError(t, r.Error(), "sorry")
+	})
+}
+
+func TestReaderReadStringWithMaxLen(t *testing.T) {
+	t.Run("within limit", func(t *testing.T) {
+		r := NewReader([]byte(`["abc", "aé"]`))
+		var values []string
+		for arr := r.Array(); arr.Next(); {
+			values = append(values, string(r.ReadStringWithMaxLen(3)))
+		}
+		require.NoError(t, r.Error())
+		require.Equal(t, []string{"abc", "aé"}, values)
+	})
+
+	t.Run("escapes count as decoded bytes", func(t *testing.T) {
+		for _, maxLen := range []int{1, 2} {
+			buffer := make([]JsonTreeStruct, 0, 10)
+			charBuffer := make([]byte, 0, 10)
+			computed := make([][]byte, 0, 10)
+			r := NewReaderWithBuffers([]byte(`"\u0041\u0042"`), BufferConfig{StructBuffer: &buffer,
+				CharsBuffer: &charBuffer, ComputedValuesBuffer: JsonComputedValues{StringValues: &computed}})
+			r.PreProcessWith(PreProcessOptions{ComputeStrings: true, DeferStrings: true, BuildIndex: true})
+			require.NoError(t, r.Error())
+			require.True(t, r.tr.options.lazyRead)
+			s := r.ReadStringWithMaxLen(maxLen)
+			if maxLen == 1 {
+				require.IsType(t, ValueError{}, r.Error())
+				continue
+			}
+			require.NoError(t, r.Error())
+			require.Equal(t, "AB", string(s))
+		}
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		r := NewReader([]byte(`{"name": "abcd"}`))
+		obj := r.Object()
+		require.True(t, obj.Next())
+		require.Equal(t, []byte(""), r.ReadStringWithMaxLen(3))
+		var verr ValueError
+		require.ErrorAs(t, r.Error(), &verr)
+		require.Equal(t, 9, verr.Offset)
+		require.Equal(t, "", verr.Property)
+		require.Contains(t, verr.Message, "maximum length of 3 bytes")
+	})
+
+	t.Run("too long in lazy mode names the property", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `{"id": "x", "name": "abcd"}`)
+		obj := r.Object()
+		require.True(t, obj.Next())
+		require.Equal(t, "x", string(r.ReadStringWithMaxLen(3)))
+		require.True(t, obj.Next())
+		r.ReadStringWithMaxLen(3)
+		var verr ValueError
+		require.ErrorAs(t, r.Error(), &verr)
+		require.Equal(t, "name", verr.Property)
+		require.Equal(t, 20, verr.Offset)
+	})
+
+	t.Run("limit does not persist", func(t *testing.T) {
+		r := NewReader([]byte(`["abcd", "abcd"]`))
+		arr := r.Array()
+		require.True(t, arr.Next())
+		r.ReadStringWithMaxLen(10)
+		require.True(t, arr.Next())
+		require.Equal(t, "abcd", string(r.String()))
+		require.NoError(t, r.Error())
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		r := NewReader([]byte(`12345`))
+		r.ReadStringWithMaxLen(3)
+		require.ErrorAs(t, r.Error(), &TypeError{})
+	})
+}
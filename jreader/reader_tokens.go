@@ -0,0 +1,91 @@
+package jreader
+
+import "io"
+
+// TokenKind describes the type of a lexical token returned by Reader.TokenWithSpan.
+type TokenKind int
+
+const (
+	// NullTokenKind means the token is a null.
+	NullTokenKind TokenKind = iota
+
+	// BoolTokenKind means the token is true or false.
+	BoolTokenKind TokenKind = iota
+
+	// NumberTokenKind means the token is a number.
+	NumberTokenKind TokenKind = iota
+
+	// StringTokenKind means the token is a string, which may be either a value or a property name.
+	StringTokenKind TokenKind = iota
+
+	// DelimiterTokenKind means the token is one of the structural characters [ ] { } : ,
+	DelimiterTokenKind TokenKind = iota
+)
+
+// Token is a lexical token returned by Reader.TokenWithSpan.
+type Token struct {
+	// Kind is the type of the token.
+	Kind TokenKind
+
+	// Bool is the value of a BoolTokenKind token, or false otherwise.
+	Bool bool
+
+	// Delimiter is the character of a DelimiterTokenKind token, or zero otherwise.
+	Delimiter byte
+
+	// Raw is the exact text of the token in the input, including the quotes of a string. It is a
+	// slice of the input data, not a copy.
+	Raw []byte
+}
+
+// TokenWithSpan reads the next lexical token from the input, returning the token and the byte
+// range [start, end) that it occupies in the input. Unlike the Reader's other methods, which read
+// whole values, this treats structural characters such as '[' and ':' as tokens of their own, and
+// does not check that the tokens appear in a valid order; it is meant for tools such as editors that
+// need to know the exact source location of every piece of the document.
+//
+//	for {
+//	    token, start, end, err := r.TokenWithSpan()
+//	    if err == io.EOF {
+//	        break
+//	    }
+//	    ...
+//	}
+//
+// At the end of the input, the error is io.EOF, and the Reader does not enter a failed state. If
+// there is a syntax error, the Reader enters a failed state as usual. TokenWithSpan cannot be used
+// in lazy mode, since the preprocessed structure does not contain delimiters; it returns a
+// UsageError in that case.
+func (r *Reader) TokenWithSpan() (token Token, start int, end int, err error) {
+	if r.err != nil {
+		return Token{}, 0, 0, r.err
+	}
+	if r.tr.options.lazyRead {
+		r.err = UsageError{Message: errMsgTokensInLazyMode, Offset: r.Consumed()}
+		return Token{}, 0, 0, r.err
+	}
+	t, err := r.tr.next()
+	if err != nil {
+		if err != io.EOF {
+			r.err = err
+		}
+		return Token{}, 0, 0, err
+	}
+	start, end = r.tr.lastPos, r.tr.pos
+	token.Raw = r.tr.data[start:end]
+	switch t.kind {
+	case nullToken:
+		token.Kind = NullTokenKind
+	case boolToken:
+		token.Kind = BoolTokenKind
+		token.Bool = t.boolValue
+	case numberToken:
+		token.Kind = NumberTokenKind
+	case stringToken:
+		token.Kind = StringTokenKind
+	case delimiterToken:
+		token.Kind = DelimiterTokenKind
+		token.Delimiter = t.delimiter
+	}
+	return token, start, end, nil
+}
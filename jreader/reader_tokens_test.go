@@ -0,0 +1,73 @@
+package jreader
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenWithSpan(t *testing.T) {
+	data := []byte(`{"a" : [1.5, true,null], "b\"":"x" }`)
+	r := NewReader(data)
+
+	type span struct {
+		kind       TokenKind
+		start, end int
+	}
+	var spans []span
+	for {
+		token, start, end, err := r.TokenWithSpan()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, data[start:end], token.Raw)
+		spans = append(spans, span{token.Kind, start, end})
+	}
+	require.NoError(t, r.Error())
+
+	assert.Equal(t, []span{
+		{DelimiterTokenKind, 0, 1},
+		{StringTokenKind, 1, 4},
+		{DelimiterTokenKind, 5, 6},
+		{DelimiterTokenKind, 7, 8},
+		{NumberTokenKind, 8, 11},
+		{DelimiterTokenKind, 11, 12},
+		{BoolTokenKind, 13, 17},
+		{DelimiterTokenKind, 17, 18},
+		{NullTokenKind, 18, 22},
+		{DelimiterTokenKind, 22, 23},
+		{DelimiterTokenKind, 23, 24},
+		{StringTokenKind, 25, 30},
+		{DelimiterTokenKind, 30, 31},
+		{StringTokenKind, 31, 34},
+		{DelimiterTokenKind, 35, 36},
+	}, spans)
+}
+
+func TestTokenWithSpanValues(t *testing.T) {
+	r := NewReader([]byte(`false ]`))
+	token, _, _, err := r.TokenWithSpan()
+	require.NoError(t, err)
+	assert.Equal(t, Token{Kind: BoolTokenKind, Bool: false, Raw: []byte("false")}, token)
+	token, _, _, err = r.TokenWithSpan()
+	require.NoError(t, err)
+	assert.Equal(t, Token{Kind: DelimiterTokenKind, Delimiter: ']', Raw: []byte("]")}, token)
+}
+
+func TestTokenWithSpanSyntaxError(t *testing.T) {
+	r := NewReader([]byte(`[xyz]`))
+	_, _, _, err := r.TokenWithSpan()
+	require.NoError(t, err)
+	_, _, _, err = r.TokenWithSpan()
+	require.IsType(t, SyntaxError{}, err)
+	require.Equal(t, err, r.Error())
+}
+
+func TestTokenWithSpanInLazyMode(t *testing.T) {
+	r := newLazyReaderForTest(t, `[1]`)
+	_, _, _, err := r.TokenWithSpan()
+	require.IsType(t, UsageError{}, err)
+}
@@ -0,0 +1,51 @@
+package jreader
+
+// Tuple reads a JSON array whose elements have a fixed position-dependent meaning, such as a
+// [longitude, latitude, "label"] coordinate. It calls each of the reader functions in order, once
+// for each array element; each function should read exactly one value with the Reader's methods.
+//
+//	var lng, lat float64
+//	var label string
+//	err := r.Tuple(
+//	    func(r *jreader.Reader) error { lng = r.Float64(); return nil },
+//	    func(r *jreader.Reader) error { lat = r.Float64(); return nil },
+//	    func(r *jreader.Reader) error { label = string(r.String()); return nil },
+//	)
+//
+// A reader function may return an error to reject a value that was well-formed but not valid for
+// the application; this has the same effect as calling AddError. If a reader function does not
+// read its element, the element is skipped.
+//
+// If the array has fewer or more elements than there are reader functions, the Reader enters a
+// failed state with a TupleLengthError. If there is a parsing error, or the next value is not an
+// array, or one of the reader functions causes an error, the Reader enters a failed state as
+// usual. In all cases the return value is the same as Error().
+func (r *Reader) Tuple(readers ...func(*Reader) error) error {
+	arr := r.Array()
+	return r.readTuple(&arr, readers)
+}
+
+// TupleOrNull is the same as Tuple, except that it also accepts a null, in which case none of the
+// reader functions are called. The first return value is true if there was an array, or false if
+// there was a null (or an error).
+func (r *Reader) TupleOrNull(readers ...func(*Reader) error) (bool, error) {
+	arr := r.ArrayOrNull()
+	if !arr.IsDefined() {
+		return false, r.err
+	}
+	return true, r.readTuple(&arr, readers)
+}
+
+func (r *Reader) readTuple(arr *ArrayState, readers []func(*Reader) error) error {
+	count := 0
+	for arr.Next() {
+		if count < len(readers) {
+			r.AddError(readers[count](r))
+		}
+		count++
+	}
+	if r.err == nil && count != len(readers) {
+		r.err = TupleLengthError{Expected: len(readers), Actual: count, Offset: r.tr.LastPos()}
+	}
+	return r.err
+}
@@ -0,0 +1,101 @@
+package jreader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readCoordinates(r *Reader) (lng, lat float64, label string, err error) {
+	err = r.Tuple(
+		func(r *Reader) error { lng = r.Float64(); return nil },
+		func(r *Reader) error { lat = r.Float64(); return nil },
+		func(r *Reader) error { label = string(r.String()); return nil },
+	)
+	return
+}
+
+func TestTuple(t *testing.T) {
+	t.Run("exact length", func(t *testing.T) {
+		r := NewReader([]byte(`[12.5, 45.25, "label"]`))
+		lng, lat, label, err := readCoordinates(&r)
+		require.NoError(t, err)
+		assert.Equal(t, 12.5, lng)
+		assert.Equal(t, 45.25, lat)
+		assert.Equal(t, "label", label)
+		require.NoError(t, r.RequireEOF())
+	})
+
+	t.Run("short array", func(t *testing.T) {
+		r := NewReader([]byte(`[12.5, 45.25]`))
+		_, _, _, err := readCoordinates(&r)
+		require.IsType(t, TupleLengthError{}, err)
+		assert.Equal(t, 3, err.(TupleLengthError).Expected)
+		assert.Equal(t, 2, err.(TupleLengthError).Actual)
+		assert.Equal(t, err, r.Error())
+	})
+
+	t.Run("long array", func(t *testing.T) {
+		r := NewReader([]byte(`[12.5, 45.25, "label", [1], {"a":2}]`))
+		_, _, _, err := readCoordinates(&r)
+		require.IsType(t, TupleLengthError{}, err)
+		assert.Equal(t, 3, err.(TupleLengthError).Expected)
+		assert.Equal(t, 5, err.(TupleLengthError).Actual)
+	})
+
+	t.Run("element of wrong type at position 2", func(t *testing.T) {
+		r := NewReader([]byte(`[12.5, 45.25, 3]`))
+		_, _, _, err := readCoordinates(&r)
+		require.IsType(t, TypeError{}, err)
+		assert.Equal(t, StringValue, err.(TypeError).Expected)
+		assert.Equal(t, 14, err.(TypeError).Offset)
+	})
+
+	t.Run("error from reader function", func(t *testing.T) {
+		fakeError := errors.New("sorry")
+		r := NewReader([]byte(`[1, 2]`))
+		err := r.Tuple(
+			func(r *Reader) error { return fakeError },
+			func(r *Reader) error { return nil },
+		)
+		assert.Equal(t, fakeError, err)
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		r := NewReader([]byte(`{}`))
+		_, _, _, err := readCoordinates(&r)
+		require.IsType(t, TypeError{}, err)
+	})
+
+	t.Run("lazy mode", func(t *testing.T) {
+		r := newLazyReaderForTest(t, `[[1.5, 2.5, "a"], [3, 4, "b", "extra"]]`)
+
+		arr := r.Array()
+		require.True(t, arr.Next())
+		lng, lat, label, err := readCoordinates(&r)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{1.5, 2.5, "a"}, []interface{}{lng, lat, label})
+		require.True(t, arr.Next())
+		_, _, _, err = readCoordinates(&r)
+		require.IsType(t, TupleLengthError{}, err)
+		assert.Equal(t, 4, err.(TupleLengthError).Actual)
+	})
+}
+
+func TestTupleOrNull(t *testing.T) {
+	r := NewReader([]byte(`null`))
+	called := false
+	isArray, err := r.TupleOrNull(func(r *Reader) error { called = true; return nil })
+	require.NoError(t, err)
+	assert.False(t, isArray)
+	assert.False(t, called)
+
+	r = NewReader([]byte(`[true]`))
+	var b bool
+	isArray, err = r.TupleOrNull(func(r *Reader) error { b = r.Bool(); return nil })
+	require.NoError(t, err)
+	assert.True(t, isArray)
+	assert.True(t, b)
+}
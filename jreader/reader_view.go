@@ -0,0 +1,89 @@
+package jreader
+
+// ReaderView is a read-only handle on a pre-processed document, from which any number of Readers
+// can be created to read it independently. Each Reader has its own position, but they all share the
+// input, the pre-processed tree, and the computed values without copying them, and none of them
+// modifies what it shares; so unlike the Reader that pre-processed the document, a ReaderView and
+// the Readers that it creates can be used from several goroutines at once, without locking. For
+// instance, to decode the elements of a large array in parallel:
+//
+//	r.PreProcess()
+//	view, err := jreader.NewReaderView(&r)
+//	...
+//	for _, node := range elements { // NodeRefs, such as from FindAll
+//	    go func(node jreader.NodeRef) {
+//	        elementReader := view.ReaderAt(node)
+//	        decodeElement(&elementReader)
+//	    }(node)
+//	}
+//
+// This is only safe as long as nothing modifies what is shared: the Reader that the view was created
+// from must not be reset, pre-processed again, or used to read values that were pre-processed with
+// PreProcessOptions.DeferStrings or DeferNumbers, while any of the view's Readers are in use, and
+// the input must not change. Since the computed values are not modified, a string or number whose
+// computation was deferred is decoded again by each read of it through a ReaderView.
+//
+// The Readers have the same options as the Reader that the view was created from, as they were when
+// the view was created, including those set with SetDetectMisuse, SetInternKeys, SetNormalizeKeys
+// and SetDefaultToNull; if keys are interned, each Reader has its own table of interned keys.
+type ReaderView struct {
+	data     []byte
+	tree     []JsonTreeStruct
+	computed JsonComputedValues
+	options  readerOptions
+	settings readerSettings
+}
+
+// NewReaderView creates a ReaderView for the value that r has pre-processed. It does not change r.
+// If r is not in lazy mode (see Reader.PreProcess), it returns a StateError.
+func NewReaderView(r *Reader) (ReaderView, error) {
+	if !r.tr.options.lazyRead || r.tr.structBuffer.values == nil {
+		return ReaderView{}, StateError{Message: errMsgViewNotInLazyMode, Offset: r.tr.getPos()}
+	}
+	v := ReaderView{
+		data:     r.tr.data,
+		tree:     *r.tr.structBuffer.values,
+		computed: r.tr.computedValuesBuffer,
+		options:  r.tr.options,
+		settings: r.settings(),
+	}
+	v.options.readKey = false
+	v.options.sharedTree = true
+	return v, nil
+}
+
+// Reader returns a new Reader, in lazy mode, that is positioned at the start of the view's value.
+func (v ReaderView) Reader() Reader {
+	if len(v.tree) == 0 {
+		return v.newReader(0, 0, 0)
+	}
+	return v.newReader(0, v.tree[0].subTreeSize, v.tree[0].end)
+}
+
+// ReaderAt returns a new Reader, in lazy mode, that sees only the value identified by node, such as
+// one that was located with FindAll: RequireEOF succeeds once the whole value has been consumed.
+// Unlike with NewSubReader, no part of the tree is copied, and offsets in errors are relative to
+// the start of the whole input. If node does not refer to a value in the view's tree, the Reader is
+// in a failed state with a UsageError.
+func (v ReaderView) ReaderAt(node NodeRef) Reader {
+	if node.Index < 0 || node.Index >= len(v.tree) || v.tree[node.Index].start != node.Start ||
+		v.tree[node.Index].end != node.End {
+		r := v.newReader(0, 0, 0)
+		r.err = UsageError{Message: errMsgViewBadNode, Offset: node.Start}
+		return r
+	}
+	return v.newReader(node.Index, node.Index+v.tree[node.Index].subTreeSize, node.End)
+}
+
+// newReader creates a Reader for the nodes tree[first:last], whose text ends at data[end].
+func (v ReaderView) newReader(first, last, end int) Reader {
+	var tree []JsonTreeStruct
+	charBuffer := make([]byte, 0)
+	r := Reader{tr: newTokenReader(v.data[:end], &tree, &charBuffer, JsonComputedValues{})}
+	v.settings.apply(&r)
+	// The capacity is limited so that nothing can be appended to the shared tree in place.
+	tree = v.tree[first:last:last]
+	r.tr.computedValuesBuffer = v.computed
+	r.tr.options = v.options
+	return r
+}
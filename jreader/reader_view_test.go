@@ -0,0 +1,206 @@
+package jreader
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type viewTestItem struct {
+	ID    int64
+	Name  string
+	Tags  []string
+	Score float64
+}
+
+func decodeViewTestItem(r *Reader) viewTestItem {
+	var item viewTestItem
+	for obj := r.Object(); obj.Next(); {
+		switch string(obj.Name()) {
+		case "id":
+			item.ID = r.Int64()
+		case "name":
+			item.Name = string(r.decodedString(nil, r.String()))
+		case "tags":
+			for arr := r.Array(); arr.Next(); {
+				item.Tags = append(item.Tags, string(r.decodedString(nil, r.String())))
+			}
+		case "score":
+			item.Score = r.Float64()
+		}
+	}
+	return item
+}
+
+func makeViewTestDocument(n int) []byte {
+	var b strings.Builder
+	b.WriteString(`{"meta":{"count":` + fmt.Sprint(n) + `},"items":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"id":%d,"name":"item\t%d","tags":["a","bA%d"],"score":%d.5}`, i, i, i%7, i)
+	}
+	b.WriteString(`]}`)
+	return []byte(b.String())
+}
+
+// TestReaderViewConcurrentDecoding is mainly useful with -race, which reports any write to the
+// shared tree or computed values.
+func TestReaderViewConcurrentDecoding(t *testing.T) {
+	const items, workers = 2000, 8
+	data := makeViewTestDocument(items)
+
+	var expected []viewTestItem
+	r := NewReader(data)
+	for obj := r.Object(); obj.Next(); {
+		if string(obj.Name()) != "items" {
+			require.NoError(t, r.SkipValue())
+			continue
+		}
+		for arr := r.Array(); arr.Next(); {
+			expected = append(expected, decodeViewTestItem(&r))
+		}
+	}
+	require.NoError(t, r.Error())
+	require.Len(t, expected, items)
+
+	optionSets := map[string]PreProcessOptions{
+		"index only": {BuildIndex: true},
+		"computed":   {ComputeStrings: true, ComputeNumbers: true, BuildIndex: true},
+		"deferred": {ComputeStrings: true, ComputeNumbers: true, DeferStrings: true, DeferNumbers: true,
+			BuildIndex: true},
+	}
+	for name, opts := range optionSets {
+		t.Run(name, func(t *testing.T) {
+			tree := make([]JsonTreeStruct, 0, 100)
+			chars := make([]byte, 0, 100)
+			strs := make([][]byte, 0, 100)
+			numbers := make([]NumberProps, 0, 100)
+			r := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &tree, CharsBuffer: &chars,
+				ComputedValuesBuffer: JsonComputedValues{StringValues: &strs, NumberValues: &numbers}})
+			r.PreProcessWith(opts)
+			require.NoError(t, r.Error())
+			view, err := NewReaderView(&r)
+			require.NoError(t, err)
+
+			itemsNode := FindAll(data, tree, "items")[0]
+			var elements []NodeRef
+			for i := itemsNode.Index + 1; i < itemsNode.Index+tree[itemsNode.Index].subTreeSize; i += tree[i].subTreeSize {
+				elements = append(elements, NodeRef{Index: i, Start: tree[i].start, End: tree[i].end})
+			}
+			require.Len(t, elements, items)
+
+			computedStrings, computedNumbers := len(strs), len(numbers)
+			results := make([]viewTestItem, items)
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := w; i < items; i += workers {
+						er := view.ReaderAt(elements[i])
+						results[i] = decodeViewTestItem(&er)
+						assert.NoError(t, er.Error())
+						assert.NoError(t, er.RequireEOF())
+					}
+				}(w)
+			}
+			wg.Wait()
+			assert.Equal(t, expected, results)
+			assert.Len(t, strs, computedStrings)
+			assert.Len(t, numbers, computedNumbers)
+		})
+	}
+}
+
+func TestReaderView(t *testing.T) {
+	data := `{"a":[1,"x\ty"],"b":{"c":true}} [2]`
+
+	t.Run("Reader reads the whole value", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		view, err := NewReaderView(&r)
+		require.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			vr := view.Reader()
+			v, err := vr.ReadAllAny()
+			require.NoError(t, err)
+			assert.Equal(t, ObjectValue, v.Kind)
+			assert.Len(t, v.Properties, 2)
+			require.NoError(t, vr.RequireEOF())
+		}
+		_, err = r.ReadAllAny()
+		require.NoError(t, err)
+	})
+
+	t.Run("ReaderAt a property value", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		view, err := NewReaderView(&r)
+		require.NoError(t, err)
+		node := FindAll([]byte(data), *r.tr.structBuffer.values, "a")[0]
+		vr := view.ReaderAt(node)
+		arr := vr.Array()
+		require.True(t, arr.Next())
+		assert.Equal(t, int64(1), vr.Int64())
+		require.True(t, arr.Next())
+		assert.Equal(t, "x\ty", string(vr.decodedString(nil, vr.String())))
+		require.False(t, arr.Next())
+		require.NoError(t, vr.Error())
+		require.NoError(t, vr.RequireEOF())
+	})
+
+	t.Run("bad node", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		view, err := NewReaderView(&r)
+		require.NoError(t, err)
+		vr := view.ReaderAt(NodeRef{Index: 1, Start: 0, End: 3})
+		require.IsType(t, UsageError{}, vr.Error())
+	})
+
+	t.Run("PreProcess has no effect on a view's Reader", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		view, err := NewReaderView(&r)
+		require.NoError(t, err)
+		tree := append([]JsonTreeStruct(nil), *r.tr.structBuffer.values...)
+		vr := view.Reader()
+		vr.Reset([]byte(`[1,2,3]`))
+		vr.PreProcess()
+		assert.Equal(t, tree, *r.tr.structBuffer.values)
+		assert.Equal(t, []int64{1, 2, 3}, func() []int64 {
+			var values []int64
+			for arr := vr.Array(); arr.Next(); {
+				values = append(values, vr.Int64())
+			}
+			return values
+		}())
+	})
+
+	t.Run("Readers have the original Reader's settings", func(t *testing.T) {
+		r := newLazyReaderForTest(t, data)
+		r.SetDetectMisuse(true)
+		r.SetInternKeys(true)
+		r.SetNormalizeKeys(bytes.ToLower)
+		r.SetDefaultToNull(true)
+		view, err := NewReaderView(&r)
+		require.NoError(t, err)
+		vr := view.Reader()
+
+		expected := r.Options()
+		expected.MutationCheck = false
+		assert.Equal(t, expected, vr.Options())
+		vr.internedKeys["x"] = "x"
+		assert.Empty(t, r.internedKeys, "the table of interned keys is not shared")
+		assert.Empty(t, view.Reader().internedKeys)
+	})
+
+	t.Run("not in lazy mode", func(t *testing.T) {
+		r := NewReader([]byte(data))
+		_, err := NewReaderView(&r)
+		require.IsType(t, StateError{}, err)
+	})
+}
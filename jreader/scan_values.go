@@ -0,0 +1,49 @@
+package jreader
+
+import (
+	"bufio"
+	"io"
+)
+
+// ScanJSONValues is a split function for a bufio.Scanner that returns each top-level JSON value in
+// the input as a token, for reading a stream of values that are separated by whitespace, such as
+// newline-delimited JSON:
+//
+//	scanner := bufio.NewScanner(input)
+//	scanner.Split(jreader.ScanJSONValues)
+//	for scanner.Scan() {
+//	    r := jreader.NewReader(scanner.Bytes())
+//	    ...
+//	}
+//
+// The whitespace between values is not included in the tokens. A value may be split across any
+// number of reads from the underlying input; however, like any token, it must fit in the Scanner's
+// buffer, so use Scanner.Buffer to allow values larger than bufio.MaxScanTokenSize.
+//
+// This only finds the boundaries of the values, by matching brackets and quotes; it does not check
+// that the values are well-formed, which is left to the Reader that parses them. If the input ends
+// in the middle of a value, the Scanner's error is io.ErrUnexpectedEOF. If a value starts with a
+// character that cannot begin a JSON value, such as a comma, the error is a SyntaxError whose
+// Offset is relative to the start of the Scanner's current buffer rather than to the whole input.
+func ScanJSONValues(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := skipRawWhitespace(data, 0)
+	if start == len(data) {
+		return start, nil, nil // skip whitespace; there is no value yet
+	}
+	if _, ok := valueKindFromFirstByte(data[start]); !ok {
+		return 0, nil, SyntaxError{Message: errMsgUnexpectedChar, Value: string(data[start]), Offset: start}
+	}
+	end, complete := skipRawValue(data, start)
+	if !complete {
+		if !atEOF {
+			return start, nil, nil // request more data
+		}
+		if end != len(data) { // a string, array, or object was not terminated
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+	}
+	if atEOF && skipRawWhitespace(data, end) == len(data) {
+		return len(data), data[start:end], bufio.ErrFinalToken
+	}
+	return end, data[start:end], nil
+}
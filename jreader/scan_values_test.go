@@ -0,0 +1,92 @@
+package jreader
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scanAllValues(input io.Reader, bufferSize int) ([]string, error) {
+	scanner := bufio.NewScanner(input)
+	scanner.Split(ScanJSONValues)
+	if bufferSize > 0 {
+		scanner.Buffer(make([]byte, 0, 16), bufferSize)
+	}
+	var tokens []string
+	for scanner.Scan() {
+		tokens = append(tokens, scanner.Text())
+	}
+	return tokens, scanner.Err()
+}
+
+func TestScanJSONValues(t *testing.T) {
+	input := `{"a": "}{\"]", "b": [1, {"c": null}]}` + "\n" +
+		`  [1,2]` + "\r\n" + `"str\"ing" 123 -4.5e6 true false null {}[]` + "\n\n"
+	expected := []string{`{"a": "}{\"]", "b": [1, {"c": null}]}`, `[1,2]`, `"str\"ing"`, `123`, `-4.5e6`,
+		`true`, `false`, `null`, `{}`, `[]`}
+
+	t.Run("whole input", func(t *testing.T) {
+		tokens, err := scanAllValues(strings.NewReader(input), 0)
+		require.NoError(t, err)
+		assert.Equal(t, expected, tokens)
+	})
+
+	t.Run("one byte at a time", func(t *testing.T) {
+		tokens, err := scanAllValues(iotest.OneByteReader(strings.NewReader(input)), 0)
+		require.NoError(t, err)
+		assert.Equal(t, expected, tokens)
+	})
+
+	t.Run("number at end of input", func(t *testing.T) {
+		tokens, err := scanAllValues(iotest.OneByteReader(strings.NewReader(`1 22 333`)), 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "22", "333"}, tokens)
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		for _, s := range []string{"", " \n\t "} {
+			tokens, err := scanAllValues(strings.NewReader(s), 0)
+			require.NoError(t, err)
+			assert.Empty(t, tokens)
+		}
+	})
+
+	t.Run("values larger than the default buffer", func(t *testing.T) {
+		big := `["` + strings.Repeat("x", bufio.MaxScanTokenSize) + `", {"y": "]"}]`
+		_, err := scanAllValues(strings.NewReader(big+" 1"), 0)
+		require.Equal(t, bufio.ErrTooLong, err)
+
+		tokens, err := scanAllValues(strings.NewReader(big+" 1"), 2*bufio.MaxScanTokenSize)
+		require.NoError(t, err)
+		assert.Equal(t, []string{big, "1"}, tokens)
+	})
+
+	t.Run("truncated final value", func(t *testing.T) {
+		for _, s := range []string{`1 {"a": [1, 2`, `1 "abc`, `1 ["a\"]`} {
+			tokens, err := scanAllValues(strings.NewReader(s), 0)
+			require.Equal(t, io.ErrUnexpectedEOF, err, s)
+			assert.Equal(t, []string{"1"}, tokens)
+		}
+	})
+
+	t.Run("invalid start of value", func(t *testing.T) {
+		tokens, err := scanAllValues(strings.NewReader(`1, 2`), 0)
+		require.IsType(t, SyntaxError{}, err)
+		assert.Equal(t, []string{"1"}, tokens)
+	})
+
+	t.Run("values can be parsed", func(t *testing.T) {
+		tokens, err := scanAllValues(strings.NewReader(input), 0)
+		require.NoError(t, err)
+		for _, token := range tokens {
+			r := NewReader([]byte(token))
+			_, err := r.ReadAllAny()
+			require.NoError(t, err, token)
+		}
+	})
+}
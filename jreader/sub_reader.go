@@ -0,0 +1,49 @@
+package jreader
+
+// NewSubReader creates a Reader for a single value within the pre-processed tree of another Reader,
+// such as one that was located with FindAll. The new Reader sees only that value: its input is the
+// text of the value, so offsets in its errors are relative to the start of the value, and
+// RequireEOF succeeds once the whole value has been consumed. It is in lazy mode, with the same
+// options as the parent, including those set with SetDetectMisuse, SetInternKeys, SetNormalizeKeys
+// and SetDefaultToNull; if keys are interned, it has its own table of interned keys.
+//
+// The new Reader has its own copy of the part of the tree that describes the value, but it shares
+// the parent's input and computed values, which it does not modify: as with ReaderView, a string or
+// number whose computation was deferred (see PreProcessOptions) is computed again each time it is
+// read. Therefore, several sub-readers can be used concurrently, for instance to decode the elements
+// of a large array in parallel, as long as the parent is not reset, pre-processed again, or used to
+// read at the same time.
+//
+// If the parent is not in lazy mode (see Reader.PreProcess), the new Reader is in a failed state
+// with a StateError. If node does not refer to a value in the parent's tree, it is in a failed state
+// with a UsageError.
+func NewSubReader(parent *Reader, node NodeRef) Reader {
+	var subTree []JsonTreeStruct
+	charBuffer := make([]byte, 0)
+	r := Reader{tr: newTokenReader(nil, &subTree, &charBuffer, JsonComputedValues{})}
+	if !parent.tr.options.lazyRead || parent.tr.structBuffer.values == nil {
+		r.err = StateError{Message: errMsgSubReaderNotInLazyMode}
+		return r
+	}
+	tree := *parent.tr.structBuffer.values
+	if node.Index < 0 || node.Index >= len(tree) || tree[node.Index].start != node.Start ||
+		tree[node.Index].end != node.End {
+		r.err = UsageError{Message: errMsgSubReaderBadNode, Offset: node.Start}
+		return r
+	}
+	root := tree[node.Index]
+	r.tr.Reset(parent.tr.data[root.start:root.end])
+	subTree = make([]JsonTreeStruct, root.subTreeSize)
+	copy(subTree, tree[node.Index:node.Index+root.subTreeSize])
+	for i := range subTree {
+		subTree[i].start -= root.start
+		subTree[i].end -= root.start
+	}
+	subTree[0].key = nil // the value is the root of the new tree, not a property
+	r.tr.computedValuesBuffer = parent.tr.computedValuesBuffer
+	r.tr.options = parent.tr.options
+	r.tr.options.readKey = false
+	r.tr.options.sharedTree = true
+	parent.settings().apply(&r)
+	return r
+}
@@ -0,0 +1,165 @@
+package jreader
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSubReader(t *testing.T) {
+	data := []byte(`{"a": [1, 2.5, "x\ny"], "b": {"id": {"name": "né", "tags": [true, null], "n": -3}}, "c": 0}`)
+
+	modes := map[string]func() Reader{
+		"raw values": func() Reader { return newLazyReaderForTest(t, string(data)) },
+		"computed values": func() Reader {
+			buffer := make([]JsonTreeStruct, 0, 100)
+			charBuffer := make([]byte, 0, 100)
+			strings := make([][]byte, 0, 10)
+			numbers := make([]NumberProps, 0, 10)
+			r := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer,
+				ComputedValuesBuffer: JsonComputedValues{StringValues: &strings, NumberValues: &numbers}})
+			r.PreProcess()
+			require.NoError(t, r.Error())
+			return r
+		},
+	}
+	for name, newParent := range modes {
+		t.Run(name, func(t *testing.T) {
+			parent := newParent()
+			for _, key := range []string{"a", "b", "id", "tags", "n", "c"} {
+				found := FindAll(data, *parent.tr.structBuffer.values, key)
+				require.Len(t, found, 1, key)
+
+				sub := NewSubReader(&parent, found[0])
+				got, err := sub.ReadAllAny()
+				require.NoError(t, err, key)
+				require.NoError(t, sub.RequireEOF(), key)
+
+				fresh := NewReader(found[0].Raw(data))
+				expected, err := fresh.ReadAllAny()
+				require.NoError(t, err, key)
+				assert.True(t, expected.Equal(got), "%s: expected %v, got %v", key, expected, got)
+			}
+		})
+	}
+
+	t.Run("reads values in place", func(t *testing.T) {
+		parent := newLazyReaderForTest(t, string(data))
+		found := FindAll(data, *parent.tr.structBuffer.values, "id")
+		sub := NewSubReader(&parent, found[0])
+		var name string
+		var n int64
+		for obj := sub.Object(); obj.Next(); {
+			switch string(obj.Name()) {
+			case "name":
+				name = string(sub.decodedString(nil, sub.String()))
+			case "n":
+				n = sub.Int64()
+			}
+		}
+		require.NoError(t, sub.Error())
+		assert.Equal(t, "né", name)
+		assert.Equal(t, int64(-3), n)
+
+		// the parent is unaffected
+		m, err := parent.ReadObjectKeys()
+		require.NoError(t, err)
+		assert.Len(t, m, 3)
+	})
+
+	t.Run("has the parent's settings", func(t *testing.T) {
+		parent := newLazyReaderForTest(t, string(data))
+		parent.SetDetectMisuse(true)
+		parent.SetInternKeys(true)
+		parent.SetNormalizeKeys(bytes.ToLower)
+		parent.SetDefaultToNull(true)
+		found := FindAll(data, *parent.tr.structBuffer.values, "tags")
+		sub := NewSubReader(&parent, found[0])
+
+		expected := parent.Options()
+		expected.MutationCheck = false
+		assert.Equal(t, expected, sub.Options())
+		sub.internedKeys["x"] = "x"
+		assert.Empty(t, parent.internedKeys, "the table of interned keys is not shared")
+
+		arr := sub.Array()
+		require.True(t, arr.Next())
+		require.True(t, arr.Next())
+		require.False(t, arr.Next())
+		assert.False(t, sub.Bool(), "a missing value is read as a null")
+		require.NoError(t, sub.Error())
+	})
+
+	t.Run("parent not pre-processed", func(t *testing.T) {
+		parent := NewReader(data)
+		sub := NewSubReader(&parent, NodeRef{})
+		require.IsType(t, StateError{}, sub.Error())
+	})
+
+	t.Run("node from another tree", func(t *testing.T) {
+		parent := newLazyReaderForTest(t, string(data))
+		sub := NewSubReader(&parent, NodeRef{Index: 2, Start: 1, End: 3})
+		require.IsType(t, UsageError{}, sub.Error())
+		sub = NewSubReader(&parent, NodeRef{Index: 1000})
+		require.IsType(t, UsageError{}, sub.Error())
+	})
+}
+
+func TestNewSubReaderConcurrentWithDeferredValues(t *testing.T) {
+	const items = 64
+	var sb strings.Builder
+	sb.WriteString(`[`)
+	for i := 0; i < items; i++ {
+		if i > 0 {
+			sb.WriteString(`,`)
+		}
+		fmt.Fprintf(&sb, `{"id": {"name": "n\u00e9%d", "n": %d.5}}`, i, i)
+	}
+	sb.WriteString(`]`)
+	data := []byte(sb.String())
+
+	buffer := make([]JsonTreeStruct, 0, 100)
+	charBuffer := make([]byte, 0, 100)
+	strs := make([][]byte, 0, 10)
+	numbers := make([]NumberProps, 0, 10)
+	parent := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &buffer, CharsBuffer: &charBuffer,
+		ComputedValuesBuffer: JsonComputedValues{StringValues: &strs, NumberValues: &numbers}})
+	parent.PreProcessWith(PreProcessOptions{ComputeStrings: true, DeferStrings: true, ComputeNumbers: true,
+		DeferNumbers: true, BuildIndex: true})
+	require.NoError(t, parent.Error())
+	found := FindAll(data, *parent.tr.structBuffer.values, "id")
+	require.Len(t, found, items)
+	computedStrings, computedNumbers := len(strs), len(numbers)
+
+	names := make([]string, items)
+	values := make([]float64, items)
+	var wg sync.WaitGroup
+	for i := range found {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sub := NewSubReader(&parent, found[i])
+			for obj := sub.Object(); obj.Next(); {
+				switch string(obj.Name()) {
+				case "name":
+					names[i] = string(sub.String())
+				case "n":
+					values[i] = sub.Float64()
+				}
+			}
+			assert.NoError(t, sub.Error())
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < items; i++ {
+		assert.Equal(t, fmt.Sprintf("né%d", i), names[i])
+		assert.Equal(t, float64(i)+0.5, values[i])
+	}
+	assert.Len(t, strs, computedStrings)
+	assert.Len(t, numbers, computedNumbers)
+}
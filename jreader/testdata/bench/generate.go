@@ -0,0 +1,163 @@
+//go:build ignore
+
+// This program generates the documents in testdata/bench that are used by BenchmarkCorpus. The
+// documents are committed, so that benchmark results are comparable across changes; run it with
+// "go generate" in the jreader directory only if the documents themselves need to change.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const outputDir = "testdata/bench"
+
+var words = []string{ //nolint:gochecknoglobals
+	"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliet",
+	"kilo", "lima", "mike", "november", "oscar", "papa", "quebec", "romeo", "sierra", "tango",
+	"uniform", "victor", "whiskey", "x-ray", "yankee", "zulu", "café", "naïve", "日本",
+	"line\nbreak", "tab\there", "quote\"d", "back\\slash",
+}
+
+func main() {
+	rnd := rand.New(rand.NewSource(1)) //nolint:gosec
+	documents := map[string]any{
+		"tweets.json":  tweets(rnd, 300),
+		"geo.json":     geo(rnd, 20, 500),
+		"config.json":  config(rnd, 5, 6),
+		"numbers.json": numbers(rnd, 25000),
+	}
+	for name, doc := range documents {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(doc); err != nil {
+			panic(err)
+		}
+		if err := os.WriteFile(filepath.Join(outputDir, name), buf.Bytes(), 0o644); err != nil { //nolint:gosec
+			panic(err)
+		}
+		fmt.Printf("%s: %d bytes\n", name, buf.Len())
+	}
+}
+
+func sentence(rnd *rand.Rand, n int) string {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(words[rnd.Intn(len(words))])
+	}
+	return buf.String()
+}
+
+// tweets models a timeline API response: many objects with the same keys, mostly strings, some
+// nesting, and many nulls and booleans.
+func tweets(rnd *rand.Rand, count int) any {
+	statuses := make([]any, 0, count)
+	for i := 0; i < count; i++ {
+		id := 1000000000000000000 + rnd.Int63n(1000000000000000)
+		hashtags := []any{}
+		for j := rnd.Intn(4); j > 0; j-- {
+			hashtags = append(hashtags, map[string]any{
+				"text":    words[rnd.Intn(len(words))],
+				"indices": []int{rnd.Intn(140), rnd.Intn(140)},
+			})
+		}
+		statuses = append(statuses, map[string]any{
+			"id":         id,
+			"id_str":     strconv.FormatInt(id, 10),
+			"created_at": fmt.Sprintf("Sun Aug 31 %02d:%02d:%02d +0000 2014", rnd.Intn(24), rnd.Intn(60), rnd.Intn(60)),
+			"text":       sentence(rnd, 5+rnd.Intn(20)),
+			"truncated":  false,
+			"lang":       []string{"en", "ja", "es", "fr"}[rnd.Intn(4)],
+			"user": map[string]any{
+				"id":              rnd.Int63n(1000000000),
+				"name":            sentence(rnd, 2),
+				"screen_name":     words[rnd.Intn(len(words))] + strconv.Itoa(rnd.Intn(1000)),
+				"description":     sentence(rnd, rnd.Intn(15)),
+				"followers_count": rnd.Intn(100000),
+				"verified":        rnd.Intn(10) == 0,
+				"url":             nil,
+			},
+			"entities": map[string]any{
+				"hashtags":      hashtags,
+				"urls":          []any{},
+				"user_mentions": []any{},
+			},
+			"geo":                nil,
+			"retweet_count":      rnd.Intn(500),
+			"favorite_count":     rnd.Intn(500),
+			"favorited":          false,
+			"retweeted":          rnd.Intn(2) == 0,
+			"possibly_sensitive": rnd.Intn(20) == 0,
+		})
+	}
+	return map[string]any{"statuses": statuses, "search_metadata": map[string]any{"count": count}}
+}
+
+// geo models a GeoJSON feature collection: a few objects containing long arrays of coordinate
+// pairs, so that it is almost entirely floating-point numbers.
+func geo(rnd *rand.Rand, features, points int) any {
+	result := make([]any, 0, features)
+	for i := 0; i < features; i++ {
+		ring := make([][2]float64, 0, points)
+		lon, lat := -140+rnd.Float64()*90, 42+rnd.Float64()*20
+		for j := 0; j < points; j++ {
+			lon += (rnd.Float64() - 0.5) / 10
+			lat += (rnd.Float64() - 0.5) / 10
+			ring = append(ring, [2]float64{lon, lat})
+		}
+		result = append(result, map[string]any{
+			"type":       "Feature",
+			"properties": map[string]any{"name": sentence(rnd, 2)},
+			"geometry":   map[string]any{"type": "Polygon", "coordinates": [][][2]float64{ring}},
+		})
+	}
+	return map[string]any{"type": "FeatureCollection", "features": result}
+}
+
+// config models a configuration document: objects nested several levels deep, with few repeated
+// keys and a mix of all value types.
+func config(rnd *rand.Rand, depth, width int) any {
+	m := make(map[string]any, width)
+	for i := 0; i < width; i++ {
+		key := words[rnd.Intn(len(words))] + "_" + strconv.Itoa(i)
+		if depth > 0 && rnd.Intn(3) != 0 {
+			m[key] = config(rnd, depth-1, width)
+			continue
+		}
+		switch rnd.Intn(5) {
+		case 0:
+			m[key] = sentence(rnd, 1+rnd.Intn(3))
+		case 1:
+			m[key] = rnd.Intn(65536)
+		case 2:
+			m[key] = rnd.Intn(2) == 0
+		case 3:
+			m[key] = []string{sentence(rnd, 1), sentence(rnd, 1)}
+		default:
+			m[key] = rnd.Float64() * 100
+		}
+	}
+	return m
+}
+
+// numbers is a single large array of numbers, half integers and half floating-point.
+func numbers(rnd *rand.Rand, count int) any {
+	result := make([]any, 0, count)
+	for i := 0; i < count; i++ {
+		if i%2 == 0 {
+			result = append(result, rnd.Int63n(1<<40)-(1<<39))
+		} else {
+			result = append(result, rnd.NormFloat64()*1e6)
+		}
+	}
+	return result
+}
@@ -66,8 +66,11 @@ type readerOptions struct {
 	lazyRead       bool
 	computeString  bool
 	computeNumber  bool // TODO
+	deferString    bool // only used while pre-processing; see PreProcessOptions.DeferStrings
+	deferNumber    bool // only used while pre-processing; see PreProcessOptions.DeferNumbers
 	readKey        bool
 	readRawNumbers bool
+	sharedTree     bool // the tree and computed values must not be modified; see ReaderView
 }
 
 type tokenReader struct {
@@ -83,12 +86,15 @@ type tokenReader struct {
 	anyValueBuffer       AnyValue
 	tokenBuffer          token
 	options              readerOptions
+	progressFunc         func(consumedBytes int)
+	progressInterval     int
+	progressLastReported int
 }
 
 func newTokenReader(data []byte, buffer *[]JsonTreeStruct, charBuffer *[]byte, computedValuesBuffer JsonComputedValues) tokenReader {
 	tr := tokenReader{
 		structBuffer: JsonStructPointer{
-			Values: buffer,
+			values: buffer,
 		},
 		charBuffer:           charBuffer,
 		computedValuesBuffer: computedValuesBuffer,
@@ -102,13 +108,18 @@ func (r *tokenReader) Reset(data []byte) {
 	r.len = len(data)
 	r.pos = 0
 	r.hasUnread = false
+	r.progressLastReported = 0
+	if r.options.sharedTree {
+		// The computed values belong to the Reader that pre-processed the tree; see ReaderView.
+		r.computedValuesBuffer = JsonComputedValues{}
+	}
 
 	if r.charBuffer != nil {
 		*r.charBuffer = (*r.charBuffer)[:0]
 	}
-	r.structBuffer.Pos = 0
-	if r.structBuffer.Values != nil {
-		*r.structBuffer.Values = (*r.structBuffer.Values)[:0]
+	r.structBuffer.pos = 0
+	if r.structBuffer.values != nil {
+		*r.structBuffer.values = (*r.structBuffer.values)[:0]
 	}
 	r.options.computeString = r.computedValuesBuffer.StringValues != nil
 	if r.options.computeString {
@@ -246,11 +257,11 @@ func (r *tokenReader) PropertyName() ([]byte, error) {
 // This and all other tokenReader methods skip transparently past whitespace between tokens.
 func (r *tokenReader) Delimiter(delimiter byte) (bool, error) {
 	if r.options.lazyRead {
-		currStruct, err := r.structBuffer.CurrentStruct()
+		currStruct, err := r.currentNode()
 		if err != nil {
 			return false, err
 		}
-		return r.data[currStruct.Start] == delimiter, nil
+		return r.data[currStruct.start] == delimiter, nil
 	} else {
 		if r.hasUnread {
 			if r.unreadToken.kind == delimiterToken && r.unreadToken.delimiter == delimiter {
@@ -375,6 +386,10 @@ func (r *tokenReader) next() (*token, error) {
 	}
 	b, ok := r.skipWhitespaceAndReadByte()
 	if !ok {
+		if r.options.lazyRead {
+			_, err := r.currentNode()
+			return nil, err
+		}
 		return nil, io.EOF
 	}
 
@@ -421,29 +436,39 @@ func (r *tokenReader) next() (*token, error) {
 	case (b >= '0' && b <= '9') || b == '-':
 		if r.options.lazyRead {
 			curStruct, _ := r.structBuffer.CurrentStruct()
-			if r.options.computeNumber {
-				r.tokenBuffer.numberValue = (*r.computedValuesBuffer.NumberValues)[curStruct.ComputedValueIndex]
+			if r.options.computeNumber && curStruct.computedType == NumberComputed {
+				r.tokenBuffer.numberValue = (*r.computedValuesBuffer.NumberValues)[curStruct.computedIndex]
+			} else if r.options.computeNumber && curStruct.computedType == numberDeferred {
+				r.tokenBuffer.numberValue = r.computeDeferredNumber(curStruct.start, curStruct.end)
 			} else {
-				nBytes := r.data[curStruct.Start:curStruct.End]
-				r.tokenBuffer.numberValue = NumberProps{raw: nBytes}
+				// The number has not been parsed, so it is marked as truncated, as in readNumberProps
+				// with readRawNumbers; this makes the NumberProps methods parse the raw text.
+				nBytes := r.data[curStruct.start:curStruct.end]
+				r.tokenBuffer.numberValue = NumberProps{raw: nBytes, trunc: true}
 			}
 			r.structBuffer.Next()
 			r.tokenBuffer.kind = numberToken
 			return &r.tokenBuffer, nil
 		} else {
-			if n, ok := r.readNumber(b); ok {
+			if r.readNumberProps(b, &r.tokenBuffer.numberValue) {
 				r.tokenBuffer.kind = numberToken
-				r.tokenBuffer.numberValue = n
 				return &r.tokenBuffer, nil
 			}
+			// Don't leave the position somewhere in the middle of the invalid token.
+			r.pos = r.lastPos
 			return nil, SyntaxError{Message: errMsgInvalidNumber, Offset: r.lastPos}
 		}
 	case b == '"':
 		if r.options.lazyRead {
 			curStruct, _ := r.structBuffer.CurrentStruct()
-			sBytes := r.data[(curStruct.Start + 1):(curStruct.End - 1)]
+			sBytes := r.data[(curStruct.start + 1):(curStruct.end - 1)]
 			if r.options.computeString && !r.options.readKey {
-				sBytes = (*r.computedValuesBuffer.StringValues)[curStruct.ComputedValueIndex]
+				switch curStruct.computedType { //nolint:exhaustive
+				case StringComputed:
+					sBytes = (*r.computedValuesBuffer.StringValues)[curStruct.computedIndex]
+				case stringDeferred:
+					sBytes = r.computeDeferredString(sBytes)
+				}
 			}
 			r.structBuffer.Next()
 			r.tokenBuffer.kind = stringToken
@@ -467,6 +492,20 @@ func (r *tokenReader) next() (*token, error) {
 	return nil, SyntaxError{Message: errMsgUnexpectedChar, Value: string(b), Offset: r.lastPos}
 }
 
+// currentNode returns the node of the pre-processed tree for the next value, in lazy mode. If all of
+// the tree has been read, the error is io.EOF if there is nothing else in the input, or a StateError
+// if there is, since the rest of the input has not been pre-processed.
+func (r *tokenReader) currentNode() (JsonTreeStruct, error) {
+	node, err := r.structBuffer.CurrentStruct()
+	if err == nil {
+		return node, nil
+	}
+	if values := *r.structBuffer.values; len(values) > 0 && skipRawWhitespace(r.data, values[0].end) < r.len {
+		return node, StateError{Message: errMsgBeyondPreProcessed, Offset: values[0].end}
+	}
+	return node, io.EOF
+}
+
 func (r *tokenReader) putBack(token *token) {
 	r.unreadToken = *token
 	r.hasUnread = true
@@ -504,23 +543,46 @@ func (r *tokenReader) skipWhitespaceAndReadByte() (byte, bool) {
 	if r.options.lazyRead {
 		curStruct, err := r.structBuffer.CurrentStruct()
 		if err != nil {
+			if r.progressFunc != nil {
+				r.reportProgress(r.len)
+			}
 			return 0, false
 		}
-		return r.data[curStruct.Start], true
+		r.lastPos = curStruct.start
+		if r.progressFunc != nil {
+			r.reportProgress(curStruct.start)
+		}
+		return r.data[curStruct.start], true
 	} else {
 		for {
 			ch, ok := r.readByte()
 			if !ok {
+				if r.progressFunc != nil {
+					r.reportProgress(r.pos)
+				}
 				return 0, false
 			}
 			if !unicode.IsSpace(rune(ch)) {
 				r.lastPos = r.pos - 1
+				if r.progressFunc != nil {
+					r.reportProgress(r.lastPos)
+				}
 				return ch, true
 			}
 		}
 	}
 }
 
+// reportProgress calls the progress function if at least progressInterval bytes have been consumed
+// since it was last called, or if the end of the input has been reached.
+func (r *tokenReader) reportProgress(consumed int) {
+	if consumed-r.progressLastReported >= r.progressInterval ||
+		(consumed == r.len && consumed != r.progressLastReported) {
+		r.progressLastReported = consumed
+		r.progressFunc(consumed)
+	}
+}
+
 func (r *tokenReader) consumeASCIILowercaseAlphabeticChars() int {
 	n := 0
 	for {
@@ -537,13 +599,28 @@ func (r *tokenReader) consumeASCIILowercaseAlphabeticChars() int {
 	return n
 }
 
-func (r *tokenReader) readNumber(first byte) (result NumberProps, ok bool) { //nolint:unparam
-	ok = r.readNumberProps(first, &result)
-	if ok && r.options.lazyParse && r.options.computeNumber {
-		nValues := r.computedValuesBuffer.NumberValues
-		*nValues = append(*nValues, result)
+// computeDeferredNumber parses the number at the current node of the pre-processed tree, which is
+// at data[start:end], and records the result in the computed values buffer, so that the number is
+// only parsed once however many times it is read. As with computeDeferredString, nothing is recorded
+// if the tree is shared with other Readers.
+func (r *tokenReader) computeDeferredNumber(start, end int) NumberProps {
+	raw := r.data[start:end]
+	nr := tokenReader{data: r.data[:end], pos: start + 1, len: end}
+	var result NumberProps
+	if !nr.readNumberProps(r.data[start], &result) || nr.pos != end {
+		// Pre-processing has already checked the number's syntax, so this cannot happen unless the
+		// input was modified; the number is left unparsed, as it would be without computed numbers.
+		return NumberProps{raw: raw, trunc: true}
+	}
+	if r.options.sharedTree {
+		return result
 	}
-	return
+	nValues := r.computedValuesBuffer.NumberValues
+	node := &(*r.structBuffer.values)[r.structBuffer.pos]
+	node.computedType = NumberComputed
+	node.computedIndex = len(*nValues)
+	*nValues = append(*nValues, result)
+	return result
 }
 
 func (r *tokenReader) readString() ([]byte, error) {
@@ -639,6 +716,107 @@ func readHexChar(reader *bytes.Reader) (rune, bool) {
 	return rune(n), true
 }
 
+// computeDeferredString decodes the string at the current node of the pre-processed tree, whose
+// raw content is raw, and records the result in the computed values buffer, so that the string is
+// only decoded once however many times it is read. If the tree is shared with other Readers (see
+// ReaderView), nothing is recorded, and the string is decoded again each time it is read.
+func (r *tokenReader) computeDeferredString(raw []byte) []byte {
+	chars := r.charBuffer
+	start := len(*chars)
+	decoded, ok := unescapeString(*chars, raw)
+	if !ok {
+		// Pre-processing has already checked the escape sequences with validEscapes, so this cannot
+		// happen unless the input was modified.
+		return raw
+	}
+	*chars = decoded
+	value := decoded[start:len(decoded):len(decoded)]
+	if r.options.sharedTree {
+		return value
+	}
+	sValues := r.computedValuesBuffer.StringValues
+	*sValues = append(*sValues, value)
+	node := &(*r.structBuffer.values)[r.structBuffer.pos]
+	node.computedType = StringComputed
+	node.computedIndex = len(*sValues) - 1
+	return value
+}
+
+// validEscapes returns true if every escape sequence in the string content src is one that
+// unescapeString can decode. This is cheaper than decoding the string, for checking a string whose
+// decoding is deferred.
+func validEscapes(src []byte) bool {
+	for i := 0; i < len(src); i++ {
+		if src[i] != '\\' {
+			continue
+		}
+		i++
+		if i >= len(src) {
+			return false
+		}
+		switch src[i] {
+		case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		case 'u':
+			if i+4 >= len(src) {
+				return false
+			}
+			for _, ch := range src[i+1 : i+5] {
+				if !isHexDigit(ch) {
+					return false
+				}
+			}
+			i += 4
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// unescapeString appends the decoded form of the string content src, which is the raw content of a
+// JSON string literal without the surrounding quotes, to dst. It returns false if src contains an
+// invalid escape sequence.
+func unescapeString(dst []byte, src []byte) ([]byte, bool) {
+	for i := 0; i < len(src); i++ {
+		ch := src[i]
+		if ch != '\\' {
+			dst = append(dst, ch)
+			continue
+		}
+		i++
+		if i >= len(src) {
+			return dst, false
+		}
+		switch src[i] {
+		case '"', '\\', '/':
+			dst = append(dst, src[i])
+		case 'b':
+			dst = append(dst, '\b')
+		case 'f':
+			dst = append(dst, '\f')
+		case 'n':
+			dst = append(dst, '\n')
+		case 'r':
+			dst = append(dst, '\r')
+		case 't':
+			dst = append(dst, '\t')
+		case 'u':
+			if i+4 >= len(src) {
+				return dst, false
+			}
+			n, err := strconv.ParseUint(string(src[i+1:i+5]), 16, 32)
+			if err != nil {
+				return dst, false
+			}
+			dst = appendRune(dst, rune(n))
+			i += 4
+		default:
+			return dst, false
+		}
+	}
+	return dst, true
+}
+
 func (r *tokenReader) syntaxErrorOnLastToken(msg string) error { //nolint:unparam
 	return SyntaxError{Message: msg, Offset: r.LastPos()}
 }
@@ -0,0 +1,101 @@
+package jreader
+
+import "strconv"
+
+// NodeRef identifies a value within the pre-processed tree of a JSON document. It is returned by
+// FindAll.
+type NodeRef struct {
+	// Index is the index of the value's node in the tree.
+	Index int
+
+	// Start and End are the character indexes within the input where the value begins and ends, so
+	// that data[Start:End] is the text of the value.
+	Start, End int
+
+	// Depth is the number of arrays and objects that contain the value: 0 for the root value, 1 for
+	// an element or property of the root, and so on.
+	Depth int
+
+	// Pointer is the location of the value as a JSON Pointer (RFC 6901), such as "/items/0/id".
+	Pointer string
+}
+
+// Raw returns the text of the value, given the same input that the tree was built from. The result
+// refers to data without copying it.
+func (n NodeRef) Raw(data []byte) RawMessage {
+	return data[n.Start:n.End]
+}
+
+// Reader returns a new Reader for the value, given the same input that the tree was built from.
+// Offsets in errors from this Reader are relative to the start of the value, not to the start of
+// the input.
+func (n NodeRef) Reader(data []byte) Reader {
+	return NewReader(data[n.Start:n.End])
+}
+
+// FindAll returns every value in a pre-processed JSON document that is the value of an object
+// property called key, at any depth, in the order they appear in the input. Property names are
+// compared after any escape sequences in them are decoded; string values that happen to equal key
+// are not matched. The tree must be the structure buffer of a Reader that has pre-processed data
+// (see Reader.PreProcess).
+//
+// This scans the tree once, without parsing the input again, so it is much cheaper than walking the
+// document with a Reader when only a few values are of interest.
+func FindAll(data []byte, tree []JsonTreeStruct, key string) []NodeRef {
+	if len(tree) == 0 {
+		return nil
+	}
+	type frame struct {
+		end        int // index of the first node after the container's subtree
+		isArray    bool
+		children   int
+		pointerLen int
+	}
+	var (
+		found   []NodeRef
+		stack   []frame
+		pointer []byte
+	)
+	for i := 0; i < tree[0].subTreeSize && i < len(tree); i++ {
+		for len(stack) > 0 && i >= stack[len(stack)-1].end {
+			stack = stack[:len(stack)-1]
+		}
+		node := tree[i]
+		if len(stack) > 0 {
+			parent := &stack[len(stack)-1]
+			pointer = append(pointer[:parent.pointerLen], '/')
+			if parent.isArray {
+				pointer = strconv.AppendInt(pointer, int64(parent.children), 10)
+			} else {
+				pointer = appendPointerToken(pointer, node.key)
+				if propertyNameEquals(node.key, key) {
+					found = append(found, NodeRef{Index: i, Start: node.start, End: node.end,
+						Depth: len(stack), Pointer: string(pointer)})
+				}
+			}
+			parent.children++
+		}
+		if node.subTreeSize > 1 {
+			stack = append(stack, frame{end: i + node.subTreeSize, isArray: node.Kind() == ArrayValue,
+				pointerLen: len(pointer)})
+		}
+	}
+	return found
+}
+
+// appendPointerToken appends a raw property name to a JSON Pointer, decoding any JSON escape
+// sequences and escaping the characters that are special in JSON Pointers.
+func appendPointerToken(pointer []byte, rawName []byte) []byte {
+	name, _ := decodeName(nil, rawName)
+	for _, ch := range name {
+		switch ch {
+		case '~':
+			pointer = append(pointer, '~', '0')
+		case '/':
+			pointer = append(pointer, '~', '1')
+		default:
+			pointer = append(pointer, ch)
+		}
+	}
+	return pointer
+}
@@ -0,0 +1,121 @@
+package jreader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAll(t *testing.T) {
+	data := []byte(`{"id": 1, "items": [{"id": "a", "name": "id"}, ["id", {"id": [2]}], {}],
+		"meta": {"owner": {"id": null}, "a/b~": {"id": true}}, "tags": ["id"]}`)
+	tree := treeForTest(t, data)
+
+	found := FindAll(data, tree, "id")
+	var pointers, raws []string
+	var depths []int
+	for _, n := range found {
+		pointers = append(pointers, n.Pointer)
+		raws = append(raws, string(n.Raw(data)))
+		depths = append(depths, n.Depth)
+		assert.Equal(t, tree[n.Index].start, n.Start)
+	}
+	assert.Equal(t, []string{"/id", "/items/0/id", "/items/1/1/id", "/meta/owner/id", "/meta/a~1b~0/id"}, pointers)
+	assert.Equal(t, []string{`1`, `"a"`, `[2]`, `null`, `true`}, raws)
+	assert.Equal(t, []int{1, 3, 4, 3, 3}, depths)
+
+	r := found[2].Reader(data)
+	arr := r.Array()
+	require.True(t, arr.Next())
+	assert.Equal(t, int64(2), r.Int64())
+	require.False(t, arr.Next())
+	require.NoError(t, r.RequireEOF())
+}
+
+func TestFindAllEscapedKey(t *testing.T) {
+	data := []byte(`{"\u0069d": 5, "x": {"i\"d": 6}}`)
+	found := FindAll(data, treeForTest(t, data), "id")
+	require.Len(t, found, 1)
+	assert.Equal(t, "/id", found[0].Pointer)
+	assert.Equal(t, `5`, string(found[0].Raw(data)))
+
+	found = FindAll(data, treeForTest(t, data), `i"d`)
+	require.Len(t, found, 1)
+	assert.Equal(t, `/x/i"d`, found[0].Pointer)
+}
+
+func TestFindAllNoMatches(t *testing.T) {
+	data := []byte(`["id", {"x": "id"}]`)
+	assert.Empty(t, FindAll(data, treeForTest(t, data), "id"))
+	assert.Nil(t, FindAll(data, nil, "id"))
+
+	scalar := []byte(`"id"`)
+	assert.Nil(t, FindAll(scalar, treeForTest(t, scalar), "id"))
+}
+
+func TestJsonTreeStructAccessors(t *testing.T) {
+	data := []byte(`{"a": [1, "x"], "b!": {}, "c": null, "d": true}`)
+	tree := treeForTest(t, data)
+	require.Len(t, tree, 7)
+
+	type nodeInfo struct {
+		kind       ValueKind
+		key        string
+		text       string
+		subtreeLen int
+	}
+	var nodes []nodeInfo
+	for _, node := range tree {
+		start, end := node.Span()
+		nodes = append(nodes, nodeInfo{node.Kind(), string(node.Key()), string(data[start:end]), node.SubtreeLen()})
+	}
+	assert.Equal(t, []nodeInfo{
+		{ObjectValue, "", string(data), 7},
+		{ArrayValue, "a", `[1, "x"]`, 3},
+		{NumberValue, "", `1`, 1},
+		{StringValue, "", `"x"`, 1},
+		{ObjectValue, `b!`, `{}`, 1},
+		{NullValue, "c", `null`, 1},
+		{BoolValue, "d", `true`, 1},
+	}, nodes)
+}
+
+func TestJsonTreeStructComputedAndDeprecatedAccessors(t *testing.T) {
+	data := []byte(`{"n": 5, "s": "a\tb", "x": "y"}`)
+	var tree []JsonTreeStruct
+	var numbers []NumberProps
+	var strs [][]byte
+	charBuffer := make([]byte, 0)
+	r := NewReaderWithBuffers(data, BufferConfig{StructBuffer: &tree, CharsBuffer: &charBuffer,
+		ComputedValuesBuffer: JsonComputedValues{NumberValues: &numbers, StringValues: &strs}})
+	r.PreProcessWith(PreProcessOptions{ComputeStrings: true, ComputeNumbers: true, BuildIndex: true})
+	require.NoError(t, r.Error())
+	require.Len(t, tree, 4)
+
+	valueType, index := tree[1].Computed()
+	assert.Equal(t, NumberComputed, valueType)
+	n, err := numbers[index].Int64()
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+	valueType, index = tree[2].Computed()
+	assert.Equal(t, StringComputed, valueType)
+	assert.Equal(t, "a\tb", string(strs[index]))
+	valueType, _ = JsonTreeStruct{computedType: numberDeferred}.Computed()
+	assert.Equal(t, NothingComputed, valueType, "a deferred number is not computed yet")
+
+	for _, node := range tree {
+		start, end := node.Span()
+		assert.Equal(t, start, node.Start())
+		assert.Equal(t, end, node.End())
+		assert.Equal(t, node.SubtreeLen(), node.SubTreeSize())
+		assert.Equal(t, node.Key(), node.AssocValue())
+		valueType, index := node.Computed()
+		assert.Equal(t, valueType, node.ComputedValueType())
+		assert.Equal(t, index, node.ComputedValueIndex())
+	}
+
+	assert.Equal(t, 0, r.tr.structBuffer.Position())
+	assert.Equal(t, 0, r.tr.structBuffer.Pos())
+	assert.Same(t, &tree, r.tr.structBuffer.Values())
+}